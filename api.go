@@ -20,6 +20,7 @@ import (
     `io`
 
     `github.com/bytedance/sonic/ast`
+    `github.com/bytedance/sonic/encoder`
     `github.com/bytedance/sonic/internal/rt`
 )
 
@@ -33,13 +34,55 @@ const (
 // APIKind is the kind of API, 0 is std json, 1 is sonic.
 const APIKind = apiKind
 
-// Config is a combination of sonic/encoder.Options and sonic/decoder.Options
+const (
+    // TimeFormatUnix is the Config.TimeFormat of the integer seconds since the Unix epoch.
+    TimeFormatUnix = "unix"
+    // TimeFormatUnixMilli is the Config.TimeFormat of the integer milliseconds since the Unix epoch.
+    TimeFormatUnixMilli = "unixmilli"
+
+    // DurationFormatString is the Config.DurationFormat of strings like `"1.5s"`.
+    DurationFormatString = "string"
+
+    // FloatNotationPlain is the Config.FloatNotation that never uses exponents.
+    FloatNotationPlain = "plain"
+    // FloatNotationExponent is the Config.FloatNotation that always uses exponents.
+    FloatNotationExponent = "exponent"
+
+    // Int64FormatString is the Config.Int64Format of strings like `"123"`.
+    Int64FormatString = "string"
+    // Int64FormatUnsafeString is the Config.Int64Format of strings only out of ±(2^53-1),
+    // the safe range of JavaScript numbers.
+    Int64FormatUnsafeString = "unsafe-string"
+
+    // UnsupportedTypesError is the Config.UnsupportedTypes that returns errors, as by default.
+    UnsupportedTypesError = "error"
+    // UnsupportedTypesNull is the Config.UnsupportedTypes that writes `null`.
+    UnsupportedTypesNull = "null"
+    // UnsupportedTypesSkip is the Config.UnsupportedTypes that leaves out struct fields,
+    // and writes `null` for the other values.
+    UnsupportedTypesSkip = "skip"
+
+    // RedactModeMask is the Config.RedactMode that writes `"***"`, as by default.
+    RedactModeMask = "mask"
+    // RedactModeHash is the Config.RedactMode that writes `"sha256:<hex>"` of the encoded values.
+    RedactModeHash = "hash"
+    // RedactModeOmit is the Config.RedactMode that leaves out the fields.
+    RedactModeOmit = "omit"
+)
+
+// Config is a combination of sonic/encoder.Options and sonic/decoder.Options.
+// The fallback implementation (encoding/json) only follows EscapeHTML, UseNumber,
+// DisallowUnknownFields, Canonical and FieldMask, other options have no effect on it.
 type Config struct {
     // EscapeHTML indicates encoder to escape all HTML characters 
     // after serializing into JSON (see https://pkg.go.dev/encoding/json#HTMLEscape).
     // WARNING: This hurts performance A LOT, USE WITH CARE.
     EscapeHTML                    bool
 
+    // EscapeNonASCII indicates encoder to escape all non-ASCII characters as \uXXXX
+    // (with surrogate pairs beyond the BMP) while quoting strings, so the output is pure ASCII.
+    EscapeNonASCII                bool
+
     // SortMapKeys indicates encoder that the keys of a map needs to be sorted 
     // before serializing into JSON.
     // WARNING: This hurts performance A LOT, USE WITH CARE.
@@ -65,6 +108,10 @@ type Config struct {
     // json.Number instead of as a float64.
     UseNumber                     bool
 
+    // UseBigInt indicates decoder to unmarshal an integer that overflows int64 into an
+    // interface{} as a *big.Int, instead of as a float64 or an int64 which loses digits.
+    UseBigInt                     bool
+
     // UseUnicodeErrors indicates decoder to return an error when encounter invalid
     // UTF-8 escape sequences.
     UseUnicodeErrors              bool
@@ -94,6 +141,85 @@ type Config struct {
 
     // Encode Infinity or Nan float into `null`, instead of returning an error.
     EncodeNullForInfOrNan bool
+
+    // DetectCycles indicates encoder to track the pointers, maps and slices nested deeper
+    // than option.CycleDetectionDepth, and to return a json.UnsupportedValueError naming
+    // the cyclic type once a value refers to itself.
+    DetectCycles bool
+
+    // CollectErrors indicates decoder to go on decoding after mismatched types, overflows
+    // and invalid values, and return all of them with their JSON paths as decoder.DecodeErrors.
+    CollectErrors bool
+
+    // WeaklyTyped indicates decoder to coerce values across JSON types, such as `"42"` into
+    // an int, `42` into a string, `1` or `"true"` into a bool, `1.0` into an int, a single value
+    // into a one-element slice and `""` into a nil pointer. Use decoder.Decoder.DecodeCoercions
+    // with the decoder.OptionCoerce* options to report them or enable only some kinds.
+    WeaklyTyped bool
+
+    // TimeFormat is the format of time.Time for encoder and decoder, which is either empty or
+    // time.RFC3339Nano for RFC 3339 strings, TimeFormatUnix, TimeFormatUnixMilli, or a custom
+    // layout of time.Format. The decoder also accepts RFC 3339 strings with the unix formats.
+    TimeFormat string
+
+    // DurationFormat is the format of time.Duration for encoder and decoder, which is either
+    // empty for integers of nanoseconds, or DurationFormatString for strings like `"1.5s"`,
+    // in which case the decoder accepts both of them.
+    DurationFormat string
+
+    // FloatPrecision indicates encoder to round floats to at most that many digits after the
+    // decimal point if it is positive, and FloatSignificantDigits to at most that many significant
+    // digits, only one of them can be set. Floats are written in their shortest text by default.
+    FloatPrecision int
+    FloatSignificantDigits int
+
+    // FloatNotation is the notation of floats for encoder, which is either empty for the one of
+    // encoding/json, FloatNotationPlain or FloatNotationExponent.
+    FloatNotation string
+
+    // FloatTrailingZero indicates encoder to write whole floats like `1.0` instead of `1`.
+    FloatTrailingZero bool
+
+    // NaNInfString indicates encoder to write NaN and ±Inf floats as the strings `"NaN"`,
+    // `"Infinity"` and `"-Infinity"` instead of returning an error, and decoder to accept them.
+    NaNInfString bool
+
+    // Int64Format is the format of the 64-bit integers for encoder, which is either empty
+    // for numbers, Int64FormatString or Int64FormatUnsafeString. With either of them,
+    // decoder also accepts integers as strings like `"123"`.
+    Int64Format string
+
+    // UnsupportedTypes is the policy of encoder for the values of unsupported types, like channels,
+    // functions, maps of unsupported keys and complex numbers without ComplexArray, which is either
+    // empty, UnsupportedTypesError, UnsupportedTypesNull or UnsupportedTypesSkip.
+    UnsupportedTypes string
+
+    // ComplexArray indicates encoder to write complex64 and complex128 as `[re, im]`,
+    // in the formats of floats.
+    ComplexArray bool
+
+    // ErrorString indicates encoder to write the values of the `error` interface type as their
+    // Error() strings, unless they implement json.Marshaler or encoding.TextMarshaler.
+    ErrorString bool
+
+    // Redact indicates encoder to redact the struct fields tagged with `json:",redact"`,
+    // by the RedactMode. Otherwise they are encoded as usual.
+    Redact bool
+
+    // RedactMode is how the redacted fields are written, which is either empty,
+    // RedactModeMask, RedactModeHash or RedactModeOmit.
+    RedactMode string
+
+    // Canonical indicates encoder to write the JSON Canonicalization Scheme of RFC 8785 for signing,
+    // which has no whitespace, object keys sorted by UTF-16 code units, numbers in the ES6 notation
+    // of IEEE doubles, and only the mandatory escapes of strings. EscapeHTML has no effect with it.
+    // Objects with duplicate keys are rejected. MarshalIndent indents the canonical text,
+    // so its output keeps the order of keys and numbers, but is not canonical anymore.
+    Canonical bool
+
+    // FieldMask selects the parts of values for encoder to write, see encoder.NewFieldMask.
+    // It applies to Marshal, MarshalToString and MarshalIndent, and is mostly given per call by WithFieldMask.
+    FieldMask *encoder.FieldMask
 }
  
 var (
@@ -138,6 +264,55 @@ type API interface {
     NewDecoder(reader io.Reader) Decoder
     // Valid validates the JSON-encoded bytes and reports if it is valid
     Valid(data []byte) bool
+    // With returns an API of the config changed by opts, which shares the compiled codes with this one,
+    // so it is cheap enough to be called per request.
+    With(opts ...Option) API
+}
+
+// Option changes a Config for a single call, see API.With.
+type Option func(*Config)
+
+// WithSortMapKeys returns the option to set Config.SortMapKeys.
+func WithSortMapKeys(on bool) Option {
+    return func(cfg *Config) { cfg.SortMapKeys = on }
+}
+
+// WithEscapeHTML returns the option to set Config.EscapeHTML.
+func WithEscapeHTML(on bool) Option {
+    return func(cfg *Config) { cfg.EscapeHTML = on }
+}
+
+// WithValidateString returns the option to set Config.ValidateString.
+func WithValidateString(on bool) Option {
+    return func(cfg *Config) { cfg.ValidateString = on }
+}
+
+// WithUseNumber returns the option to set Config.UseNumber.
+func WithUseNumber(on bool) Option {
+    return func(cfg *Config) { cfg.UseNumber = on }
+}
+
+// WithUseInt64 returns the option to set Config.UseInt64.
+func WithUseInt64(on bool) Option {
+    return func(cfg *Config) { cfg.UseInt64 = on }
+}
+
+// WithDisallowUnknownFields returns the option to set Config.DisallowUnknownFields.
+func WithDisallowUnknownFields(on bool) Option {
+    return func(cfg *Config) { cfg.DisallowUnknownFields = on }
+}
+
+// WithFieldMask returns the option to set Config.FieldMask.
+func WithFieldMask(mask *encoder.FieldMask) Option {
+    return func(cfg *Config) { cfg.FieldMask = mask }
+}
+
+// with returns cfg changed by opts.
+func (cfg Config) with(opts []Option) Config {
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    return cfg
 }
 
 // Encoder encodes JSON into io.Writer
@@ -175,6 +350,11 @@ func Marshal(val interface{}) ([]byte, error) {
     return ConfigDefault.Marshal(val)
 }
 
+// MarshalWithOptions is like Marshal, with the default config changed by opts.
+func MarshalWithOptions(val interface{}, opts ...Option) ([]byte, error) {
+    return ConfigDefault.With(opts...).Marshal(val)
+}
+
 // MarshalIndent is like Marshal but applies Indent to format the output.
 // Each JSON element in the output will begin on a new line beginning with prefix
 // followed by one or more copies of indent according to the indentation nesting.
@@ -194,6 +374,11 @@ func Unmarshal(buf []byte, val interface{}) error {
     return ConfigDefault.Unmarshal(buf, val)
 }
 
+// UnmarshalWithOptions is like Unmarshal, with the default config changed by opts.
+func UnmarshalWithOptions(buf []byte, val interface{}, opts ...Option) error {
+    return ConfigDefault.With(opts...).Unmarshal(buf, val)
+}
+
 // UnmarshalString is like Unmarshal, except buf is a string.
 func UnmarshalString(buf string, val interface{}) error {
     return ConfigDefault.UnmarshalFromString(buf, val)
@@ -17,8 +17,11 @@
 package sonic
 
 import (
+    `encoding/json`
     `testing`
 
+    `github.com/bytedance/sonic/ast`
+    `github.com/bytedance/sonic/encoder`
     `github.com/stretchr/testify/require`
 )
 
@@ -67,3 +70,116 @@ func TestIdent(t *testing.T) {
   "Age": 20
 }`, string(out))
 }
+
+type inlineMap struct {
+    A     int                    `json:"a"`
+    Extra map[string]interface{} `json:",inline"`
+    B     string                 `json:"b"`
+}
+
+type inlineNode struct {
+    A     int      `json:"a"`
+    Extra ast.Node `json:",inline"`
+}
+
+func TestInlineField(t *testing.T) {
+    for _, api := range []API{ConfigDefault, ConfigStd} {
+        var m inlineMap
+        require.NoError(t, api.UnmarshalFromString(`{"a":1,"x":[1,2],"b":"s","y":{"z":null}}`, &m))
+        require.Equal(t, 1, m.A)
+        require.Equal(t, "s", m.B)
+        require.Equal(t, map[string]interface{}{"x": []interface{}{float64(1), float64(2)}, "y": map[string]interface{}{"z": nil}}, m.Extra)
+
+        out, err := api.Marshal(inlineMap{A: 1, Extra: map[string]interface{}{"x": 1}, B: "s"})
+        require.NoError(t, err)
+        require.Equal(t, `{"a":1,"x":1,"b":"s"}`, string(out))
+
+        out, err = api.Marshal(inlineMap{A: 1, B: "s"})
+        require.NoError(t, err)
+        require.Equal(t, `{"a":1,"b":"s"}`, string(out))
+
+        out, err = api.Marshal(inlineMap{A: 1, Extra: map[string]interface{}{"a": 2, "b": "t"}, B: "s"})
+        require.NoError(t, err)
+        require.Equal(t, `{"a":1,"b":"s"}`, string(out))
+
+        var n inlineNode
+        require.NoError(t, api.UnmarshalFromString(`{"x":[1,2],"a":1,"y":{"z":null}}`, &n))
+        require.Equal(t, 1, n.A)
+        out, err = api.Marshal(n)
+        require.NoError(t, err)
+        require.Equal(t, `{"a":1,"x":[1,2],"y":{"z":null}}`, string(out))
+
+        out, err = api.Marshal(inlineNode{A: 1})
+        require.NoError(t, err)
+        require.Equal(t, `{"a":1}`, string(out))
+    }
+}
+
+type inlineTwice struct {
+    X map[string]int `json:",inline"`
+    Y map[string]int `json:",inline"`
+}
+
+type inlineStruct struct {
+    A     int `json:"a"`
+    Inner struct {
+        B int `json:"b"`
+    } `json:",inline"`
+}
+
+func TestInlineField_Invalid(t *testing.T) {
+    for _, api := range []API{ConfigDefault, ConfigStd} {
+        _, err := api.Marshal(inlineTwice{})
+        require.EqualError(t, err, `json: fields "X" and "Y" of sonic.inlineTwice are both tagged inline, at most one is allowed`)
+        require.EqualError(t, api.UnmarshalFromString(`{}`, &inlineTwice{}), err.Error())
+
+        _, err = api.Marshal(inlineStruct{})
+        require.Error(t, err)
+        require.Contains(t, err.Error(), `json: the inline field "Inner" of sonic.inlineStruct must be a map with string keys or a type like ast.Node`)
+        require.EqualError(t, api.UnmarshalFromString(`{"a":1,"b":2}`, &inlineStruct{}), err.Error())
+    }
+}
+
+func TestWithOptions(t *testing.T) {
+    v := map[string]interface{}{"b": []int{1}, "a": "<>", "c": map[string]int{"x": 1, "y": 2}}
+    api := ConfigStd.With(WithEscapeHTML(false))
+    out, err := api.Marshal(v)
+    require.NoError(t, err)
+    require.Equal(t, `{"a":"<>","b":[1],"c":{"x":1,"y":2}}`, string(out))
+    out, err = ConfigStd.Marshal(v)
+    require.NoError(t, err)
+    require.Equal(t, `{"a":"\u003c\u003e","b":[1],"c":{"x":1,"y":2}}`, string(out))
+
+    mask, err := encoder.NewFieldMask("a", "c.y")
+    require.NoError(t, err)
+    out, err = MarshalWithOptions(v, WithSortMapKeys(true), WithFieldMask(mask))
+    require.NoError(t, err)
+    require.Equal(t, `{"a":"<>","c":{"y":2}}`, string(out))
+    out, err = ConfigDefault.With(WithFieldMask(mask), WithSortMapKeys(true)).MarshalIndent(v, "", " ")
+    require.NoError(t, err)
+    require.Equal(t, "{\n \"a\": \"<>\",\n \"c\": {\n  \"y\": 2\n }\n}", string(out))
+
+    var x interface{}
+    require.NoError(t, UnmarshalWithOptions([]byte(`{"n":1}`), &x, WithUseNumber(true)))
+    require.Equal(t, map[string]interface{}{"n": json.Number("1")}, x)
+    var y struct{ M int }
+    require.Error(t, ConfigDefault.With(WithDisallowUnknownFields(true)).UnmarshalFromString(`{"n":1}`, &y))
+    require.NoError(t, ConfigDefault.UnmarshalFromString(`{"n":1}`, &y))
+}
+
+func TestCanonical(t *testing.T) {
+    api := Config{Canonical: true, EscapeHTML: true}.Froze()
+    node, err := GetFromString(`{"b": [1.0, "<>"], "a": {"d": 2e0, "c": null}}`)
+    require.NoError(t, err)
+    for _, v := range []interface{}{&node, map[string]interface{}{"b": []interface{}{1, "<>"}, "a": map[string]interface{}{"d": 2, "c": nil}}} {
+        out, err := api.Marshal(v)
+        require.NoError(t, err)
+        require.Equal(t, `{"a":{"c":null,"d":2},"b":[1,"<>"]}`, string(out))
+    }
+
+    out, err := api.MarshalIndent(map[string]float64{"b": 1.0, "a": 2e0}, "", " ")
+    require.NoError(t, err)
+    require.Equal(t, "{\n \"a\": 2,\n \"b\": 1\n}", string(out))
+    _, err = api.Marshal(json.RawMessage(`{"a":1,"a":2}`))
+    require.Error(t, err)
+}
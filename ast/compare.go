@@ -0,0 +1,409 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+    `sort`
+    `strconv`
+    `sync`
+
+    `github.com/bytedance/sonic/internal/native/types`
+    `github.com/bytedance/sonic/internal/rt`
+)
+
+// Equal reports whether the node and other represent the same JSON value.
+// The keys of objects are compared regardless of their order,
+// and numbers are compared by their decimal values, so `1.0` equals `1e0`.
+//
+// Raw nodes of the same json text are equal without being parsed,
+// otherwise the children are loaded as by other methods of Node.
+func (self *Node) Equal(other *Node) (bool, error) {
+    if err := self.Check(); err != nil {
+        return false, err
+    }
+    if err := other.Check(); err != nil {
+        return false, err
+    }
+    return equalNode(self, other)
+}
+
+// Hash returns a hash of the JSON value of the node, which is the same for Equal nodes,
+// regardless of the key order of objects. It does not change across processes,
+// so it can be used as a persistent key of the content.
+func (self *Node) Hash() (uint64, error) {
+    if err := self.Check(); err != nil {
+        return 0, err
+    }
+    return hashNode(self)
+}
+
+// Clone returns a deep copy of the node, which shares neither the source json
+// nor the parser state with the node, so the source buffer can be reused or released.
+// Raw nodes are copied as raw, and values of V_ANY nodes are shared.
+func (self *Node) Clone() (Node, error) {
+    if err := self.Check(); err != nil {
+        return Node{}, err
+    }
+    return cloneNode(self)
+}
+
+/** Equality **/
+
+func equalNode(a *Node, b *Node) (bool, error) {
+    if a == b {
+        return true, nil
+    }
+
+    /* compare the raw json texts first */
+    if a.isRaw() && b.isRaw() {
+        if ra, rb := rawString(a), rawString(b); ra == rb {
+            return true, nil
+        }
+    }
+
+    var err error
+    if a, err = loadValue(a); err != nil {
+        return false, err
+    }
+    if b, err = loadValue(b); err != nil {
+        return false, err
+    }
+    if a.itype() != b.itype() {
+        return false, nil
+    }
+
+    switch a.itype() {
+        case _V_NONE, types.V_NULL, types.V_TRUE, types.V_FALSE:
+            return true, nil
+        case types.V_STRING:
+            return a.toString() == b.toString(), nil
+        case _V_NUMBER:
+            return canonicalNumber(a.toString()) == canonicalNumber(b.toString()), nil
+        case types.V_ARRAY:
+            return equalArray(a, b)
+        case types.V_OBJECT:
+            return equalObject(a, b)
+        default:
+            return false, ErrUnsupportType
+    }
+}
+
+func equalArray(a *Node, b *Node) (bool, error) {
+    if err := a.skipAllIndex(); err != nil {
+        return false, err
+    }
+    if err := b.skipAllIndex(); err != nil {
+        return false, err
+    }
+    if a.len() != b.len() {
+        return false, nil
+    }
+
+    ia, ib := a.values(), b.values()
+    for va, vb := ia.next(), ib.next(); va != nil && vb != nil; va, vb = ia.next(), ib.next() {
+        if ok, err := equalNode(va, vb); !ok || err != nil {
+            return false, err
+        }
+    }
+    return true, nil
+}
+
+func equalObject(a *Node, b *Node) (bool, error) {
+    if err := a.skipAllKey(); err != nil {
+        return false, err
+    }
+    if err := b.skipAllKey(); err != nil {
+        return false, err
+    }
+    if a.len() != b.len() {
+        return false, nil
+    }
+
+    /* match the members in the order of keys */
+    pa, pb := sortedPairs(a), sortedPairs(b)
+    if len(pa) != len(pb) {
+        return false, nil
+    }
+    for i := range pa {
+        if pa[i].Key != pb[i].Key {
+            return false, nil
+        }
+        if ok, err := equalNode(&pa[i].Value, &pb[i].Value); !ok || err != nil {
+            return false, err
+        }
+    }
+    return true, nil
+}
+
+func sortedPairs(n *Node) []*Pair {
+    ret := make([]*Pair, 0, n.len())
+    it := n.properties()
+    for p := it.next(); p != nil; p = it.next() {
+        ret = append(ret, p)
+    }
+    sort.SliceStable(ret, func(i, j int) bool { return ret[i].Key < ret[j].Key })
+    return ret
+}
+
+// loadValue parses the raw node, and returns the node of the json value
+// of a V_ANY node.
+func loadValue(n *Node) (*Node, error) {
+    if err := n.checkRaw(); err != nil {
+        return nil, err
+    }
+    if !n.isAny() {
+        return n, nil
+    }
+    buf, err := n.MarshalJSON()
+    if err != nil {
+        return nil, err
+    }
+    ret := NewRaw(rt.Mem2Str(buf))
+    return &ret, ret.checkRaw()
+}
+
+func rawString(n *Node) string {
+    lock := n.rlock()
+    ret := n.toString()
+    if lock {
+        n.runlock()
+    }
+    return ret
+}
+
+// canonicalNumber rewrites the json number s as `[-]digitsEexp`, where the digits
+// have no leading or trailing zeros, so that numbers of the same value have the same text.
+func canonicalNumber(s string) string {
+    var exp int
+    var digits []byte
+
+    /* split the sign, digits and exponent */
+    i, neg := 0, len(s) != 0 && s[0] == '-'
+    if neg {
+        i++
+    }
+    for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+        digits = append(digits, s[i])
+    }
+    if i < len(s) && s[i] == '.' {
+        for i++; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+            digits = append(digits, s[i])
+            exp--
+        }
+    }
+    if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+        e, err := strconv.Atoi(s[i + 1:])
+        if err != nil {
+            return s
+        }
+        exp += e
+        i = len(s)
+    }
+    if i != len(s) {
+        return s
+    }
+
+    /* strip the zeros */
+    for len(digits) != 0 && digits[0] == '0' {
+        digits = digits[1:]
+    }
+    if len(digits) == 0 {
+        return "0"
+    }
+    for digits[len(digits) - 1] == '0' {
+        digits = digits[:len(digits) - 1]
+        exp++
+    }
+
+    buf := make([]byte, 0, len(digits) + 8)
+    if neg {
+        buf = append(buf, '-')
+    }
+    buf = append(buf, digits...)
+    buf = append(buf, 'e')
+    return string(strconv.AppendInt(buf, int64(exp), 10))
+}
+
+/** Hashing **/
+
+const (
+    _FNV_OFFSET = 14695981039346656037
+    _FNV_PRIME  = 1099511628211
+)
+
+func hashNode(n *Node) (uint64, error) {
+    n, err := loadValue(n)
+    if err != nil {
+        return 0, err
+    }
+
+    h := hashByte(_FNV_OFFSET, byte(n.itype()))
+    switch n.itype() {
+        case _V_NONE, types.V_NULL, types.V_TRUE, types.V_FALSE:
+            return h, nil
+        case types.V_STRING:
+            return hashString(h, n.toString()), nil
+        case _V_NUMBER:
+            return hashString(h, canonicalNumber(n.toString())), nil
+        case types.V_ARRAY:
+            return hashArray(h, n)
+        case types.V_OBJECT:
+            return hashObject(h, n)
+        default:
+            return 0, ErrUnsupportType
+    }
+}
+
+func hashArray(h uint64, n *Node) (uint64, error) {
+    if err := n.skipAllIndex(); err != nil {
+        return 0, err
+    }
+    it := n.values()
+    for v := it.next(); v != nil; v = it.next() {
+        vh, err := hashNode(v)
+        if err != nil {
+            return 0, err
+        }
+        h = hashUint64(h, vh)
+    }
+    return h, nil
+}
+
+func hashObject(h uint64, n *Node) (uint64, error) {
+    if err := n.skipAllKey(); err != nil {
+        return 0, err
+    }
+
+    /* the sum of members does not depend on the key order */
+    var c, s uint64
+    it := n.properties()
+    for p := it.next(); p != nil; p = it.next() {
+        vh, err := hashNode(&p.Value)
+        if err != nil {
+            return 0, err
+        }
+        s += mix64(hashUint64(hashString(_FNV_OFFSET, p.Key), vh))
+        c++
+    }
+    return hashUint64(hashUint64(h, c), s), nil
+}
+
+func hashByte(h uint64, c byte) uint64 {
+    return (h ^ uint64(c)) * _FNV_PRIME
+}
+
+func hashString(h uint64, s string) uint64 {
+    for i := 0; i < len(s); i++ {
+        h = hashByte(h, s[i])
+    }
+    return hashByte(h, 0xff)
+}
+
+func hashUint64(h uint64, v uint64) uint64 {
+    for i := 0; i < 8; i++ {
+        h = hashByte(h, byte(v >> (i * 8)))
+    }
+    return h
+}
+
+// mix64 is the finalizer of SplitMix64.
+func mix64(h uint64) uint64 {
+    h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+    h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+    return h ^ (h >> 31)
+}
+
+/** Cloning **/
+
+func cloneNode(n *Node) (Node, error) {
+    /* raw nodes stay raw */
+    lock := n.rlock()
+    if n.isRaw() {
+        ret := newRawNode(cloneString(n.toString()), n.t & _MASK_RAW, n.m != nil)
+        if lock {
+            n.runlock()
+        }
+        return ret, nil
+    }
+    if lock {
+        n.runlock()
+    }
+
+    var err error
+    var ret Node
+    switch n.itype() {
+        case _V_NONE, types.V_NULL, types.V_TRUE, types.V_FALSE:
+            ret = Node{t: n.itype()}
+        case types.V_STRING:
+            ret = NewString(cloneString(n.toString()))
+        case _V_NUMBER:
+            ret = NewNumber(cloneString(n.toString()))
+        case types.V_ARRAY:
+            ret, err = cloneArray(n)
+        case types.V_OBJECT:
+            ret, err = cloneObject(n)
+        case _V_ANY:
+            ret = NewAny(n.packAny())
+        case V_ERROR:
+            return Node{}, n.Check()
+        default:
+            return Node{}, ErrUnsupportType
+    }
+    if err != nil {
+        return Node{}, err
+    }
+    if n.m != nil {
+        ret.m = new(sync.RWMutex)
+    }
+    return ret, nil
+}
+
+func cloneArray(n *Node) (Node, error) {
+    if err := n.skipAllIndex(); err != nil {
+        return Node{}, err
+    }
+    s := new(linkedNodes)
+    it := n.values()
+    for v := it.next(); v != nil; v = it.next() {
+        c, err := cloneNode(v)
+        if err != nil {
+            return Node{}, err
+        }
+        s.Push(c)
+    }
+    return newArray(s), nil
+}
+
+func cloneObject(n *Node) (Node, error) {
+    if err := n.skipAllKey(); err != nil {
+        return Node{}, err
+    }
+    s := new(linkedPairs)
+    it := n.properties()
+    for p := it.next(); p != nil; p = it.next() {
+        c, err := cloneNode(&p.Value)
+        if err != nil {
+            return Node{}, err
+        }
+        s.Push(NewPair(cloneString(p.Key), c))
+    }
+    return newObject(s), nil
+}
+
+func cloneString(s string) string {
+    return string(rt.Str2Mem(s))
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ast
+
+import (
+	"testing"
+
+	"github.com/bytedance/sonic/internal/rt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeEqual(t *testing.T) {
+    cases := []struct {
+        a, b string
+        eq   bool
+    }{
+        {`null`, `null`, true},
+        {`true`, `false`, false},
+        {`1`, `1.0`, true},
+        {`100`, `1e2`, true},
+        {`-0.0`, `0`, true},
+        {`0.1`, `1E-1`, true},
+        {`1`, `-1`, false},
+        {`12345678901234567890`, `12345678901234567891`, false},
+        {`"ab"`, `"ab"`, true},
+        {`"1"`, `1`, false},
+        {`[1,2]`, `[2,1]`, false},
+        {`[1,[2]]`, `[1.0, [2e0]]`, true},
+        {`[1]`, `[1,1]`, false},
+        {`{"a":1,"b":[{"c":null,"d":"x"}]}`, `{"b":[{"d":"x","c":null}],"a":1.00}`, true},
+        {`{"a":1}`, `{"a":1,"b":2}`, false},
+        {`{"a":1}`, `{"b":1}`, false},
+        {`{}`, `[]`, false},
+    }
+    for _, c := range cases {
+        // raw, lazy and fully loaded nodes
+        for _, load := range []func(string) Node{NewRaw, lazyNode, loadedNode} {
+            a, b := load(c.a), NewRaw(c.b)
+            eq, err := a.Equal(&b)
+            require.NoError(t, err, c.a)
+            require.Equal(t, c.eq, eq, "%s == %s", c.a, c.b)
+
+            ha, err := a.Hash()
+            require.NoError(t, err)
+            hb, err := b.Hash()
+            require.NoError(t, err)
+            require.Equal(t, c.eq, ha == hb, "hash(%s) == hash(%s)", c.a, c.b)
+        }
+    }
+
+    // raw nodes of the same text are not parsed
+    a, b := NewRaw(`{"a":[1,2]}`), NewRaw(`{"a":[1,2]}`)
+    eq, err := a.Equal(&b)
+    require.NoError(t, err)
+    require.True(t, eq)
+    require.True(t, a.isRaw() && b.isRaw())
+
+    // built nodes and values
+    c := NewObject([]Pair{NewPair("a", NewArray([]Node{NewNumber("1"), NewAny(2)}))})
+    eq, err = c.Equal(&a)
+    require.NoError(t, err)
+    require.True(t, eq)
+
+    // errors
+    e := NewRaw(`{"a":`)
+    _, err = e.Equal(&a)
+    require.Error(t, err)
+    _, err = a.Equal(nil)
+    require.Error(t, err)
+    l := lazyNode(`{"a":[1,}`)
+    _, err = l.Hash()
+    require.Error(t, err)
+}
+
+func TestNodeClone(t *testing.T) {
+    src := []byte(`{"a":[1,"x",{"b":null}],"c":{"d":true},"e":"é"}`)
+    for _, load := range []func(string) Node{NewRaw, lazyNode, loadedNode} {
+        n := load(rt.Mem2Str(src))
+        c, err := n.Clone()
+        require.NoError(t, err)
+
+        // the clone does not refer to the source
+        exp := loadedNode(string(src))
+        for i := range src {
+            src[i] = ' '
+        }
+        eq, err := c.Equal(&exp)
+        require.NoError(t, err)
+        require.True(t, eq)
+        copy(src, `{"a":[1,"x",{"b":null}],"c":{"d":true},"e":"é"}`)
+
+        // nor shares the children
+        _, err = c.Get("c").Set("d", NewBool(false))
+        require.NoError(t, err)
+        v, err := n.GetByPath("c", "d").Bool()
+        require.NoError(t, err)
+        require.True(t, v)
+    }
+
+    n := NewRawConcurrentRead(`[1]`)
+    c, err := n.Clone()
+    require.NoError(t, err)
+    require.NotNil(t, c.m)
+
+    e := NewRaw(`[1,`)
+    _, err = e.Clone()
+    require.Error(t, err)
+}
+
+func lazyNode(src string) Node {
+    n, err := NewSearcher(src).GetByPath()
+    if err != nil {
+        return *newError(0, err.Error())
+    }
+    return n
+}
+
+func loadedNode(src string) Node {
+    n := NewRaw(src)
+    if err := n.LoadAll(); err != nil {
+        return *newError(0, err.Error())
+    }
+    return n
+}
@@ -17,9 +17,12 @@
 package ast
 
 import (
+	"io"
+	"strings"
 	"sync"
 	"unicode/utf8"
 
+	"github.com/bytedance/sonic/internal/encoder/alg"
 	"github.com/bytedance/sonic/internal/rt"
     "github.com/bytedance/sonic/option"
 )
@@ -272,3 +275,287 @@ func (self *Node) encodeObject(buf *[]byte) error {
     *buf = append(*buf, '}')
     return nil
 }
+
+// EncodeOptions controls the output of Node.Encode and Node.AppendJSON.
+// The zero value writes the same json as Node.MarshalJSON.
+//
+// Raw json is rewritten as text without being parsed unless the keys are sorted,
+// so its strings keep the escapes of the source. It is copied as is with the zero
+// value, otherwise its strings and other scalars are skipped natively and escaped
+// in bulk, while the whitespace and the brackets between them are rewritten in Go,
+// as there is no native routine to indent json. With SortKeys it is parsed into
+// nodes at first, which costs as much as loading them. Values of V_ANY nodes are
+// encoded and then rewritten the same way.
+type EncodeOptions struct {
+    // Prefix and Indent indicate encoder to begin every element on a new line,
+    // which starts with Prefix followed by copies of Indent as in json.MarshalIndent.
+    // The output is compact if both are empty.
+    Prefix string
+    Indent string
+
+    // SortKeys indicates encoder to write the keys of objects in ascending order.
+    SortKeys bool
+
+    // EscapeHTML indicates encoder to escape the HTML characters in strings as in json.HTMLEscape.
+    EscapeHTML bool
+
+    // EscapeNonASCII indicates encoder to escape the non-ASCII characters in strings as \uXXXX.
+    EscapeNonASCII bool
+}
+
+// Encode writes the json of the node to w with opts, which can be nil.
+// The json is written in chunks of about option.DefaultAstBufferSize bytes,
+// so a large tree is never held in a single buffer.
+func (self *Node) Encode(w io.Writer, opts *EncodeOptions) error {
+    buf := newBuffer()
+    enc := newNodeEncoder(*buf, w, opts)
+    err := enc.encode(self)
+    if err == nil {
+        err = enc.flush(true)
+    }
+    *buf = enc.buf
+    freeBuffer(buf)
+    return err
+}
+
+// AppendJSON appends the json of the node to buf with opts, which can be nil.
+func (self *Node) AppendJSON(buf []byte, opts *EncodeOptions) ([]byte, error) {
+    enc := newNodeEncoder(buf, nil, opts)
+    err := enc.encode(self)
+    return enc.buf, err
+}
+
+type nodeEncoder struct {
+    EncodeOptions
+    buf    []byte
+    tmp    []byte
+    w      io.Writer
+    depth  int
+    pretty bool
+    plain  bool
+}
+
+func newNodeEncoder(buf []byte, w io.Writer, opts *EncodeOptions) *nodeEncoder {
+    ret := &nodeEncoder{buf: buf, w: w}
+    if opts != nil {
+        ret.EncodeOptions = *opts
+    }
+    ret.pretty = ret.Prefix != "" || ret.Indent != ""
+    ret.plain = ret.EncodeOptions == EncodeOptions{}
+    return ret
+}
+
+func (self *nodeEncoder) encode(n *Node) error {
+    if n == nil {
+        self.buf = append(self.buf, strNull...)
+        return nil
+    }
+
+    /* raw json is rewritten without being parsed */
+    lock := n.rlock()
+    if n.isRaw() {
+        raw := n.toString()
+        if lock {
+            n.runlock()
+        }
+        return self.encodeText(raw)
+    }
+    if lock {
+        n.runlock()
+    }
+
+    switch int(n.itype()) {
+        case V_NONE  : return ErrNotExist
+        case V_ERROR : return n.Check()
+        case V_NULL  : self.buf = append(self.buf, strNull...)
+        case V_TRUE  : self.buf = append(self.buf, bytesTrue...)
+        case V_FALSE : self.buf = append(self.buf, bytesFalse...)
+        case V_NUMBER: self.buf = append(self.buf, n.toString()...)
+        case V_STRING: self.encodeString(n.toString())
+        case V_ARRAY : return self.encodeArray(n)
+        case V_OBJECT: return self.encodeObject(n)
+        case V_ANY   :
+            var buf []byte
+            if err := n.encodeInterface(&buf); err != nil {
+                return err
+            }
+            return self.encodeText(rt.Mem2Str(buf))
+        default      : return ErrUnsupportType
+    }
+    return nil
+}
+
+func (self *nodeEncoder) encodeArray(n *Node) error {
+    if err := n.skipAllIndex(); err != nil {
+        return err
+    }
+
+    var started bool
+    self.buf = append(self.buf, '[')
+    self.depth++
+    it := n.values()
+    for v := it.next(); v != nil; v = it.next() {
+        if started {
+            self.buf = append(self.buf, ',')
+        }
+        started = true
+        self.newline()
+        if err := self.encode(v); err != nil {
+            return err
+        }
+        if err := self.flush(false); err != nil {
+            return err
+        }
+    }
+    self.depth--
+    if started {
+        self.newline()
+    }
+    self.buf = append(self.buf, ']')
+    return nil
+}
+
+func (self *nodeEncoder) encodeObject(n *Node) error {
+    if err := n.skipAllKey(); err != nil {
+        return err
+    }
+
+    var ps []*Pair
+    if self.SortKeys {
+        ps = sortedPairs(n)
+    } else {
+        it := n.properties()
+        for p := it.next(); p != nil; p = it.next() {
+            ps = append(ps, p)
+        }
+    }
+
+    self.buf = append(self.buf, '{')
+    self.depth++
+    for i, p := range ps {
+        if i != 0 {
+            self.buf = append(self.buf, ',')
+        }
+        self.newline()
+        self.encodeString(p.Key)
+        self.buf = append(self.buf, ':')
+        if self.pretty {
+            self.buf = append(self.buf, ' ')
+        }
+        if err := self.encode(&p.Value); err != nil {
+            return err
+        }
+        if err := self.flush(false); err != nil {
+            return err
+        }
+    }
+    self.depth--
+    if len(ps) != 0 {
+        self.newline()
+    }
+    self.buf = append(self.buf, '}')
+    return nil
+}
+
+func (self *nodeEncoder) encodeString(s string) {
+    i := len(self.buf)
+    quote(&self.buf, s)
+    self.escape(i)
+}
+
+// encodeText writes the valid json text, which is parsed only if the keys are sorted,
+// see EncodeOptions for the cost of it.
+func (self *nodeEncoder) encodeText(s string) error {
+    if self.plain {
+        self.buf = append(self.buf, s...)
+        return nil
+    }
+    if self.SortKeys && strings.ContainsRune(s, '{') {
+        n := NewRaw(s)
+        if err := n.checkRaw(); err != nil {
+            return err
+        }
+        return self.encode(&n)
+    }
+
+    /* the scalars are skipped natively, and the text is escaped at once before flushing */
+    k := len(self.buf)
+    p := NewParserObj(s)
+    for p.p < len(s) {
+        switch c := s[p.p]; c {
+            case ' ', '\t', '\n', '\r':
+                p.p++
+            case '[', '{':
+                self.buf = append(self.buf, c)
+                self.depth++
+                if j := skipBlank(s, p.p + 1); j >= 0 && (s[j] == ']' || s[j] == '}') {
+                    self.buf = append(self.buf, s[j])
+                    self.depth--
+                    p.p = j + 1
+                } else {
+                    self.newline()
+                    p.p++
+                }
+            case ']', '}':
+                self.depth--
+                self.newline()
+                self.buf = append(self.buf, c)
+                p.p++
+            case ',':
+                self.buf = append(self.buf, ',')
+                self.newline()
+                p.p++
+                self.escape(k)
+                if err := self.flush(false); err != nil {
+                    return err
+                }
+                k = len(self.buf)
+            case ':':
+                self.buf = append(self.buf, ':')
+                if self.pretty {
+                    self.buf = append(self.buf, ' ')
+                }
+                p.p++
+            default:
+                start, err := p.skipFast()
+                if err != 0 {
+                    return p.ExportError(err)
+                }
+                self.buf = append(self.buf, s[start:p.p]...)
+        }
+    }
+    self.escape(k)
+    return nil
+}
+
+func (self *nodeEncoder) newline() {
+    if !self.pretty {
+        return
+    }
+    self.buf = append(self.buf, '\n')
+    self.buf = append(self.buf, self.Prefix...)
+    for i := 0; i < self.depth; i++ {
+        self.buf = append(self.buf, self.Indent...)
+    }
+}
+
+// escape escapes the quoted strings in buf[i:] as the options.
+func (self *nodeEncoder) escape(i int) {
+    if self.EscapeHTML {
+        self.tmp = append(self.tmp[:0], self.buf[i:]...)
+        self.buf = alg.HtmlEscape(self.buf[:i], self.tmp)
+    }
+    if self.EscapeNonASCII {
+        self.tmp = append(self.tmp[:0], self.buf[i:]...)
+        self.buf = alg.ASCIIEscape(self.buf[:i], self.tmp)
+    }
+}
+
+func (self *nodeEncoder) flush(force bool) error {
+    if self.w == nil || len(self.buf) == 0 || (!force && len(self.buf) < int(option.DefaultAstBufferSize)) {
+        return nil
+    }
+    _, err := self.w.Write(self.buf)
+    self.buf = self.buf[:0]
+    return err
+}
@@ -17,6 +17,7 @@
 package ast
 
 import (
+    `bytes`
     `encoding/json`
     `runtime`
     `sync`
@@ -443,3 +444,100 @@ func TestGet_InvalidPathType(t *testing.T) {
         s.GetByPath("a", -1)
     }))
 }
+
+type chunkWriter struct {
+    bytes.Buffer
+    writes int
+}
+
+func (self *chunkWriter) Write(p []byte) (int, error) {
+    self.writes++
+    return self.Buffer.Write(p)
+}
+
+func TestEncodeOptions(t *testing.T) {
+    src := ` {"b": [1, {}, [], "<a&b>"], "a": {"y": "\"q\"", "x": null}, "c": "été"} `
+    loads := map[string]func() Node{
+        "raw": func() Node { return NewRaw(src) },
+        "lazy": func() Node {
+            n, err := NewSearcher(src).GetByPath()
+            require.NoError(t, err)
+            return n
+        },
+        "loaded": func() Node {
+            n := NewRaw(src)
+            require.NoError(t, n.LoadAll())
+            return n
+        },
+        "any": func() Node {
+            var v interface{}
+            require.NoError(t, json.Unmarshal([]byte(src), &v))
+            return NewAny(v)
+        },
+    }
+
+    for name, load := range loads {
+        // sorted, indented and escaped like encoding/json
+        n := load()
+        out, err := n.AppendJSON([]byte("x"), &EncodeOptions{Prefix: ">", Indent: "  ", SortKeys: true, EscapeHTML: true})
+        require.NoError(t, err, name)
+        var v interface{}
+        require.NoError(t, json.Unmarshal([]byte(src), &v))
+        exp, _ := json.MarshalIndent(v, ">", "  ")
+        require.Equal(t, "x" + string(exp), string(out), name)
+
+        // only indented
+        if name != "any" {
+            n = load()
+            w := &chunkWriter{}
+            require.NoError(t, n.Encode(w, &EncodeOptions{Indent: "\t"}))
+            var buf bytes.Buffer
+            require.NoError(t, json.Indent(&buf, []byte(strings.TrimSpace(src)), "", "\t"))
+            require.Equal(t, buf.String(), w.String(), name)
+        }
+
+        // compact and ASCII-only
+        n = load()
+        out, err = n.AppendJSON(nil, &EncodeOptions{SortKeys: true, EscapeNonASCII: true})
+        require.NoError(t, err, name)
+        require.Equal(t, `{"a":{"x":null,"y":"\"q\""},"b":[1,{},[],"<a&b>"],"c":"\u00e9t\u00e9"}`, string(out), name)
+    }
+
+    // the same as MarshalJSON without options
+    n := NewRaw(src)
+    out, err := n.AppendJSON(nil, nil)
+    require.NoError(t, err)
+    exp, err := n.MarshalJSON()
+    require.NoError(t, err)
+    require.Equal(t, string(exp), string(out))
+    var nilNode *Node
+    out, err = nilNode.AppendJSON(nil, nil)
+    require.NoError(t, err)
+    require.Equal(t, "null", string(out))
+
+    // large trees are written in chunks
+    arr := NewArray(nil)
+    for i := 0; i < 1000; i++ {
+        require.NoError(t, arr.Add(NewRaw(`{"key": "value", "list": [1, 2, 3]}`)))
+    }
+    w := &chunkWriter{}
+    require.NoError(t, arr.Encode(w, &EncodeOptions{Indent: " "}))
+    require.Greater(t, w.writes, 1)
+    out, err = arr.AppendJSON(nil, &EncodeOptions{Indent: " "})
+    require.NoError(t, err)
+    require.Equal(t, string(out), w.String())
+    require.True(t, json.Valid(out))
+
+    // raw json is compacted and escaped without sorting keys
+    n = NewRaw(src)
+    out, err = n.AppendJSON(nil, &EncodeOptions{EscapeHTML: true, EscapeNonASCII: true})
+    require.NoError(t, err)
+    require.Equal(t, `{"b":[1,{},[],"\u003ca\u0026b\u003e"],"a":{"y":"\"q\"","x":null},"c":"\u00e9t\u00e9"}`, string(out))
+
+    // errors are returned
+    e := NewRaw(`[1,`)
+    require.Error(t, e.Encode(&chunkWriter{}, nil))
+    e = NewRaw(`[1, tru]`)
+    _, err = e.AppendJSON(nil, &EncodeOptions{Indent: " "})
+    require.Error(t, err)
+}
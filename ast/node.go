@@ -19,13 +19,18 @@ package ast
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/native/types"
+	"github.com/bytedance/sonic/internal/resolver"
 	"github.com/bytedance/sonic/internal/rt"
+	"github.com/bytedance/sonic/internal/utils"
 )
 
 const (
@@ -68,6 +73,14 @@ func (self *Node) UnmarshalJSON(data []byte) (err error) {
     return self.Check()
 }
 
+// a Node tagged with `json:",inline"` collects the unknown keys of its parent struct
+func init() {
+    resolver.RegisterInlineSetter(reflect.TypeOf(Node{}), func(vp unsafe.Pointer, key string, raw string) error {
+        _, err := (*Node)(vp).Set(key, NewRaw(string(rt.Str2Mem(raw))))
+        return err
+    })
+}
+
 /** Node Type Accessor **/
 
 // Type returns json type represented by the node
@@ -527,6 +540,76 @@ func (self *Node) StrictFloat64() (float64, error) {
     }
 }
 
+// BigInt casts the node to *big.Int like Int64, but keeps all the digits of integers,
+// including V_NUMBER|V_TRUE|V_FALSE|V_ANY|V_STRING|V_NULL,
+// V_NONE it will return error
+func (self *Node) BigInt() (*big.Int, error) {
+    if err := self.checkRaw(); err != nil {
+        return nil, err
+    }
+    switch self.t {
+        case _V_NUMBER, types.V_STRING : return toBigInt(self.toString())
+        case types.V_TRUE     : return big.NewInt(1), nil
+        case types.V_FALSE    : return big.NewInt(0), nil
+        case types.V_NULL     : return big.NewInt(0), nil
+        case _V_ANY           :
+            any := self.packAny()
+            switch v := any.(type) {
+                case *big.Int   : return new(big.Int).Set(v), nil
+                case *big.Float : return toBigInt(v.Text('g', -1))
+                case uint       : return new(big.Int).SetUint64(uint64(v)), nil
+                case uint64     : return new(big.Int).SetUint64(v), nil
+                case float32    : return toBigInt(strconv.FormatFloat(float64(v), 'g', -1, 32))
+                case float64    : return toBigInt(strconv.FormatFloat(v, 'g', -1, 64))
+                case string     : return toBigInt(v)
+                case json.Number: return toBigInt(string(v))
+                default         :
+                    if i, err := self.Int64(); err == nil {
+                        return big.NewInt(i), nil
+                    } else {
+                        return nil, err
+                    }
+            }
+        default               : return nil, ErrUnsupportType
+    }
+}
+
+// BigFloat casts the node to *big.Float like Float64, but keeps all the digits of numbers,
+// including V_NUMBER|V_TRUE|V_FALSE|V_ANY|V_STRING|V_NULL,
+// V_NONE it will return error
+func (self *Node) BigFloat() (*big.Float, error) {
+    if err := self.checkRaw(); err != nil {
+        return nil, err
+    }
+    switch self.t {
+        case _V_NUMBER, types.V_STRING : return toBigFloat(self.toString())
+        case types.V_TRUE     : return big.NewFloat(1), nil
+        case types.V_FALSE    : return big.NewFloat(0), nil
+        case types.V_NULL     : return big.NewFloat(0), nil
+        case _V_ANY           :
+            any := self.packAny()
+            switch v := any.(type) {
+                case *big.Float : return new(big.Float).Copy(v), nil
+                case *big.Int   : return new(big.Float).SetInt(v), nil
+                case string     : return toBigFloat(v)
+                case json.Number: return toBigFloat(string(v))
+                case float32, float64:
+                    if f, _ := self.Float64(); !math.IsNaN(f) {
+                        return big.NewFloat(f), nil
+                    } else {
+                        return nil, ErrUnsupportType
+                    }
+                default         :
+                    if i, err := self.BigInt(); err == nil {
+                        return new(big.Float).SetInt(i), nil
+                    } else {
+                        return nil, err
+                    }
+            }
+        default               : return nil, ErrUnsupportType
+    }
+}
+
 /** Sequential Value Methods **/
 
 // Len returns children count of a array|object|string node
@@ -1738,6 +1821,28 @@ func (node *Node) toInt64() (int64, error) {
     return ret, nil
 }
 
+// toBigInt parses the number text, truncating the fractions.
+func toBigInt(v string) (*big.Int, error) {
+    ret := new(big.Int)
+    if utils.SetBigInt(ret, v) {
+        return ret, nil
+    }
+    if f, err := toBigFloat(v); err != nil {
+        return nil, err
+    } else if ret, _ = f.Int(nil); ret == nil {
+        return nil, &strconv.NumError{Func: "BigInt", Num: v, Err: strconv.ErrRange}
+    }
+    return ret, nil
+}
+
+func toBigFloat(v string) (*big.Float, error) {
+    ret := new(big.Float)
+    if !utils.SetBigFloat(ret, v) {
+        return nil, &strconv.NumError{Func: "BigFloat", Num: v, Err: strconv.ErrSyntax}
+    }
+    return ret, nil
+}
+
 func newBytes(v []byte) Node {
     return Node{
         t: types.V_STRING,
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
 	"strings"
@@ -541,6 +542,54 @@ func TestTypeCast(t *testing.T) {
     }
 }
 
+func TestNodeBig(t *testing.T) {
+    root, perr := NewParser(`[123456789012345678901234567890, "-7", 2.5e3, 1.25, true, null, {}]`).Parse()
+    require.Zero(t, perr)
+    ints := []string{"123456789012345678901234567890", "-7", "2500", "1", "1", "0"}
+    floats := []string{"1.2345678901234567890123456789e+29", "-7", "2500", "1.25", "1", "0"}
+    for i := range ints {
+        v, err := root.Index(i).BigInt()
+        require.NoError(t, err)
+        assert.Equal(t, ints[i], v.String())
+        f, err := root.Index(i).BigFloat()
+        require.NoError(t, err)
+        assert.Equal(t, floats[i], f.Text('g', -1))
+    }
+    _, err := root.Index(6).BigInt()
+    assert.Equal(t, ErrUnsupportType, err)
+    s := NewString("x")
+    _, err = s.BigFloat()
+    assert.Error(t, err)
+
+    /* values kept in V_ANY nodes */
+    n, _ := new(big.Int).SetString("-98765432109876543210", 10)
+    cases := []struct{
+        v interface{}
+        i string
+        f string
+    }{
+        {n, "-98765432109876543210", "-9.876543210987654321e+19"},
+        {uint64(1 << 63), "9223372036854775808", "9.223372036854775808e+18"},
+        {json.Number("1e2"), "100", "100"},
+        {float32(1.5), "1", "1.5"},
+    }
+    for _, c := range cases {
+        node := NewAny(c.v)
+        i, err := node.BigInt()
+        require.NoError(t, err, c.v)
+        assert.Equal(t, c.i, i.String())
+        f, err := node.BigFloat()
+        require.NoError(t, err, c.v)
+        assert.Equal(t, c.f, f.Text('g', -1))
+    }
+    node := NewAny(n)
+    i, _ := node.BigInt()
+    assert.NotSame(t, n, i)
+    a := NewAny(struct{}{})
+    _, err = a.BigInt()
+    assert.Equal(t, ErrUnsupportType, err)
+}
+
 func TestCheckError_Nil(t *testing.T) {
     nill := (*Node)(nil)
     if nill.Valid() || nill.Check() == nil {
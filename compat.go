@@ -24,6 +24,8 @@ import (
     `io`
     `reflect`
 
+    `github.com/bytedance/sonic/encoder`
+    `github.com/bytedance/sonic/internal/encoder/alg`
     `github.com/bytedance/sonic/option`
 )
 
@@ -44,6 +46,24 @@ func (cfg frozenConfig) marshalOptions(val interface{}, prefix, indent string) (
     enc := json.NewEncoder(w)
     enc.SetEscapeHTML(cfg.EscapeHTML)
     enc.SetIndent(prefix, indent)
+    if cfg.FieldMask != nil {
+        buf, err := encoder.EncodeMasked(val, cfg.FieldMask, 0)
+        if err != nil {
+            return nil, err
+        }
+        val = json.RawMessage(buf)
+    }
+    if cfg.Canonical {
+        buf, err := json.Marshal(val)
+        if err != nil {
+            return nil, err
+        }
+        if buf, err = alg.Canonicalize(nil, buf, 0); err != nil {
+            return nil, err
+        }
+        enc.SetEscapeHTML(false)
+        val = json.RawMessage(buf)
+    }
     err := enc.Encode(val)
 	out := w.Bytes()
 
@@ -55,9 +75,17 @@ func (cfg frozenConfig) marshalOptions(val interface{}, prefix, indent string) (
 	return out, err
 }
 
+// With is implemented by sonic
+func (cfg frozenConfig) With(opts ...Option) API {
+    if len(opts) == 0 {
+        return &cfg
+    }
+    return cfg.Config.with(opts).Froze()
+}
+
 // Marshal is implemented by sonic
 func (cfg frozenConfig) Marshal(val interface{}) ([]byte, error) {
-    if !cfg.EscapeHTML {
+    if !cfg.EscapeHTML || cfg.FieldMask != nil || cfg.Canonical {
         return cfg.marshalOptions(val, "", "")
     }
     return json.Marshal(val)
@@ -71,7 +99,7 @@ func (cfg frozenConfig) MarshalToString(val interface{}) (string, error) {
 
 // MarshalIndent is implemented by sonic
 func (cfg frozenConfig) MarshalIndent(val interface{}, prefix, indent string) ([]byte, error) {
-    if !cfg.EscapeHTML {
+    if !cfg.EscapeHTML || cfg.FieldMask != nil || cfg.Canonical {
         return cfg.marshalOptions(val, prefix, indent)
     }
     return json.MarshalIndent(val, prefix, indent)
@@ -21,13 +21,17 @@ package decoder
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/json"
 	"io"
 	"reflect"
+	"strconv"
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/decoder/consts"
+	"github.com/bytedance/sonic/internal/decoder/errors"
 	"github.com/bytedance/sonic/internal/native/types"
+	"github.com/bytedance/sonic/internal/resolver"
 	"github.com/bytedance/sonic/option"
 )
 
@@ -46,6 +50,11 @@ const (
      _F_allow_control   = consts.F_allow_control
      _F_no_validate_json = consts.F_no_validate_json
      _F_case_sensitive  = consts.F_case_sensitive
+     _F_collect_errors  = consts.F_collect_errors
+     _F_use_big_int     = consts.F_use_big_int
+     _F_duration_string = consts.F_duration_string
+     _F_nan_inf_string  = consts.F_nan_inf_string
+     _F_quoted_int      = consts.F_quoted_int
 )
 
 type Options uint64
@@ -59,8 +68,27 @@ const (
      OptionValidateString   Options = 1 << _F_validate_string
      OptionNoValidateJSON   Options = 1 << _F_no_validate_json
      OptionCaseSensitive    Options = 1 << _F_case_sensitive
+     OptionCollectErrors    Options = 1 << _F_collect_errors
+     OptionUseBigInt        Options = 1 << _F_use_big_int
+     OptionDurationString   Options = 1 << _F_duration_string
+     OptionNaNInfString     Options = 1 << _F_nan_inf_string
+     OptionQuotedInt        Options = 1 << _F_quoted_int
+
+     OptionCoerceStringToNumber Options = Options(consts.OptionCoerceStringToNumber)
+     OptionCoerceNumberToString Options = Options(consts.OptionCoerceNumberToString)
+     OptionCoerceBool           Options = Options(consts.OptionCoerceBool)
+     OptionCoerceFloatToInt     Options = Options(consts.OptionCoerceFloatToInt)
+     OptionCoerceSingleToSlice  Options = Options(consts.OptionCoerceSingleToSlice)
+     OptionCoerceEmptyToNil     Options = Options(consts.OptionCoerceEmptyToNil)
+     OptionWeaklyTyped          Options = Options(consts.OptionWeaklyTyped)
 )
 
+// OptionTimeFormat returns the option to decode time.Time in format,
+// which is ignored by the fallback decoder.
+func OptionTimeFormat(format string) Options {
+     return Options(consts.OptionTimeFormat(format))
+}
+
 func (self *Decoder) SetOptions(opts Options) {
      if (opts & OptionUseNumber != 0) && (opts & OptionUseInt64 != 0) {
          panic("can't set OptionUseInt64 and OptionUseNumber both!")
@@ -127,6 +155,87 @@ func (self *Decoder) Decode(val interface{}) error {
    return dec.Decode(val)
 }
 
+// DecodePresence decodes like Decode, and also reports which struct fields were in
+// the input, keyed by their Go paths like `Items[0].Name` or `Tags["k"].Name`.
+func (self *Decoder) DecodePresence(val interface{}) (map[string]FieldState, error) {
+    if err := self.Decode(val); err != nil {
+        return nil, err
+    }
+
+    /* the fallback takes another pass over a generic document */
+    var doc interface{}
+    dec := json.NewDecoder(bytes.NewBufferString(self.s))
+    dec.UseNumber()
+    if err := dec.Decode(&doc); err != nil {
+        return nil, err
+    }
+    ret := make(map[string]FieldState)
+    presenceOf(ret, reflect.TypeOf(val), doc, "")
+    return ret, nil
+}
+
+// DecodeCoercions decodes like Decode, the fallback decoder never coerces
+// values across JSON types, so there are no coercions to report.
+func (self *Decoder) DecodeCoercions(val interface{}) ([]Coercion, error) {
+    return nil, self.Decode(val)
+}
+
+func presenceOf(ret map[string]FieldState, vt reflect.Type, doc interface{}, path string) {
+    if doc == nil {
+        return
+    }
+    for vt.Kind() == reflect.Ptr || resolver.IsOptional(vt) {
+        if vt.Kind() == reflect.Ptr {
+            vt = vt.Elem()
+        } else {
+            vt, _ = resolver.OptionalValue(vt)
+        }
+    }
+    if pt := reflect.PtrTo(vt); pt.Implements(unmarshalerType) || pt.Implements(textUnmarshalerType) {
+        return
+    }
+
+    switch v := doc.(type) {
+    case map[string]interface{}:
+        for k, e := range v {
+            switch vt.Kind() {
+            case reflect.Map:
+                presenceOf(ret, vt.Elem(), e, path + "[" + strconv.Quote(k) + "]")
+            case reflect.Struct:
+                sf, ok := resolver.FieldByName(vt, k)
+                if !ok {
+                    ret[joinPath(path, k)] = FieldUnknown
+                    continue
+                }
+                fp := joinPath(path, sf.Name)
+                if e == nil {
+                    ret[fp] = FieldNull
+                } else {
+                    ret[fp] = FieldPresent
+                }
+                presenceOf(ret, sf.Type, e, fp)
+            }
+        }
+    case []interface{}:
+        if vt.Kind() != reflect.Slice && vt.Kind() != reflect.Array {
+            return
+        }
+        for i, e := range v {
+            if vt.Kind() == reflect.Array && i >= vt.Len() {
+                break
+            }
+            presenceOf(ret, vt.Elem(), e, path + "[" + strconv.Itoa(i) + "]")
+        }
+    }
+}
+
+func joinPath(path string, name string) string {
+    if path == "" {
+        return name
+    }
+    return path + "." + name
+}
+
 // UseInt64 indicates the Decoder to unmarshal an integer into an interface{} as an
 // int64 instead of as a float64.
 func (self *Decoder) UseInt64() {
@@ -141,6 +250,12 @@ func (self *Decoder) UseNumber() {
      self.f  |= 1 << _F_use_number
 }
 
+// UseBigInt indicates the Decoder to unmarshal an integer that overflows int64 into
+// an interface{} as a *big.Int, which is not supported by the fallback decoder.
+func (self *Decoder) UseBigInt() {
+     self.f |= 1 << _F_use_big_int
+}
+
 // UseUnicodeErrors indicates the Decoder to return an error when encounter invalid
 // UTF-8 escape sequences.
 func (self *Decoder) UseUnicodeErrors() {
@@ -159,6 +274,18 @@ func (self *Decoder) CopyString() {
      self.f |= 1 << _F_copy_string
 }
 
+// CollectErrors indicates the Decoder to go on decoding after mismatched types,
+// overflows and invalid values, and return all of them as DecodeErrors.
+func (self *Decoder) CollectErrors() {
+     self.f |= 1 << _F_collect_errors
+}
+
+// WeaklyTyped indicates the Decoder to coerce values across JSON types,
+// which is not supported by the fallback decoder.
+func (self *Decoder) WeaklyTyped() {
+     self.f |= uint64(OptionWeaklyTyped)
+}
+
 // ValidateString causes the Decoder to validate string values when decoding string value 
 // in JSON. Validation is that, returning error when unescaped control chars(0x00-0x1f) or
 // invalid UTF-8 chars in the string value of JSON.
@@ -198,3 +325,49 @@ func (s SyntaxError) Error() string {
 
 // MismatchTypeError represents mismatching between json and object
 type MismatchTypeError json.UnmarshalTypeError
+
+// MissingFieldError represents an absent `required` field,
+// which is never returned by the fallback decoder
+type MissingFieldError = errors.MissingFieldError
+
+// FieldState tells how a field appeared in the input of Decoder.DecodePresence
+type FieldState = consts.FieldState
+
+const (
+    FieldAbsent  FieldState = consts.FieldAbsent
+    FieldPresent FieldState = consts.FieldPresent
+    FieldNull    FieldState = consts.FieldNull
+    FieldUnknown FieldState = consts.FieldUnknown
+)
+
+// UnionError represents an object of a registered union without a known discriminator,
+// which is never returned by the fallback decoder
+type UnionError = errors.UnionError
+
+// CoercionKind is a kind of lenient conversion of the weakly typed modes
+type CoercionKind = consts.CoercionKind
+
+const (
+    CoerceStringToNumber CoercionKind = consts.CoerceStringToNumber
+    CoerceNumberToString CoercionKind = consts.CoerceNumberToString
+    CoerceBool           CoercionKind = consts.CoerceBool
+    CoerceFloatToInt     CoercionKind = consts.CoerceFloatToInt
+    CoerceSingleToSlice  CoercionKind = consts.CoerceSingleToSlice
+    CoerceEmptyToNil     CoercionKind = consts.CoerceEmptyToNil
+)
+
+// Coercion describes a value reported by Decoder.DecodeCoercions,
+// which is never reported by the fallback decoder
+type Coercion = consts.Coercion
+
+var (
+    unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+    textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// DecodeError represents a single error collected with OptionCollectErrors,
+// which is never returned by the fallback decoder
+type DecodeError = errors.DecodeError
+
+// DecodeErrors represents all the errors collected with OptionCollectErrors
+type DecodeErrors = errors.DecodeErrors
@@ -33,6 +33,43 @@ type SyntaxError = api.SyntaxError
 // MismatchTypeError represents mismatching between json and object
 type MismatchTypeError = api.MismatchTypeError
 
+// DecodeError represents a single error collected with OptionCollectErrors
+type DecodeError = api.DecodeError
+
+// DecodeErrors represents all the errors collected with OptionCollectErrors
+type DecodeErrors = api.DecodeErrors
+
+// FieldState tells how a field appeared in the input of Decoder.DecodePresence
+type FieldState = api.FieldState
+
+const (
+    FieldAbsent  FieldState = api.FieldAbsent
+    FieldPresent FieldState = api.FieldPresent
+    FieldNull    FieldState = api.FieldNull
+    FieldUnknown FieldState = api.FieldUnknown
+)
+
+// UnionError represents an object of a registered union without a known discriminator
+type UnionError = api.UnionError
+
+// CoercionKind is a kind of lenient conversion of the weakly typed modes
+type CoercionKind = api.CoercionKind
+
+const (
+    CoerceStringToNumber CoercionKind = api.CoerceStringToNumber
+    CoerceNumberToString CoercionKind = api.CoerceNumberToString
+    CoerceBool           CoercionKind = api.CoerceBool
+    CoerceFloatToInt     CoercionKind = api.CoerceFloatToInt
+    CoerceSingleToSlice  CoercionKind = api.CoerceSingleToSlice
+    CoerceEmptyToNil     CoercionKind = api.CoerceEmptyToNil
+)
+
+// Coercion describes a value reported by Decoder.DecodeCoercions
+type Coercion = api.Coercion
+
+// MissingFieldError represents an absent `required` field
+type MissingFieldError = api.MissingFieldError
+
 // Options for decode.
 type Options = api.Options
 
@@ -45,6 +82,30 @@ const (
     OptionValidateString   Options = api.OptionValidateString
     OptionNoValidateJSON   Options = api.OptionNoValidateJSON
     OptionCaseSensitive    Options = api.OptionCaseSensitive
+    OptionCollectErrors    Options = api.OptionCollectErrors
+    OptionUseBigInt        Options = api.OptionUseBigInt
+
+    // OptionDurationString indicates the decoder to also accept time.Duration as a string
+    // like `"1.5s"`, besides an integer of nanoseconds.
+    OptionDurationString   Options = api.OptionDurationString
+
+    // OptionNaNInfString indicates the decoder to also accept floats as the strings
+    // `"NaN"`, `"Infinity"` and `"-Infinity"`.
+    OptionNaNInfString     Options = api.OptionNaNInfString
+
+    // OptionQuotedInt indicates the decoder to also accept integers as strings like `"123"`,
+    // as the encoder writes them with encoder.Int64String.
+    OptionQuotedInt        Options = api.OptionQuotedInt
+
+    OptionCoerceStringToNumber Options = api.OptionCoerceStringToNumber
+    OptionCoerceNumberToString Options = api.OptionCoerceNumberToString
+    OptionCoerceBool           Options = api.OptionCoerceBool
+    OptionCoerceFloatToInt     Options = api.OptionCoerceFloatToInt
+    OptionCoerceSingleToSlice  Options = api.OptionCoerceSingleToSlice
+    OptionCoerceEmptyToNil     Options = api.OptionCoerceEmptyToNil
+
+    // OptionWeaklyTyped enables all the coercions above.
+    OptionWeaklyTyped          Options = api.OptionWeaklyTyped
 )
 
 // StreamDecoder is the decoder context object for streaming input.
@@ -69,4 +130,8 @@ var (
     // Skip skips only one json value, and returns first non-blank character position and its ending position if it is valid.
     // Otherwise, returns negative error code using start and invalid character position using end
     Skip = api.Skip
+
+    // OptionTimeFormat returns the option to decode time.Time in format, which is either
+    // time.RFC3339Nano (the default), "unix", "unixmilli" or a custom layout of time.Parse.
+    OptionTimeFormat = api.OptionTimeFormat
 )
@@ -22,7 +22,9 @@ package decoder
 import (
 	"encoding/json"
 	"fmt"
-	_ "reflect"
+	"math"
+	"math/big"
+	"reflect"
 	"strings"
 	_ "strings"
 	"testing"
@@ -410,4 +412,502 @@ func BenchmarkSkip_Sonic(b *testing.B) {
     for i:=0; i<b.N; i++ {
         _, _ = Skip(data)
     }
-}
\ No newline at end of file
+}
+func TestDecoder_OptionCollectErrors(t *testing.T) {
+    type user struct {
+        Name string  `json:"name"`
+        Age  int8    `json:"age"`
+        Tags []int   `json:"tags"`
+    }
+    var v struct {
+        Users []user  `json:"users"`
+        Score float64 `json:"score"`
+    }
+    src := `{"users":[{"name":1,"age":300},{"name":"ok","age":18,"tags":[1,"a",3]}],"score":1.5}`
+    d := NewDecoder(src)
+    d.SetOptions(OptionCollectErrors)
+    err := d.Decode(&v)
+    require.Error(t, err)
+
+    errs, ok := err.(DecodeErrors)
+    require.True(t, ok)
+    require.Len(t, errs, 3)
+    assert.Equal(t, "$.users[0].name", errs[0].Path)
+    assert.Equal(t, strings.Index(src, `1,"age"`), errs[0].Pos)
+    assert.Equal(t, "number", errs[0].JSONType)
+    assert.Equal(t, "string", errs[0].Type.String())
+    assert.Equal(t, "$.users[0].age", errs[1].Path)
+    assert.Equal(t, "int8", errs[1].Type.String())
+    assert.Equal(t, "$.users[1].tags[1]", errs[2].Path)
+    assert.Equal(t, "string", errs[2].JSONType)
+
+    /* the valid values are still decoded */
+    assert.Equal(t, "ok", v.Users[1].Name)
+    assert.Equal(t, int8(18), v.Users[1].Age)
+    assert.Equal(t, []int{1, 0, 3}, v.Users[1].Tags)
+    assert.Equal(t, 1.5, v.Score)
+
+    /* syntax errors are not collected */
+    d = NewDecoder(`{"users":`)
+    d.SetOptions(OptionCollectErrors)
+    err = d.Decode(&v)
+    _, ok = err.(SyntaxError)
+    assert.True(t, ok)
+
+    /* nothing is returned without errors */
+    d = NewDecoder(`{"score":2}`)
+    d.CollectErrors()
+    assert.NoError(t, d.Decode(&v))
+}
+
+func TestDecoder_MismatchTypeErrorLocation(t *testing.T) {
+    type item struct {
+        Price float64 `json:"price"`
+    }
+    type order struct {
+        Items []item `json:"items"`
+    }
+    var v order
+    err := NewDecoder("{\"items\": [\n  {\"price\": 1},\n  {\"price\": \"x\"}\n]}").Decode(&v)
+    e, ok := err.(*MismatchTypeError)
+    require.True(t, ok)
+    assert.Equal(t, 3, e.Line())
+    assert.Equal(t, 13, e.Column())
+    assert.Equal(t, "$.items[1].price", e.Path())
+    assert.Equal(t, "order.Items[1].Price", e.FieldPath())
+}
+
+func TestDecoder_DecodePresence(t *testing.T) {
+    type addr struct {
+        City string `json:"city"`
+        Zip  string `json:"zip"`
+    }
+    type patch struct {
+        Name  string            `json:"name"`
+        Age   int               `json:"age"`
+        Addr  *addr             `json:"addr"`
+        Tags  []addr            `json:"tags"`
+        Attrs map[string]int    `json:"attrs"`
+        Note  string            `json:"note"`
+    }
+    src := `{"name":"x","age":0,"addr":{"city":null,"extra":1},"tags":[{},{"zip":"1"}],"attrs":{"k":1},"note":null,"other":true}`
+    for _, opts := range []Options{0, OptionCollectErrors} {
+        var v patch
+        d := NewDecoder(src)
+        d.SetOptions(opts)
+        ps, err := d.DecodePresence(&v)
+        require.NoError(t, err)
+        assert.Equal(t, map[string]FieldState{
+            "Name": FieldPresent,
+            "Age": FieldPresent,
+            "Addr": FieldPresent,
+            "Addr.City": FieldNull,
+            "Addr.extra": FieldUnknown,
+            "Tags": FieldPresent,
+            "Tags[1].Zip": FieldPresent,
+            "Attrs": FieldPresent,
+            "Note": FieldNull,
+            "other": FieldUnknown,
+        }, ps)
+        assert.Equal(t, FieldAbsent, ps["Addr.Zip"])
+        assert.Equal(t, "x", v.Name)
+        assert.Equal(t, "1", v.Tags[1].Zip)
+        assert.Equal(t, len(src), d.Pos())
+
+        /* keys are matched like the decoder does */
+        d = NewDecoder(`{"NAME":"y"}`)
+        d.SetOptions(opts)
+        ps, err = d.DecodePresence(&v)
+        require.NoError(t, err)
+        assert.Equal(t, FieldPresent, ps["Name"])
+        d = NewDecoder(`{"NAME":"y"}`)
+        d.SetOptions(opts | OptionCaseSensitive)
+        ps, err = d.DecodePresence(&v)
+        require.NoError(t, err)
+        assert.Equal(t, FieldUnknown, ps["NAME"])
+
+        /* nothing is reported for a broken input */
+        d = NewDecoder(`{"name":"x","age":"y"}`)
+        d.SetOptions(opts)
+        ps, err = d.DecodePresence(&v)
+        require.Error(t, err)
+        assert.Nil(t, ps)
+    }
+}
+
+func TestDecoder_RequiredAndDefault(t *testing.T) {
+    type inner struct {
+        ID   int      `json:"id,required"`
+        Tags []string `json:"tags" default:"[\"a\",\"b\"]"`
+    }
+    type outer struct {
+        Name  string  `json:"name,required"`
+        Level int     `json:"level,default=3"`
+        Ratio float64 `json:"ratio,omitempty,default=0.5"`
+        Items []inner `json:"items"`
+    }
+
+    for _, opts := range []Options{0, OptionCollectErrors} {
+        var v outer
+        d := NewDecoder(`{"name":"x","ratio":null,"items":[{"id":1},{"id":2,"tags":[]}]}`)
+        d.SetOptions(opts)
+        require.NoError(t, d.Decode(&v))
+        assert.Equal(t, 3, v.Level)
+        assert.Equal(t, 0.0, v.Ratio)
+        assert.Equal(t, []string{"a", "b"}, v.Items[0].Tags)
+        assert.Equal(t, []string{}, v.Items[1].Tags)
+
+        /* the defaults are decoded for each value */
+        v.Items[0].Tags[0] = "c"
+        var w outer
+        d = NewDecoder(`{"name":"y","items":[{"id":1}]}`)
+        d.SetOptions(opts)
+        require.NoError(t, d.Decode(&w))
+        assert.Equal(t, []string{"a", "b"}, w.Items[0].Tags)
+
+        /* the missing field is reported with its paths */
+        src := "{\"name\":\"z\",\n\"items\":[{\"id\":1},{\"tags\":null}]}"
+        d = NewDecoder(src)
+        d.SetOptions(opts)
+        err := d.Decode(&w)
+        require.Error(t, err)
+        if opts == OptionCollectErrors {
+            errs, ok := err.(DecodeErrors)
+            require.True(t, ok)
+            require.Len(t, errs, 1)
+            assert.Equal(t, "$.items[1].id", errs[0].Path)
+            assert.Nil(t, errs[0].Type)
+            assert.Equal(t, `json: missing required field "id" of decoder.inner at $.items[1].id (index 31)`, errs[0].Error())
+            err = errs[0].Err
+        }
+        e, ok := err.(*MissingFieldError)
+        require.True(t, ok, "%T", err)
+        assert.Equal(t, "id", e.Field)
+        assert.Equal(t, "$.items[1].id", e.Path())
+        assert.Equal(t, "outer.Items[1].ID", e.FieldPath())
+        assert.Equal(t, 2, e.Line())
+        assert.Equal(t, `json: missing required field "id" of decoder.inner at $.items[1].id`, e.Error())
+
+        d = NewDecoder(`{}`)
+        d.SetOptions(opts)
+        err = d.Decode(&w)
+        if opts == OptionCollectErrors {
+            err = err.(DecodeErrors)[0].Err
+        }
+        e, ok = err.(*MissingFieldError)
+        require.True(t, ok, "%T", err)
+        assert.Equal(t, "$.name", e.Path())
+        assert.Equal(t, "outer.Name", e.FieldPath())
+    }
+
+    /* invalid defaults fail at compiling */
+    var bad struct {
+        A int `json:"a" default:"{"`
+    }
+    require.Error(t, NewDecoder(`{}`).Decode(&bad))
+}
+
+func TestDecoder_WeaklyTyped(t *testing.T) {
+    type item struct {
+        ID    int64    `json:"id"`
+        Qty   uint8    `json:"qty"`
+        Price float64  `json:"price"`
+        Code  string   `json:"code"`
+        On    bool     `json:"on"`
+        Off   bool     `json:"off"`
+        Tags  []string `json:"tags"`
+        Ref   *int     `json:"ref"`
+        Name  *string  `json:"name"`
+        Attrs map[string]string `json:"attrs"`
+    }
+    src := `{"id":"42","qty":3.0,"price":"1.5","code":42,"on":1,"off":"false","tags":"a","ref":"","name":"","attrs":{"k":7}}`
+
+    /* strict by default */
+    var v item
+    err := NewDecoder(src).Decode(&v)
+    require.Error(t, err)
+
+    d := NewDecoder(src)
+    d.WeaklyTyped()
+    cs, err := d.DecodeCoercions(&v)
+    require.NoError(t, err)
+    empty := ""
+    assert.Equal(t, item{ID: 42, Qty: 3, Price: 1.5, Code: "42", On: true, Tags: []string{"a"}, Name: &empty, Attrs: map[string]string{"k": "7"}}, v)
+    kinds := make(map[string]CoercionKind, len(cs))
+    for _, c := range cs {
+        kinds[c.Path] = c.Kind
+    }
+    assert.Equal(t, strings.Index(src, `"42"`), cs[0].Pos)
+    assert.Equal(t, reflect.TypeOf(int64(0)), cs[0].Type)
+    assert.Equal(t, map[string]CoercionKind{
+        "$.id": CoerceStringToNumber,
+        "$.qty": CoerceFloatToInt,
+        "$.price": CoerceStringToNumber,
+        "$.code": CoerceNumberToString,
+        "$.on": CoerceBool,
+        "$.off": CoerceBool,
+        "$.tags": CoerceSingleToSlice,
+        "$.ref": CoerceEmptyToNil,
+        `$.attrs.k`: CoerceNumberToString,
+    }, kinds)
+    assert.Len(t, cs, 9)
+
+    /* only the enabled kinds are coerced */
+    var w item
+    d = NewDecoder(`{"id":"42","qty":"3"}`)
+    d.SetOptions(OptionCoerceStringToNumber)
+    require.NoError(t, d.Decode(&w))
+    assert.Equal(t, int64(42), w.ID)
+    assert.Equal(t, uint8(3), w.Qty)
+    for _, s := range []string{`{"qty":"3.0"}`, `{"qty":"300"}`, `{"qty":"0x1"}`, `{"on":1}`, `{"ref":""}`} {
+        d = NewDecoder(s)
+        d.SetOptions(OptionCoerceStringToNumber)
+        require.Error(t, d.Decode(&w), s)
+    }
+    d = NewDecoder(`{"qty":"3.0","id":-1.0}`)
+    d.SetOptions(OptionCoerceStringToNumber | OptionCoerceFloatToInt)
+    require.NoError(t, d.Decode(&w))
+    assert.Equal(t, int64(-1), w.ID)
+    d = NewDecoder(`{"qty":2.5}`)
+    d.SetOptions(OptionWeaklyTyped)
+    require.Error(t, d.Decode(&w))
+
+    /* fast slice decoders are bypassed for the coercions of elements */
+    var ids []int64
+    d = NewDecoder(`["1",2,3.0]`)
+    d.SetOptions(OptionWeaklyTyped)
+    require.NoError(t, d.Decode(&ids))
+    assert.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+type arrayTuple struct{}
+
+type arrayInner struct {
+    Z string
+}
+
+type arrayPoint struct {
+    arrayTuple `json:",array"`
+    X int
+    Y float64     `json:",string"`
+    *arrayInner
+    Tags []string
+}
+
+func TestDecoder_ArrayStruct(t *testing.T) {
+    var v []arrayPoint
+    require.NoError(t, NewDecoder(`[[1,"2.5","z",["a"],true], [ 3 ], [], null]`).Decode(&v))
+    assert.Equal(t, []arrayPoint{
+        {X: 1, Y: 2.5, arrayInner: &arrayInner{Z: "z"}, Tags: []string{"a"}},
+        {X: 3},
+        {},
+        {},
+    }, v)
+
+    /* the fields without items are kept */
+    p := arrayPoint{X: 1, Tags: []string{"b"}}
+    require.NoError(t, NewDecoder(`[2]`).Decode(&p))
+    assert.Equal(t, arrayPoint{X: 2, Tags: []string{"b"}}, p)
+
+    /* objects and mismatched items are reported */
+    err := NewDecoder(`{"X":1}`).Decode(&p)
+    var me *MismatchTypeError
+    require.ErrorAs(t, err, &me)
+    var w struct{ P arrayPoint }
+    err = NewDecoder(`{"P":[1,2]}`).Decode(&w)
+    require.Error(t, err)
+    err = NewDecoder(`{"P":["x"]}`).Decode(&w)
+    require.Error(t, err)
+}
+
+type bigFields struct {
+    I  *big.Int
+    V  big.Int
+    F  *big.Float
+    S  []big.Float
+}
+
+func TestDecoder_Big(t *testing.T) {
+    var v bigFields
+    src := `{"I":-123456789012345678901234567890,"V":18446744073709551616,"F":3.14159265358979323846264338327950288,"S":["1.5",2e-400,null]}`
+    require.NoError(t, NewDecoder(src).Decode(&v))
+    assert.Equal(t, "-123456789012345678901234567890", v.I.String())
+    assert.Equal(t, "18446744073709551616", v.V.String())
+    assert.Equal(t, "3.14159265358979323846264338327950288", v.F.Text('g', -1))
+    assert.Equal(t, "1.5", v.S[0].String())
+    assert.Equal(t, "2e-400", v.S[1].Text('g', -1))
+    assert.Len(t, v.S, 3)
+
+    /* null keeps values and clears pointers */
+    require.NoError(t, NewDecoder(`{"I":null,"V":null}`).Decode(&v))
+    assert.Nil(t, v.I)
+    assert.Equal(t, "18446744073709551616", v.V.String())
+
+    /* integers only for big.Int */
+    for _, s := range []string{`{"V":1.5}`, `{"V":"1"}`, `{"F":true}`, `{"F":"x"}`} {
+        var me *MismatchTypeError
+        require.ErrorAs(t, NewDecoder(s).Decode(&v), &me, s)
+    }
+
+    /* overflowing integers in interface{} */
+    var x interface{}
+    d := NewDecoder(`[1, 123456789012345678901234567890, -9223372036854775809, 9223372036854775807, 1e30]`)
+    d.UseBigInt()
+    require.NoError(t, d.Decode(&x))
+    arr := x.([]interface{})
+    assert.Equal(t, float64(1), arr[0])
+    assert.Equal(t, "123456789012345678901234567890", arr[1].(*big.Int).String())
+    assert.Equal(t, "-9223372036854775809", arr[2].(*big.Int).String())
+    assert.Equal(t, float64(9223372036854775807), arr[3])
+    assert.Equal(t, 1e30, arr[4])
+
+    d = NewDecoder(`{"a":18446744073709551616,"b":2}`)
+    d.SetOptions(OptionUseBigInt | OptionUseInt64)
+    var m map[string]interface{}
+    require.NoError(t, d.Decode(&m))
+    assert.Equal(t, "18446744073709551616", m["a"].(*big.Int).String())
+    assert.Equal(t, int64(2), m["b"])
+
+    /* before json.Number, and with collected errors too */
+    for _, opts := range []Options{OptionUseNumber, OptionUseNumber | OptionCollectErrors} {
+        var y struct{ A interface{}; B []interface{} }
+        d = NewDecoder(`{"A":-18446744073709551616,"B":[1.5,2]}`)
+        d.SetOptions(opts | OptionUseBigInt)
+        require.NoError(t, d.Decode(&y))
+        assert.Equal(t, "-18446744073709551616", y.A.(*big.Int).String())
+        assert.Equal(t, []interface{}{json.Number("1.5"), json.Number("2")}, y.B)
+    }
+}
+
+type timeFields struct {
+    T  time.Time
+    P  *time.Time
+    D  time.Duration
+    DS []time.Duration
+}
+
+func TestDecoder_Time(t *testing.T) {
+    var v timeFields
+    src := `{"T":"2024-05-06T07:08:09.123456789+08:00","P":"2024-05-06T07:08:09Z","D":1500000000,"DS":[60000000000,null]}`
+    require.NoError(t, NewDecoder(src).Decode(&v))
+    assert.True(t, v.T.Equal(time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("", 8 * 3600))))
+    assert.True(t, v.P.Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)))
+    assert.Equal(t, 1500 * time.Millisecond, v.D)
+    assert.Equal(t, []time.Duration{time.Minute, 0}, v.DS)
+
+    /* null keeps the value, bad times are returned as is */
+    require.NoError(t, NewDecoder(`{"T":null}`).Decode(&v))
+    assert.Equal(t, 2024, v.T.Year())
+    var pe *time.ParseError
+    require.ErrorAs(t, NewDecoder(`{"T":"2024-05-06"}`).Decode(&v), &pe)
+    var me *MismatchTypeError
+    require.ErrorAs(t, NewDecoder(`{"T":1714950489}`).Decode(&v), &me)
+    require.ErrorAs(t, NewDecoder(`{"D":"1.5s"}`).Decode(&v), &me)
+
+    /* unix times and duration strings */
+    d := NewDecoder(`{"T":1714979289,"P":"2024-05-06T07:08:09Z","D":"1.5s","DS":["1m",2]}`)
+    d.SetOptions(OptionTimeFormat("unix") | OptionDurationString)
+    require.NoError(t, d.Decode(&v))
+    assert.True(t, v.T.Equal(v.P.Local()))
+    assert.Equal(t, 1500 * time.Millisecond, v.D)
+    assert.Equal(t, []time.Duration{time.Minute, 2}, v.DS)
+    d = NewDecoder(`{"D":"1.5x"}`)
+    d.SetOptions(OptionDurationString)
+    require.ErrorAs(t, d.Decode(&v), &me)
+
+    d = NewDecoder(`{"T":1714979289123}`)
+    d.SetOptions(OptionTimeFormat("unixmilli"))
+    require.NoError(t, d.Decode(&v))
+    assert.Equal(t, int64(1714979289123), v.T.UnixNano() / 1e6)
+
+    d = NewDecoder(`"06/05/2024"`)
+    d.SetOptions(OptionTimeFormat("02/01/2006"))
+    var tm time.Time
+    require.NoError(t, d.Decode(&tm))
+    assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), tm)
+}
+
+type formatFields struct {
+    TS    time.Time      `json:"ts,format:unixmilli"`
+    Day   *time.Time     `json:"day,format:2006-01-02"`
+    Data  []byte         `json:"data,format:base64url"`
+    Hex   []byte         `json:"hex,format:hex"`
+    Price float64        `json:"price,format:%.2f"`
+    ID    float64        `json:"id,format:string"`
+    D     time.Duration  `json:"d,format:string"`
+}
+
+func TestDecoder_Format(t *testing.T) {
+    var v formatFields
+    src := `{"ts":1714979289000,"day":"2024-05-06","data":"-_8","hex":"cafe","price":1.50,"id":"12","d":"1s"}`
+    require.NoError(t, NewDecoder(src).Decode(&v))
+    assert.Equal(t, int64(1714979289), v.TS.Unix())
+    assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), *v.Day)
+    assert.Equal(t, []byte{0xfb, 0xff}, v.Data)
+    assert.Equal(t, []byte{0xca, 0xfe}, v.Hex)
+    assert.Equal(t, 1.5, v.Price)
+    assert.Equal(t, 12.0, v.ID)
+    assert.Equal(t, time.Second, v.D)
+
+    /* null clears pointers and keeps values, durations are also integers */
+    require.NoError(t, NewDecoder(`{"day":null,"hex":null,"d":2}`).Decode(&v))
+    assert.Nil(t, v.Day)
+    assert.Equal(t, []byte{0xca, 0xfe}, v.Hex)
+    assert.Equal(t, time.Duration(2), v.D)
+
+    var me *MismatchTypeError
+    for _, s := range []string{`{"hex":1}`, `{"ts":true}`, `{"d":"1x"}`, `{"data":[]}`} {
+        require.ErrorAs(t, NewDecoder(s).Decode(&v), &me, s)
+    }
+    require.Error(t, NewDecoder(`{"hex":"xyz"}`).Decode(&v))
+    require.Error(t, NewDecoder(`{"day":"06/05/2024"}`).Decode(&v))
+    require.Error(t, NewDecoder(`{}`).Decode(&struct{ A int `json:"a,format:hex"` }{}))
+}
+
+func TestDecoder_NaNInf(t *testing.T) {
+    var v struct {
+        A float64
+        B float32
+        C []float64
+    }
+    d := NewDecoder(`{"A":"NaN","B":"-Infinity","C":["Infinity",1.5]}`)
+    d.SetOptions(OptionNaNInfString)
+    require.NoError(t, d.Decode(&v))
+    assert.True(t, math.IsNaN(v.A))
+    assert.True(t, math.IsInf(float64(v.B), -1))
+    assert.Equal(t, []float64{math.Inf(1), 1.5}, v.C)
+
+    var me *MismatchTypeError
+    require.ErrorAs(t, NewDecoder(`{"A":"NaN"}`).Decode(&v), &me)
+    d = NewDecoder(`{"B":"nan"}`)
+    d.SetOptions(OptionNaNInfString)
+    require.ErrorAs(t, d.Decode(&v), &me)
+}
+
+func TestDecoder_QuotedInt(t *testing.T) {
+    var v struct {
+        ID  int64
+        N   uint64
+        I   int
+        U8  uint8
+        IS  []int64
+        S   int64  `json:",string"`
+    }
+    d := NewDecoder(`{"ID":"1152921504606846976","N":"18446744073709551615","I":-3,"U8":"255","IS":["1",2],"S":"7"}`)
+    d.SetOptions(OptionQuotedInt)
+    require.NoError(t, d.Decode(&v))
+    assert.Equal(t, int64(1) << 60, v.ID)
+    assert.Equal(t, uint64(1 << 64 - 1), v.N)
+    assert.Equal(t, -3, v.I)
+    assert.Equal(t, uint8(255), v.U8)
+    assert.Equal(t, []int64{1, 2}, v.IS)
+    assert.Equal(t, int64(7), v.S)
+
+    var me *MismatchTypeError
+    require.ErrorAs(t, NewDecoder(`{"ID":"1"}`).Decode(&v), &me)
+    for _, src := range []string{`{"U8":"256"}`, `{"ID":"01"}`, `{"ID":"+1"}`, `{"ID":"1.0"}`, `{"N":"-1"}`, `{"ID":""}`} {
+        d = NewDecoder(src)
+        d.SetOptions(OptionQuotedInt)
+        require.ErrorAs(t, d.Decode(&v), &me, src)
+    }
+}
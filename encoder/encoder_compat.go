@@ -24,6 +24,8 @@ import (
     `encoding/json`
     `reflect`
 
+    `github.com/bytedance/sonic/internal/encoder/vars`
+    `github.com/bytedance/sonic/internal/resolver`
     `github.com/bytedance/sonic/option`
 )
 
@@ -37,6 +39,13 @@ const EnableFallback = true
 // Options is a set of encoding options.
 type Options uint64
 
+// EncodeError is returned when a nested value fails to encode, and tells
+// the Go path to that value. The fallback encoder never returns it.
+type EncodeError = vars.EncodeError
+
+// FieldMask is a set of the JSON paths to encode, see NewFieldMask.
+type FieldMask = resolver.FieldMask
+
 const (
     bitSortMapKeys          = iota
     bitEscapeHTML          
@@ -46,6 +55,29 @@ const (
     bitValidateString
     bitNoValidateJSONMarshaler
     bitNoEncoderNewline
+    bitEncodeNullForInfOrNan
+    bitDetectCycles
+    bitDurationString
+    bitFloatExponent
+    bitFloatPlain
+    bitFloatTrailingZero
+    bitFloatNaNInfString
+    bitEscapeNonASCII
+    bitInt64String
+    bitUnsafeInt64String
+    bitUnsupportedNull
+    bitUnsupportedSkip
+    bitErrorString
+    bitRedact
+    bitRedactHash
+    bitRedactOmit
+    bitCanonical
+    bitComplexArray
+
+    // the float precision and significant digits take 6 bits from here
+    bitFloatPrecision = 48
+    bitFloatDigits    = 54
+    floatDigitsMask   = 1<<6 - 1
 
     // used for recursive compile
     bitPointerValue = 63
@@ -87,8 +119,105 @@ const (
   
     // CompatibleWithStd is used to be compatible with std encoder.
     CompatibleWithStd Options = SortMapKeys | EscapeHTML | CompactMarshaler
+
+    // Encode Infinity or Nan float into `null`, instead of returning an error.
+    // The fallback ignores it.
+    EncodeNullForInfOrNan Options = 1 << bitEncodeNullForInfOrNan
+
+    // DetectCycles indicates that the encoder should return an error once a value refers to itself.
+    // The fallback ignores it, encoding/json always detects cycles.
+    DetectCycles Options = 1 << bitDetectCycles
+
+    // DurationString indicates that the encoder should write time.Duration as a string like `"1.5s"`.
+    // The fallback ignores it.
+    DurationString Options = 1 << bitDurationString
+
+    // FloatExponent indicates that the encoder should always write floats in exponent notation.
+    // The fallback ignores it.
+    FloatExponent Options = 1 << bitFloatExponent
+
+    // FloatPlain indicates that the encoder should never write floats in exponent notation.
+    // The fallback ignores it.
+    FloatPlain Options = 1 << bitFloatPlain
+
+    // FloatTrailingZero indicates that the encoder should write whole floats like `1.0` instead of `1`.
+    // The fallback ignores it.
+    FloatTrailingZero Options = 1 << bitFloatTrailingZero
+
+    // FloatNaNInfString indicates that the encoder should write NaN and ±Inf floats
+    // as the strings `"NaN"`, `"Infinity"` and `"-Infinity"`. The fallback ignores it.
+    FloatNaNInfString Options = 1 << bitFloatNaNInfString
+
+    // EscapeNonASCII indicates encoder to escape all non-ASCII characters as \uXXXX while quoting strings.
+    // The fallback ignores it.
+    EscapeNonASCII Options = 1 << bitEscapeNonASCII
+
+    // Int64String indicates that the encoder should write all the 64-bit integers as strings like `"123"`.
+    // The fallback ignores it.
+    Int64String Options = 1 << bitInt64String
+
+    // UnsafeInt64String indicates that the encoder should write the 64-bit integers
+    // out of ±(2^53-1), the safe range of JavaScript numbers, as strings. The fallback ignores it.
+    UnsafeInt64String Options = 1 << bitUnsafeInt64String
+
+    // UnsupportedTypeNull indicates that the encoder should write the values of unsupported types,
+    // like channels and functions, as `null` instead of returning an error. The fallback ignores it.
+    UnsupportedTypeNull Options = 1 << bitUnsupportedNull
+
+    // UnsupportedTypeSkip indicates that the encoder should leave out the struct fields of unsupported types,
+    // and write the other values of them as `null` instead of returning an error. The fallback ignores it.
+    UnsupportedTypeSkip Options = 1 << bitUnsupportedSkip
+
+    // ErrorString indicates that the encoder should write `error` values as their Error() strings.
+    // The fallback ignores it.
+    ErrorString Options = 1 << bitErrorString
+
+    // Redact indicates that the encoder should write the fields tagged with `json:",redact"` as `"***"`.
+    // The fallback ignores it.
+    Redact Options = 1 << bitRedact
+
+    // RedactHash indicates that the encoder should write the fields tagged with `json:",redact"`
+    // as the SHA-256 sums of their JSON encoding, like `"sha256:2c26b4..."`. The fallback ignores it.
+    RedactHash Options = 1 << bitRedactHash
+
+    // RedactOmit indicates that the encoder should leave out the fields tagged with `json:",redact"`.
+    // The fallback ignores it.
+    RedactOmit Options = 1 << bitRedactOmit
+
+    // Canonical indicates that the encoder should write the JSON Canonicalization Scheme of RFC 8785.
+    // The fallback ignores it, use sonic.Config{Canonical: true} for the canonical output.
+    Canonical Options = 1 << bitCanonical
+
+    // ComplexArray indicates that the encoder should write complex numbers as `[re, im]`,
+    // instead of taking them as unsupported types. The fallback ignores it.
+    ComplexArray Options = 1 << bitComplexArray
 )
 
+// FloatPrecision returns the option to round floats to at most n digits after the
+// decimal point, where n is in [0, 62]. The fallback ignores it.
+func FloatPrecision(n int) Options {
+    if n < 0 || n >= floatDigitsMask {
+        panic("sonic: float precision out of range")
+    }
+    return Options(uint64(n + 1) << bitFloatPrecision)
+}
+
+// FloatSignificantDigits returns the option to round floats to at most n significant
+// digits, where n is in [1, 63]. The fallback ignores it.
+func FloatSignificantDigits(n int) Options {
+    if n <= 0 || n > floatDigitsMask {
+        panic("sonic: float significant digits out of range")
+    }
+    return Options(uint64(n) << bitFloatDigits)
+}
+
+// TimeFormat returns the option to write time.Time in format, which is either
+// time.RFC3339Nano (the default), "unix", "unixmilli" or a custom layout of time.Format.
+// The fallback always writes time.RFC3339Nano, so it returns no option.
+func TimeFormat(format string) Options {
+    return 0
+}
+
 // Encoder represents a specific set of encoder configurations.
 type Encoder struct {
     Opts Options
@@ -206,6 +335,62 @@ func EncodeInto(buf *[]byte, val interface{}, opts Options) error {
     return err
 }
 
+// NewFieldMask parses the dot-separated JSON paths like `user.name` and `items.*.id` into a mask,
+// where the `*` segment matches every key, and arrays are transparent to the paths.
+func NewFieldMask(paths ...string) (*FieldMask, error) {
+    return resolver.NewFieldMask(paths...)
+}
+
+// EncodeMasked is like Encode, but only writes the parts of val selected by mask.
+// The fallback encodes the whole value and filters it, so the keys of objects are sorted.
+func EncodeMasked(val interface{}, mask *FieldMask, opts Options) ([]byte, error) {
+    var buf []byte
+    if err := EncodeMaskedInto(&buf, val, mask, opts); err != nil {
+        return nil, err
+    }
+    return buf, nil
+}
+
+// EncodeMaskedInto is like EncodeMasked but uses a user-supplied buffer.
+func EncodeMaskedInto(buf *[]byte, val interface{}, mask *FieldMask, opts Options) error {
+    if mask.All() {
+        return EncodeInto(buf, val, opts|NoEncoderNewline)
+    }
+    out, err := json.Marshal(val)
+    if err != nil {
+        return err
+    }
+    var v interface{}
+    dec := json.NewDecoder(bytes.NewReader(out))
+    dec.UseNumber()
+    if err := dec.Decode(&v); err != nil {
+        return err
+    }
+    return EncodeInto(buf, filterMasked(v, mask), opts|NoEncoderNewline)
+}
+
+func filterMasked(v interface{}, m *FieldMask) interface{} {
+    if m.All() {
+        return v
+    }
+    switch x := v.(type) {
+    case map[string]interface{}:
+        for k, e := range x {
+            if c := m.Child(k); c == nil {
+                delete(x, k)
+            } else {
+                x[k] = filterMasked(e, c)
+            }
+        }
+    case []interface{}:
+        c := m.Elem()
+        for i, e := range x {
+            x[i] = filterMasked(e, c)
+        }
+    }
+    return v
+}
+
 // HTMLEscape appends to dst the JSON-encoded src with <, >, &, U+2028 and U+2029
 // characters inside string literals changed to \u003c, \u003e, \u0026, \u2028, \u2029
 // so that the JSON will be safe to embed inside HTML <script> tags.
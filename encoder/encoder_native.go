@@ -34,6 +34,13 @@ type StreamEncoder = encoder.StreamEncoder
 // Options is a set of encoding options.
 type Options = encoder.Options
 
+// EncodeError is returned when a nested value fails to encode, and tells
+// the Go path to that value, like `Report.Sections[4].Chart.Data`.
+type EncodeError = encoder.EncodeError
+
+// FieldMask is a set of the JSON paths to encode, see NewFieldMask.
+type FieldMask = encoder.FieldMask
+
 const (
     // SortMapKeys indicates that the keys of a map needs to be sorted
     // before serializing into JSON.
@@ -73,6 +80,63 @@ const (
 
     // Encode Infinity or Nan float into `null`, instead of returning an error.
     EncodeNullForInfOrNan Options = encoder.EncodeNullForInfOrNan
+
+    // DetectCycles indicates that the encoder should return an error once a value refers to itself.
+    DetectCycles Options = encoder.DetectCycles
+
+    // DurationString indicates that the encoder should write time.Duration as a string like `"1.5s"`.
+    DurationString Options = encoder.DurationString
+
+    // FloatExponent indicates that the encoder should always write floats in exponent notation.
+    FloatExponent Options = encoder.FloatExponent
+
+    // FloatPlain indicates that the encoder should never write floats in exponent notation.
+    FloatPlain Options = encoder.FloatPlain
+
+    // FloatTrailingZero indicates that the encoder should write whole floats like `1.0` instead of `1`.
+    FloatTrailingZero Options = encoder.FloatTrailingZero
+
+    // FloatNaNInfString indicates that the encoder should write NaN and ±Inf floats
+    // as the strings `"NaN"`, `"Infinity"` and `"-Infinity"`.
+    FloatNaNInfString Options = encoder.FloatNaNInfString
+
+    // EscapeNonASCII indicates encoder to escape all non-ASCII characters as \uXXXX while quoting strings.
+    EscapeNonASCII Options = encoder.EscapeNonASCII
+
+    // Int64String indicates that the encoder should write all the 64-bit integers as strings like `"123"`.
+    Int64String Options = encoder.Int64String
+
+    // UnsafeInt64String indicates that the encoder should write the 64-bit integers
+    // out of ±(2^53-1), the safe range of JavaScript numbers, as strings.
+    UnsafeInt64String Options = encoder.UnsafeInt64String
+
+    // UnsupportedTypeNull indicates that the encoder should write the values of unsupported types,
+    // like channels and functions, as `null` instead of returning an error.
+    UnsupportedTypeNull Options = encoder.UnsupportedTypeNull
+
+    // UnsupportedTypeSkip indicates that the encoder should leave out the struct fields of unsupported types,
+    // and write the other values of them as `null` instead of returning an error.
+    UnsupportedTypeSkip Options = encoder.UnsupportedTypeSkip
+
+    // ErrorString indicates that the encoder should write `error` values as their Error() strings.
+    ErrorString Options = encoder.ErrorString
+
+    // Redact indicates that the encoder should write the fields tagged with `json:",redact"` as `"***"`.
+    Redact Options = encoder.Redact
+
+    // RedactHash indicates that the encoder should write the fields tagged with `json:",redact"`
+    // as the SHA-256 sums of their JSON encoding, like `"sha256:2c26b4..."`.
+    RedactHash Options = encoder.RedactHash
+
+    // RedactOmit indicates that the encoder should leave out the fields tagged with `json:",redact"`.
+    RedactOmit Options = encoder.RedactOmit
+
+    // Canonical indicates that the encoder should write the JSON Canonicalization Scheme of RFC 8785.
+    Canonical Options = encoder.Canonical
+
+    // ComplexArray indicates that the encoder should write complex numbers as `[re, im]`,
+    // instead of taking them as unsupported types.
+    ComplexArray Options = encoder.ComplexArray
 )
 
 
@@ -117,4 +181,25 @@ var (
     //
     // NewStreamEncoder returns a new encoder that write to w.
     NewStreamEncoder = encoder.NewStreamEncoder
+
+    // TimeFormat returns the option to write time.Time in format, which is either
+    // time.RFC3339Nano (the default), "unix", "unixmilli" or a custom layout of time.Format.
+    TimeFormat = encoder.TimeFormat
+
+    // FloatPrecision returns the option to round floats to at most n digits after the decimal point.
+    FloatPrecision = encoder.FloatPrecision
+
+    // FloatSignificantDigits returns the option to round floats to at most n significant digits.
+    FloatSignificantDigits = encoder.FloatSignificantDigits
+
+    // NewFieldMask parses the dot-separated JSON paths like `user.name` and `items.*.id` into a mask,
+    // where the `*` segment matches every key, and arrays are transparent to the paths.
+    NewFieldMask = encoder.NewFieldMask
+
+    // EncodeMasked is like Encode, but only writes the parts of val selected by mask,
+    // and the rest are skipped without being encoded. A nil mask selects everything.
+    EncodeMasked = encoder.EncodeMasked
+
+    // EncodeMaskedInto is like EncodeMasked but uses a user-supplied buffer.
+    EncodeMaskedInto = encoder.EncodeMaskedInto
 )
@@ -36,6 +36,8 @@ const (
 	_F_use_number = consts.F_use_number
 	_F_validate_string = consts.F_validate_string
     _F_case_sensitive = consts.F_case_sensitive
+    _F_collect_errors = consts.F_collect_errors
+    _F_use_big_int = consts.F_use_big_int
 
 	_MaxStack = consts.MaxStack
 
@@ -47,12 +49,46 @@ const (
     OptionValidateString   = consts.OptionValidateString
     OptionNoValidateJSON   = consts.OptionNoValidateJSON
     OptionCaseSensitive    = consts.OptionCaseSensitive
+    OptionCollectErrors    = consts.OptionCollectErrors
+    OptionUseBigInt        = consts.OptionUseBigInt
+    OptionDurationString   = consts.OptionDurationString
+    OptionNaNInfString     = consts.OptionNaNInfString
+    OptionQuotedInt        = consts.OptionQuotedInt
+
+    OptionCoerceStringToNumber = consts.OptionCoerceStringToNumber
+    OptionCoerceNumberToString = consts.OptionCoerceNumberToString
+    OptionCoerceBool           = consts.OptionCoerceBool
+    OptionCoerceFloatToInt     = consts.OptionCoerceFloatToInt
+    OptionCoerceSingleToSlice  = consts.OptionCoerceSingleToSlice
+    OptionCoerceEmptyToNil     = consts.OptionCoerceEmptyToNil
+    OptionWeaklyTyped          = consts.OptionWeaklyTyped
 )
 
 type (
 	Options = consts.Options
 	MismatchTypeError = errors.MismatchTypeError
 	SyntaxError = errors.SyntaxError
+    DecodeError = errors.DecodeError
+    DecodeErrors = errors.DecodeErrors
+    MissingFieldError = errors.MissingFieldError
+    UnionError = errors.UnionError
+    FieldState = consts.FieldState
+    CoercionKind = consts.CoercionKind
+    Coercion = consts.Coercion
+)
+
+const (
+    FieldAbsent  = consts.FieldAbsent
+    FieldPresent = consts.FieldPresent
+    FieldNull    = consts.FieldNull
+    FieldUnknown = consts.FieldUnknown
+
+    CoerceStringToNumber = consts.CoerceStringToNumber
+    CoerceNumberToString = consts.CoerceNumberToString
+    CoerceBool           = consts.CoerceBool
+    CoerceFloatToInt     = consts.CoerceFloatToInt
+    CoerceSingleToSlice  = consts.CoerceSingleToSlice
+    CoerceEmptyToNil     = consts.CoerceEmptyToNil
 )
 
 func (self *Decoder) SetOptions(opts Options) {
@@ -112,9 +148,32 @@ func (self *Decoder) CheckTrailings() error {
 // Decode parses the JSON-encoded data from current position and stores the result
 // in the value pointed to by val.
 func (self *Decoder) Decode(val interface{}) error {
+    if (self.f & (1 << _F_collect_errors | uint64(OptionWeaklyTyped))) != 0 {
+        return collectImpl(&self.s, &self.i, self.f, val)
+    }
 	return decodeImpl(&self.s, &self.i, self.f, val)
 }
 
+// DecodePresence decodes like Decode, and also reports which struct fields were in
+// the input, keyed by their Go paths like `Items[0].Name` or `Tags["k"].Name`.
+// Fields are either FieldPresent or FieldNull, object keys that match no field
+// of a struct are FieldUnknown under the path of the struct, like `Items[0].key`,
+// and absent fields are not in the map at all. Elements of arrays and maps
+// are not reported themselves, only the fields of the structs in them.
+func (self *Decoder) DecodePresence(val interface{}) (map[string]FieldState, error) {
+    if (self.f & (1 << _F_collect_errors | uint64(OptionWeaklyTyped))) != 0 {
+        return collectPresenceImpl(&self.s, &self.i, self.f, val)
+    }
+    return presenceImpl(&self.s, &self.i, self.f, val)
+}
+
+// DecodeCoercions decodes like Decode, and also reports each value that was
+// coerced across JSON types, in the order of the input. Only the kinds enabled
+// by the options (see OptionWeaklyTyped) are coerced, others are mismatched as usual.
+func (self *Decoder) DecodeCoercions(val interface{}) ([]Coercion, error) {
+    return coercionImpl(&self.s, &self.i, self.f, val)
+}
+
 // UseInt64 indicates the Decoder to unmarshal an integer into an interface{} as an
 // int64 instead of as a float64.
 func (self *Decoder) UseInt64() {
@@ -122,6 +181,14 @@ func (self *Decoder) UseInt64() {
     self.f &^= 1 << _F_use_number
 }
 
+// OptionTimeFormat returns the option to decode time.Time in format, which is either
+// time.RFC3339Nano (the default), "unix" and "unixmilli" for the integer seconds and
+// milliseconds since the Unix epoch, or a custom layout of time.Parse. The formatted
+// strings are still accepted by the unix formats.
+func OptionTimeFormat(format string) Options {
+    return consts.OptionTimeFormat(format)
+}
+
 // UseNumber indicates the Decoder to unmarshal a number into an interface{} as a
 // json.Number instead of as a float64.
 func (self *Decoder) UseNumber() {
@@ -129,6 +196,13 @@ func (self *Decoder) UseNumber() {
     self.f  |= 1 << _F_use_number
 }
 
+// UseBigInt indicates the Decoder to unmarshal an integer that overflows int64 into
+// an interface{} as a *big.Int instead of as a float64 or an int64, which keeps all
+// its digits. The other numbers are unmarshaled as usual.
+func (self *Decoder) UseBigInt() {
+    self.f |= 1 << _F_use_big_int
+}
+
 // UseUnicodeErrors indicates the Decoder to return an error when encounter invalid
 // UTF-8 escape sequences.
 func (self *Decoder) UseUnicodeErrors() {
@@ -147,6 +221,18 @@ func (self *Decoder) CopyString() {
     self.f |= 1 << _F_copy_string
 }
 
+// CollectErrors indicates the Decoder to go on decoding after mismatched types,
+// overflows and invalid values, and return all of them as DecodeErrors.
+func (self *Decoder) CollectErrors() {
+    self.f |= 1 << _F_collect_errors
+}
+
+// WeaklyTyped indicates the Decoder to coerce values across JSON types with all
+// the kinds of coercions, such as `"42"` into an int or `42` into a string.
+func (self *Decoder) WeaklyTyped() {
+    self.f |= uint64(OptionWeaklyTyped)
+}
+
 // ValidateString causes the Decoder to validate string values when decoding string value 
 // in JSON. Validation is that, returning error when unescaped control chars(0x00-0x1f) or
 // invalid UTF-8 chars in the string value of JSON.
@@ -28,11 +28,24 @@ import (
 var (
 	pretouchImpl = jitdec.Pretouch
 	decodeImpl = jitdec.Decode
+
+	// the JIT decoder can only go on after mismatched types,
+	// so collecting all the errors is left to the generic one
+	collectImpl = optdec.Decode
+
+	// the JIT decoder tracks presence with programs compiled for it,
+	// while the generic one takes it from the parsed document
+	presenceImpl = jitdec.DecodePresence
+	collectPresenceImpl = optdec.DecodePresence
+
+	// so are the coercions of the weakly typed modes
+	coercionImpl = optdec.DecodeCoercions
 ) 
 
  func init() {
 	if envs.UseOptDec {
 		pretouchImpl = optdec.Pretouch
 		decodeImpl = optdec.Decode
+		presenceImpl = optdec.DecodePresence
 	}
  }
@@ -26,6 +26,10 @@ import (
 var (
 	pretouchImpl = optdec.Pretouch
 	decodeImpl = optdec.Decode
+	collectImpl = optdec.Decode
+	presenceImpl = optdec.DecodePresence
+	collectPresenceImpl = optdec.DecodePresence
+	coercionImpl = optdec.DecodeCoercions
 )
 
 
@@ -0,0 +1,58 @@
+package consts
+
+import (
+    `reflect`
+)
+
+// CoercionKind is a kind of lenient conversion across JSON types,
+// each one is enabled by its own option.
+type CoercionKind uint8
+
+const (
+    // CoerceStringToNumber decodes a string like `"42"` into a number.
+    CoerceStringToNumber CoercionKind = iota
+
+    // CoerceNumberToString decodes a number like `42` into a string as it is written.
+    CoerceNumberToString
+
+    // CoerceBool decodes `1`, `0`, `"true"` and `"false"` into a bool.
+    CoerceBool
+
+    // CoerceFloatToInt decodes an integral float like `1.0` into an integer.
+    CoerceFloatToInt
+
+    // CoerceSingleToSlice decodes a value that is not an array into a one-element slice.
+    CoerceSingleToSlice
+
+    // CoerceEmptyToNil decodes `""` into a nil pointer of a non-string type.
+    CoerceEmptyToNil
+)
+
+// Option returns the option that enables the kind.
+func (self CoercionKind) Option() Options {
+    return 1 << (F_coerce_string_to_number + uint(self))
+}
+
+func (self CoercionKind) String() string {
+    switch self {
+        case CoerceStringToNumber : return "string to number"
+        case CoerceNumberToString : return "number to string"
+        case CoerceBool           : return "bool"
+        case CoerceFloatToInt     : return "float to int"
+        case CoerceSingleToSlice  : return "single to slice"
+        case CoerceEmptyToNil     : return "empty to nil"
+        default                   : return "CoercionKind(?)"
+    }
+}
+
+// Coercion describes a value that was decoded across JSON types.
+type Coercion struct {
+    // Kind is the kind of the conversion
+    Kind CoercionKind
+    // Path is the JSON path of the value, such as `$.users[3].age`
+    Path string
+    // Pos is the byte offset of the value in the source
+    Pos  int
+    // Type is the Go type that the value was decoded into
+    Type reflect.Type
+}
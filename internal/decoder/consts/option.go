@@ -3,6 +3,7 @@ package consts
 
 import (
     `github.com/bytedance/sonic/internal/native/types`
+    `github.com/bytedance/sonic/internal/utils`
 )
 
 
@@ -17,6 +18,29 @@ const (
     F_allow_control   = types.B_ALLOW_CONTROL
     F_no_validate_json = types.B_NO_VALIDATE_JSON
     F_case_sensitive = 7
+    F_collect_errors = 8
+
+    // F_coerce_string_to_number is the first bit of the coercions,
+    // the bit of each CoercionKind follows it in order.
+    F_coerce_string_to_number = 9
+    F_coerce_number_to_string = 10
+    F_coerce_bool             = 11
+    F_coerce_float_to_int     = 12
+    F_coerce_single_to_slice  = 13
+    F_coerce_empty_to_nil     = 14
+
+    F_use_big_int = 15
+    F_duration_string = 16
+    F_nan_inf_string = 17
+    F_quoted_int = 18
+
+    // F_presence tells the JIT decoder to track the fields for DecodePresence,
+    // it is set internally and is not an option.
+    F_presence = 19
+
+    // F_time_format is the first bit of the time format id, which takes
+    // utils.TimeFormatBits bits.
+    F_time_format = 32
 )
 
 type Options uint64
@@ -30,8 +54,28 @@ const (
     OptionValidateString   Options = 1 << F_validate_string
     OptionNoValidateJSON   Options = 1 << F_no_validate_json
     OptionCaseSensitive    Options = 1 << F_case_sensitive
+    OptionCollectErrors    Options = 1 << F_collect_errors
+    OptionUseBigInt        Options = 1 << F_use_big_int
+    OptionDurationString   Options = 1 << F_duration_string
+    OptionNaNInfString     Options = 1 << F_nan_inf_string
+    OptionQuotedInt        Options = 1 << F_quoted_int
+
+    OptionCoerceStringToNumber Options = 1 << F_coerce_string_to_number
+    OptionCoerceNumberToString Options = 1 << F_coerce_number_to_string
+    OptionCoerceBool           Options = 1 << F_coerce_bool
+    OptionCoerceFloatToInt     Options = 1 << F_coerce_float_to_int
+    OptionCoerceSingleToSlice  Options = 1 << F_coerce_single_to_slice
+    OptionCoerceEmptyToNil     Options = 1 << F_coerce_empty_to_nil
+
+    OptionWeaklyTyped = OptionCoerceStringToNumber | OptionCoerceNumberToString | OptionCoerceBool |
+        OptionCoerceFloatToInt | OptionCoerceSingleToSlice | OptionCoerceEmptyToNil
 )
 
+// OptionTimeFormat returns the option to decode time.Time in format, see utils.TimeFormatID.
+func OptionTimeFormat(format string) Options {
+    return Options(utils.TimeFormatID(format) << F_time_format)
+}
+
 const (
 	MaxStack = 4096
 )
\ No newline at end of file
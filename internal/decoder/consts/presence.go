@@ -0,0 +1,28 @@
+package consts
+
+// FieldState tells how a field of the decoded value appeared in the input.
+type FieldState uint8
+
+const (
+    // FieldAbsent is the zero state, for keys that are not in the input.
+    FieldAbsent FieldState = iota
+
+    // FieldPresent marks a field that has a non-null value in the input.
+    FieldPresent
+
+    // FieldNull marks a field that is explicitly `null` in the input.
+    FieldNull
+
+    // FieldUnknown marks an object key that does not match any field.
+    FieldUnknown
+)
+
+func (self FieldState) String() string {
+    switch self {
+        case FieldAbsent  : return "absent"
+        case FieldPresent : return "present"
+        case FieldNull    : return "null"
+        case FieldUnknown : return "unknown"
+        default           : return "FieldState(?)"
+    }
+}
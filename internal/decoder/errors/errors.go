@@ -25,6 +25,7 @@ import (
     `strings`
 
     `github.com/bytedance/sonic/internal/native/types`
+    `github.com/bytedance/sonic/internal/resolver`
     `github.com/bytedance/sonic/internal/rt`
 )
 
@@ -95,6 +96,23 @@ func calcBounds(size int, pos int) (lbound int, lwidth int, rbound int, rwidth i
     return
 }
 
+// Line returns the 1-based line number of the error position.
+func (self SyntaxError) Line() int {
+    line, _ := location(self.Src, self.Pos)
+    return line
+}
+
+// Column returns the 1-based column number (in bytes) of the error position.
+func (self SyntaxError) Column() int {
+    _, column := location(self.Src, self.Pos)
+    return column
+}
+
+// Path returns the JSON path of the value being parsed when the error occurred, such as `$.users[3].age`.
+func (self SyntaxError) Path() string {
+    return JSONPath(self.Src, self.Pos)
+}
+
 func (self SyntaxError) Message() string {
     if self.Msg == "" {
         return self.Code.Message()
@@ -138,6 +156,9 @@ type MismatchTypeError struct {
     Pos  int
     Src  string
     Type reflect.Type
+
+    // RootType is the type of the value that the decoding started from, it is used by FieldPath
+    RootType reflect.Type
 }
 
 func swithchJSONType (src string, pos int) string {
@@ -171,6 +192,163 @@ func (self MismatchTypeError) Description() string {
     return fmt.Sprintf("Mismatch type %s with value %s %s", self.Type.String(), swithchJSONType(self.Src, self.Pos), se.description())
 }
 
+// Line returns the 1-based line number of the mismatched value.
+func (self MismatchTypeError) Line() int {
+    line, _ := location(self.Src, self.Pos)
+    return line
+}
+
+// Column returns the 1-based column number (in bytes) of the mismatched value.
+func (self MismatchTypeError) Column() int {
+    _, column := location(self.Src, self.Pos)
+    return column
+}
+
+// Path returns the JSON path of the mismatched value, such as `$.items[2].price`.
+func (self MismatchTypeError) Path() string {
+    return JSONPath(self.Src, self.Pos)
+}
+
+// FieldPath returns the path of the Go field that the mismatched value was decoded into,
+// such as `Order.Items[2].Price`, it is empty if RootType is unknown.
+func (self MismatchTypeError) FieldPath() string {
+    if self.RootType == nil {
+        return ""
+    }
+    return fieldPath(self.RootType, jsonPath(self.Src, self.Pos))
+}
+
+// MissingFieldError reports a `required` field whose key is absent from the object.
+type MissingFieldError struct {
+    // Pos is the position right after the object
+    Pos   int
+    Src   string
+    Field string
+    Type  reflect.Type
+
+    // RootType is the type of the value that the decoding started from, it is used by FieldPath
+    RootType reflect.Type
+}
+
+func (self *MissingFieldError) Error() string {
+    return "json: " + self.message(self.Path())
+}
+
+func (self *MissingFieldError) message(path string) string {
+    return fmt.Sprintf("missing required field %q of %s at %s", self.Field, self.Type, path)
+}
+
+// Line returns the 1-based line number of the end of the object.
+func (self *MissingFieldError) Line() int {
+    line, _ := location(self.Src, self.Pos)
+    return line
+}
+
+// Column returns the 1-based column number (in bytes) of the end of the object.
+func (self *MissingFieldError) Column() int {
+    _, column := location(self.Src, self.Pos)
+    return column
+}
+
+// Path returns the JSON path of the missing field, such as `$.users[3].name`.
+func (self *MissingFieldError) Path() string {
+    return appendKeyPath(JSONPath(self.Src, self.Pos), self.Field)
+}
+
+func appendKeyPath(path string, key string) string {
+    if isIdent(key) {
+        return path + "." + key
+    }
+    return path + "[" + strconv.Quote(key) + "]"
+}
+
+// FieldPath returns the path of the Go field that is missing, such as `Order.Users[3].Name`,
+// it is empty if RootType is unknown.
+func (self *MissingFieldError) FieldPath() string {
+    if self.RootType == nil {
+        return ""
+    }
+    path := fieldPath(self.RootType, jsonPath(self.Src, self.Pos))
+    if sf, ok := resolver.FieldByName(self.Type, self.Field); ok {
+        if path != "" {
+            path += "."
+        }
+        path += sf.Name
+    }
+    return path
+}
+
+func ErrorMissingField(src string, pos int, vt reflect.Type, name string) error {
+    return &MissingFieldError {
+        Pos   : pos,
+        Src   : src,
+        Field : name,
+        Type  : vt,
+    }
+}
+
+// UnionError reports an object of a registered union interface
+// whose discriminator is either absent or not registered.
+type UnionError struct {
+    // Pos is the position of the object
+    Pos     int
+    Src     string
+    Type    reflect.Type
+    Key     string
+    Value   string
+    Missing bool
+}
+
+func (self *UnionError) Error() string {
+    return "json: " + self.message(self.Path())
+}
+
+func (self *UnionError) message(path string) string {
+    if self.Missing {
+        return fmt.Sprintf("missing discriminator %q of union %s at %s", self.Key, self.Type, path)
+    }
+    return fmt.Sprintf("unknown discriminator %q of union %s at %s", self.Value, self.Type, path)
+}
+
+// Path returns the JSON path of the object, such as `$.shapes[3]`.
+func (self *UnionError) Path() string {
+    return JSONPath(self.Src, self.Pos)
+}
+
+func ErrorUnion(src string, pos int, vt reflect.Type, key string, value string, missing bool) error {
+    return &UnionError {
+        Pos     : pos,
+        Src     : src,
+        Type    : vt,
+        Key     : key,
+        Value   : value,
+        Missing : missing,
+    }
+}
+
+// WithRootType records the type that the decoding started from into the mismatch errors.
+func WithRootType(err error, vt reflect.Type) error {
+    switch e := err.(type) {
+        case *MismatchTypeError : e.RootType = vt
+        case MismatchTypeError  : e.RootType = vt; return e
+        case *MissingFieldError : e.RootType = vt
+        case DecodeErrors       : for _, d := range e { d.Err = WithRootType(d.Err, vt) }
+    }
+    return err
+}
+
+func location(src string, pos int) (line int, column int) {
+    if pos > len(src) {
+        pos = len(src)
+    }
+    if pos < 0 {
+        pos = 0
+    }
+    line = 1 + strings.Count(src[:pos], "\n")
+    column = pos - strings.LastIndexByte(src[:pos], '\n')
+    return
+}
+
 func ErrorMismatch(src string, pos int, vt *rt.GoType) error {
     return &MismatchTypeError {
         Pos  : pos,
@@ -189,3 +367,302 @@ func ErrorValue(value string, vtype reflect.Type) error {
         Value : value,
     }
 }
+
+// DecodeError describes a single value that failed to decode, it is collected
+// when decoding with `OptionCollectErrors`.
+type DecodeError struct {
+    // Path is the JSON path of the value, such as `$.users[3].age`
+    Path     string
+    // Pos is the byte offset of the value in the source
+    Pos      int
+    // Type is the expected Go type, it may be nil for errors that are not about types
+    Type     reflect.Type
+    // JSONType is the actual JSON type of the value, such as "string" or "object"
+    JSONType string
+    // Err is the original error
+    Err      error
+}
+
+// NewDecodeError creates a DecodeError for the value at pos, where start is
+// the position in src that the decoding started from.
+func NewDecodeError(src string, start int, pos int, err error) *DecodeError {
+    return NewPathScanner(src, start).DecodeError(pos, err)
+}
+
+// DecodeError creates a DecodeError for the value at pos like NewDecodeError, where the
+// scanner starts from the position that the decoding started from. The path is scanned
+// on from the last error, so the errors of one decoding are located in one pass.
+func (self *PathScanner) DecodeError(pos int, err error) *DecodeError {
+    src := self.src
+    var vt reflect.Type
+    switch e := err.(type) {
+        case MismatchTypeError  : vt = e.Type
+        case *MismatchTypeError : vt = e.Type
+        case *json.UnmarshalTypeError : vt = e.Type
+    }
+    ret := &DecodeError {
+        Pos  : pos,
+        Type : vt,
+        Err  : err,
+    }
+
+    /* the missing field is inside the object */
+    if e, ok := err.(*MissingFieldError); ok {
+        ret.Path = appendKeyPath(self.JSONPath(e.Pos), e.Field)
+    } else {
+        ret.Path = self.JSONPath(pos)
+    }
+    if pos >= 0 && pos < len(src) {
+        ret.JSONType = swithchJSONType(src, pos)
+        if ret.JSONType == "" && src[pos] == 'n' {
+            ret.JSONType = "null"
+        }
+    }
+    return ret
+}
+
+func (self *DecodeError) Error() string {
+    switch e := self.Err.(type) {
+        case *MissingFieldError : return fmt.Sprintf("json: %s (index %d)", e.message(self.Path), self.Pos)
+        case *UnionError        : return fmt.Sprintf("json: %s (index %d)", e.message(self.Path), self.Pos)
+    }
+    if self.Type == nil {
+        return fmt.Sprintf("json: %s (at %s, index %d)", self.message(), self.Path, self.Pos)
+    }
+    return fmt.Sprintf("json: cannot unmarshal %s at %s (index %d) into Go value of type %s", self.JSONType, self.Path, self.Pos, self.Type.String())
+}
+
+func (self *DecodeError) message() string {
+    return strings.TrimPrefix(self.Err.Error(), "json: ")
+}
+
+func (self *DecodeError) Unwrap() error {
+    return self.Err
+}
+
+// DecodeErrors is the aggregation of all the errors collected in one decoding.
+type DecodeErrors []*DecodeError
+
+func (self DecodeErrors) Error() string {
+    if len(self) == 1 {
+        return self[0].Error()
+    }
+    buf := make([]string, 0, len(self) + 1)
+    buf = append(buf, fmt.Sprintf("json: %d errors occurred while decoding:", len(self)))
+    for _, e := range self {
+        buf = append(buf, "\t" + strings.TrimPrefix(e.Error(), "json: "))
+    }
+    return strings.Join(buf, "\n")
+}
+
+// Unwrap returns all the collected errors.
+func (self DecodeErrors) Unwrap() []error {
+    ret := make([]error, len(self))
+    for i, e := range self {
+        ret[i] = e
+    }
+    return ret
+}
+
+type pathFrame struct {
+    key    string
+    index  int
+    isObj  bool
+    hasKey bool
+}
+
+// name unquotes the key of the frame, which is kept quoted as in the source until a path is built.
+func (self *pathFrame) name() string {
+    key := self.key
+    if strings.IndexByte(key, '\\') < 0 {
+        return strings.Trim(key, `"`)
+    }
+    if err := json.Unmarshal([]byte(key), &key); err != nil {
+        return strings.Trim(self.key, `"`)
+    }
+    return key
+}
+
+// JSONPath returns the path of the value at pos in a well-formed JSON src, such as `$.users[3].age`.
+func JSONPath(src string, pos int) string {
+    return NewPathScanner(src, 0).JSONPath(pos)
+}
+
+func jsonPath(src string, pos int) []pathFrame {
+    sc := NewPathScanner(src, 0)
+    sc.advance(pos)
+    return sc.stack
+}
+
+// PathScanner walks through a well-formed JSON text once, tracking the key or
+// index of each level, so that the paths of increasing positions are found in one pass.
+// A position less than the last one is scanned over again from the start.
+type PathScanner struct {
+    src   string
+    start int
+    pos   int
+    stack []pathFrame
+}
+
+// NewPathScanner returns a PathScanner of src starting at pos.
+func NewPathScanner(src string, pos int) *PathScanner {
+    return &PathScanner{src: src, start: pos, pos: pos}
+}
+
+// JSONPath returns the path of the value at pos like JSONPath, relative to the start of the scanner.
+func (self *PathScanner) JSONPath(pos int) string {
+    self.advance(pos)
+    buf := []byte{'$'}
+    for _, f := range self.stack {
+        if !f.isObj {
+            buf = append(buf, '[')
+            buf = strconv.AppendInt(buf, int64(f.index), 10)
+            buf = append(buf, ']')
+        } else if !f.hasKey {
+            continue
+        } else if key := f.name(); isIdent(key) {
+            buf = append(buf, '.')
+            buf = append(buf, key...)
+        } else {
+            buf = append(buf, '[')
+            buf = strconv.AppendQuote(buf, key)
+            buf = append(buf, ']')
+        }
+    }
+    return string(buf)
+}
+
+// ObjectPath returns the Go path from vt of the innermost object that is open at pos,
+// like `Items[0].Addr`, which is empty for the root.
+func (self *PathScanner) ObjectPath(vt reflect.Type, pos int) string {
+    self.advance(pos)
+    if n := len(self.stack); n != 0 {
+        return string(appendFieldPath(nil, vt, self.stack[:n - 1]))
+    }
+    return ""
+}
+
+func (self *PathScanner) advance(pos int) {
+    src := self.src
+    if pos > len(src) {
+        pos = len(src)
+    }
+    if pos < self.pos {
+        self.pos = self.start
+        self.stack = self.stack[:0]
+    }
+
+    /* walk through the source, tracking the current key or index of each level */
+    for i := self.pos; i < pos; i++ {
+        switch c := src[i]; c {
+            case '{', '[': {
+                self.stack = append(self.stack, pathFrame { isObj: c == '{', index: 0 })
+            }
+            case '}', ']': {
+                if len(self.stack) > 0 {
+                    self.stack = self.stack[:len(self.stack) - 1]
+                }
+            }
+            case ',': {
+                if n := len(self.stack); n > 0 {
+                    self.stack[n - 1].index++
+                    self.stack[n - 1].hasKey = false
+                }
+            }
+            case '"': {
+                j := skipString(src, i)
+                if n := len(self.stack); n > 0 && self.stack[n - 1].isObj && isKey(src, j) {
+                    self.stack[n - 1].hasKey = true
+                    self.stack[n - 1].key = src[i:j]
+                }
+                i = j - 1
+            }
+        }
+        self.pos = i + 1
+    }
+    if self.pos < pos {
+        self.pos = pos
+    }
+}
+
+// fieldPath follows the JSON path from the Go type vt, as far as the Go types are known.
+func fieldPath(vt reflect.Type, stack []pathFrame) string {
+    return string(appendFieldPath([]byte(vt.Name()), vt, stack))
+}
+
+func appendFieldPath(buf []byte, vt reflect.Type, stack []pathFrame) []byte {
+    for _, f := range stack {
+        for vt.Kind() == reflect.Ptr || resolver.IsOptional(vt) {
+            if vt.Kind() == reflect.Ptr {
+                vt = vt.Elem()
+            } else {
+                vt, _ = resolver.OptionalValue(vt)
+            }
+        }
+
+        switch k := vt.Kind(); {
+            case !f.isObj && (k == reflect.Slice || k == reflect.Array): {
+                buf = append(buf, '[')
+                buf = strconv.AppendInt(buf, int64(f.index), 10)
+                buf = append(buf, ']')
+                vt = vt.Elem()
+            }
+            case f.isObj && f.hasKey && k == reflect.Map: {
+                buf = append(buf, '[')
+                buf = strconv.AppendQuote(buf, f.name())
+                buf = append(buf, ']')
+                vt = vt.Elem()
+            }
+            case f.isObj && f.hasKey && k == reflect.Struct: {
+                sf, ok := resolver.FieldByName(vt, f.name())
+                if !ok {
+                    return buf
+                }
+                if len(buf) != 0 {
+                    buf = append(buf, '.')
+                }
+                buf = append(buf, sf.Name...)
+                vt = sf.Type
+            }
+            /* the values inside an interface have no Go types */
+            default: {
+                return buf
+            }
+        }
+    }
+    return buf
+}
+
+func skipString(src string, i int) int {
+    for i++; i < len(src); i++ {
+        switch src[i] {
+            case '\\': i++
+            case '"' : return i + 1
+        }
+    }
+    return len(src)
+}
+
+func isKey(src string, i int) bool {
+    for ; i < len(src); i++ {
+        switch src[i] {
+            case ' ', '\t', '\r', '\n': continue
+            case ':': return true
+            default : return false
+        }
+    }
+    return false
+}
+
+func isIdent(key string) bool {
+    if key == "" {
+        return false
+    }
+    for i := 0; i < len(key); i++ {
+        c := key[i]
+        if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+            return false
+        }
+    }
+    return true
+}
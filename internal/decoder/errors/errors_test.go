@@ -17,9 +17,12 @@
 package errors
 
 import (
+    `reflect`
+    `strings`
     `testing`
 
     `github.com/bytedance/sonic/internal/native/types`
+    `github.com/bytedance/sonic/internal/rt`
     `github.com/stretchr/testify/assert`
 )
 
@@ -59,3 +62,67 @@ func TestErrors_EmptyDescription(t *testing.T) {
 }
 
 
+
+func TestErrors_JSONPath(t *testing.T) {
+    src := `{"users": [{"name": "a", "age": 1}, {"name": "b", "age": "x", "a.b": [0, {"c" : null}]}]}`
+    assert.Equal(t, "$", JSONPath(src, 0))
+    assert.Equal(t, "$.users[1].age", JSONPath(src, strings.Index(src, `"x"`)))
+    assert.Equal(t, `$.users[1]["a.b"][1].c`, JSONPath(src, strings.Index(src, `null`)))
+    assert.Equal(t, "$.users[0]", JSONPath(src, strings.Index(src, `"name"`)))
+
+    /* keys are unquoted as json, and the positions may go back */
+    src = `{"a\/b": [{"\ud83d\ude00": 1, "c": 2}], "d": 3}`
+    sc := NewPathScanner(src, 0)
+    assert.Equal(t, `$["a/b"][0]["😀"]`, sc.JSONPath(strings.Index(src, `1`)))
+    assert.Equal(t, "$.d", sc.JSONPath(strings.Index(src, `: 3`) + 2))
+    assert.Equal(t, `$["a/b"][0].c`, sc.JSONPath(strings.Index(src, `2`)))
+}
+
+func TestErrors_DecodeErrors(t *testing.T) {
+    src := `{"age":"x"}`
+    err := DecodeErrors{NewDecodeError(src, 0, 7, ErrorMismatch(src, 7, rt.UnpackType(reflect.TypeOf(0))))}
+    assert.Equal(t, "$.age", err[0].Path)
+    assert.Equal(t, "string", err[0].JSONType)
+    assert.Equal(t, reflect.TypeOf(0), err[0].Type)
+    assert.Equal(t, "json: cannot unmarshal string at $.age (index 7) into Go value of type int", err.Error())
+    assert.Len(t, err.Unwrap(), 1)
+}
+
+func TestErrors_Location(t *testing.T) {
+    e := make_err("{\n  \"a\": [1,\n   x]}", 16)
+    assert.Equal(t, 3, e.Line())
+    assert.Equal(t, 4, e.Column())
+    assert.Equal(t, "$.a[1]", e.Path())
+
+    e = make_err("", 0)
+    assert.Equal(t, 1, e.Line())
+    assert.Equal(t, 1, e.Column())
+}
+
+type testItem struct {
+    Price float64 `json:"price"`
+}
+
+type testOrder struct {
+    Items []*testItem            `json:"items"`
+    Meta  map[string][2]testItem `json:"meta"`
+    Any   interface{}            `json:"any"`
+}
+
+func TestErrors_FieldPath(t *testing.T) {
+    mismatch := func(src string, sub string) MismatchTypeError {
+        return MismatchTypeError{Src: src, Pos: strings.Index(src, sub), RootType: reflect.TypeOf(testOrder{})}
+    }
+    e := mismatch(`{"items": [{}, {}, {"price": "x"}]}`, `"x"`)
+    assert.Equal(t, "$.items[2].price", e.Path())
+    assert.Equal(t, "testOrder.Items[2].Price", e.FieldPath())
+
+    e = mismatch(`{"meta": {"k": [{}, {"PRICE": "x"}]}}`, `"x"`)
+    assert.Equal(t, `testOrder.Meta["k"][1].Price`, e.FieldPath())
+
+    e = mismatch(`{"any": {"a": "x"}}`, `"x"`)
+    assert.Equal(t, "testOrder.Any", e.FieldPath())
+
+    e.RootType = nil
+    assert.Equal(t, "", e.FieldPath())
+}
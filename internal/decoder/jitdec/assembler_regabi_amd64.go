@@ -296,6 +296,7 @@ var _OpFuncTab = [256]func(*_Assembler, *_Instr) {
     _OP_slice_append     : (*_Assembler)._asm_OP_slice_append,
     _OP_object_next      : (*_Assembler)._asm_OP_object_next,
     _OP_struct_field     : (*_Assembler)._asm_OP_struct_field,
+    _OP_struct_inline    : (*_Assembler)._asm_OP_struct_inline,
     _OP_unmarshal        : (*_Assembler)._asm_OP_unmarshal,
     _OP_unmarshal_p      : (*_Assembler)._asm_OP_unmarshal_p,
     _OP_unmarshal_text   : (*_Assembler)._asm_OP_unmarshal_text,
@@ -316,6 +317,22 @@ var _OpFuncTab = [256]func(*_Assembler, *_Instr) {
     _OP_skip_emtpy         : (*_Assembler)._asm_OP_skip_empty,
     _OP_add              : (*_Assembler)._asm_OP_add,
     _OP_check_empty      : (*_Assembler)._asm_OP_check_empty,
+    _OP_optional         : (*_Assembler)._asm_OP_optional,
+    _OP_field_reset      : (*_Assembler)._asm_OP_field_reset,
+    _OP_field_seen       : (*_Assembler)._asm_OP_field_seen,
+    _OP_field_check      : (*_Assembler)._asm_OP_field_check,
+    _OP_union            : (*_Assembler)._asm_OP_union,
+    _OP_big              : (*_Assembler)._asm_OP_big,
+    _OP_time             : (*_Assembler)._asm_OP_time,
+    _OP_duration         : (*_Assembler)._asm_OP_duration,
+    _OP_nan_inf          : (*_Assembler)._asm_OP_nan_inf,
+    _OP_quoted_int       : (*_Assembler)._asm_OP_quoted_int,
+    _OP_presence_reset   : (*_Assembler)._asm_OP_presence_reset,
+    _OP_presence_seen    : (*_Assembler)._asm_OP_presence_seen,
+    _OP_presence_null    : (*_Assembler)._asm_OP_presence_null,
+    _OP_presence_unknown : (*_Assembler)._asm_OP_presence_unknown,
+    _OP_presence_end     : (*_Assembler)._asm_OP_presence_end,
+    _OP_format           : (*_Assembler)._asm_OP_format,
     _OP_debug            : (*_Assembler)._asm_OP_debug,
 }
 
@@ -999,10 +1016,32 @@ var (
     _F_decodeJsonUnmarshaler obj.Addr
     _F_decodeJsonUnmarshalerQuoted obj.Addr
     _F_decodeTextUnmarshaler obj.Addr
+    _F_decodeInlineField obj.Addr
+    _F_checkStructFields obj.Addr
+    _F_decodeUnion obj.Addr
+    _F_decodeBig obj.Addr
+    _F_decodeTime obj.Addr
+    _F_decodeDuration obj.Addr
+    _F_decodeFormat obj.Addr
+    _F_decodeNaNInf obj.Addr
+    _F_decodeQuotedInt obj.Addr
+    _F_presenceUnknown obj.Addr
+    _F_presenceEnd obj.Addr
 )
 
 func init() {
     _F_decodeJsonUnmarshaler = jit.Func(decodeJsonUnmarshaler)
+    _F_decodeInlineField = jit.Func(decodeInlineField)
+    _F_checkStructFields = jit.Func(checkStructFields)
+    _F_decodeUnion = jit.Func(decodeUnion)
+    _F_decodeBig = jit.Func(decodeBig)
+    _F_decodeTime = jit.Func(decodeTime)
+    _F_decodeDuration = jit.Func(decodeDuration)
+    _F_decodeFormat = jit.Func(decodeFormat)
+    _F_decodeNaNInf = jit.Func(decodeNaNInf)
+    _F_decodeQuotedInt = jit.Func(decodeQuotedInt)
+    _F_presenceUnknown = jit.Func(presenceUnknown)
+    _F_presenceEnd = jit.Func(presenceEnd)
     _F_decodeJsonUnmarshalerQuoted = jit.Func(decodeJsonUnmarshalerQuoted)
     _F_decodeTextUnmarshaler = jit.Func(decodeTextUnmarshaler)
 }
@@ -1488,6 +1527,10 @@ func (self *_Assembler) _asm_OP_nil_3(_ *_Instr) {
     self.Emit("MOVQ" , _AX, jit.Ptr(_VP, 16))   // MOVOU AX, 16(VP)
 }
 
+func (self *_Assembler) _asm_OP_optional(p *_Instr) {
+    self.Emit("MOVW", jit.Imm(p.i64()), jit.Ptr(_VP, 0))    // MOVW ${p.vi()}, (VP)
+}
+
 func (self *_Assembler) _asm_OP_deref(p *_Instr) {
     self.vfollow(p.vt())
 }
@@ -1807,11 +1850,222 @@ func (self *_Assembler) _asm_OP_struct_field(p *_Instr) {
     // HACK: because `_VAR_sr` maybe used in `F_vstring`, so we should clear here again for `_OP_switch`.
     self.Emit("MOVQ" , jit.Imm(-1), _AX)                        // MOVQ    $-1, AX
     self.Emit("MOVQ" , _AX, _VAR_sr)                            // MOVQ    AX, sr
-    self.Emit("BTQ"  , jit.Imm(_F_disable_unknown), _ARG_fv)    // BTQ     ${_F_disable_unknown}, fv
-    self.Sjmp("JC"   , _LB_field_error)                         // JC      _field_error
+
+    /* unknown keys are not errors if they have a catch-all field */
+    if p.vi() == 0 {
+        self.Emit("BTQ"  , jit.Imm(_F_disable_unknown), _ARG_fv)    // BTQ     ${_F_disable_unknown}, fv
+        self.Sjmp("JC"   , _LB_field_error)                         // JC      _field_error
+    }
     self.Link("_end_{n}")                                       // _end_{n}:
 }
 
+func (self *_Assembler) _asm_OP_struct_inline(p *_Instr) {
+    self.call_sf(_F_skip_one)                   // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)     // JS      _parse_error_v
+    self.slice_from_r(_AX, 0)                   // SLICE_R AX, $0
+    self.Emit("MOVQ" , jit.Type(p.vt()), _AX)   // MOVQ    ${p.vt()}, AX
+    self.Emit("MOVQ" , _VP, _BX)                // MOVQ    VP, BX
+    self.Emit("LEAQ" , _ARG_sv, _CX)            // LEAQ    sv, CX
+    self.Emit("MOVQ" , _ARG_fv, _R8)            // MOVQ    fv, R8
+    self.call_go(_F_decodeInlineField)          // CALL_GO decodeInlineField
+    self.Emit("TESTQ", _ET, _ET)                // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)               // JNZ     _error
+}
+
+// _asm_OP_field_reset pushes the seen mask of the struct under its saved pointer,
+// the mask is tagged with _SeenTag so that it never looks like a heap pointer.
+func (self *_Assembler) _asm_OP_field_reset(_ *_Instr) {
+    self.Emit("MOVQ", jit.Ptr(_ST, 0), _CX)             // MOVQ (ST), CX
+    self.Emit("CMPQ", _CX, jit.Imm(_MaxStackBytes))     // CMPQ CX, ${_MaxStackBytes}
+    self.Sjmp("JAE"  , _LB_stack_error)                  // JA   _stack_error
+    self.Emit("MOVQ", jit.Imm(_SeenTag), _AX)           // MOVQ ${_SeenTag}, AX
+    self.Emit("MOVQ", _AX, jit.Sib(_ST, _CX, 1, 8))     // MOVQ AX, 8(ST)(CX)
+    self.Emit("ADDQ", jit.Imm(8), _CX)                  // ADDQ $8, CX
+    self.Emit("MOVQ", _CX, jit.Ptr(_ST, 0))             // MOVQ CX, (ST)
+}
+
+func (self *_Assembler) _asm_OP_field_seen(p *_Instr) {
+    self.Emit("MOVQ", jit.Ptr(_ST, 0), _AX)             // MOVQ (ST), AX
+    self.Emit("MOVQ", jit.Imm(1 << uint(p.vi())), _CX)  // MOVQ $(1 << ${p.vi()}), CX
+    self.Emit("ORQ" , _CX, jit.Sib(_ST, _AX, 1, -8))    // ORQ  CX, -8(ST)(AX)
+}
+
+// _asm_OP_field_check pops the seen mask, which is on the top after the struct is dropped.
+func (self *_Assembler) _asm_OP_field_check(p *_Instr) {
+    self.Emit("MOVQ" , jit.Ptr(_ST, 0), _DX)            // MOVQ    (ST), DX
+    self.Emit("MOVQ" , jit.Sib(_ST, _DX, 1, 0), _R8)    // MOVQ    (ST)(DX), R8
+    self.Emit("MOVQ" , jit.Imm(0), jit.Sib(_ST, _DX, 1, 0)) // MOVQ $0, (ST)(DX)
+    self.Emit("SUBQ" , jit.Imm(8), _DX)                 // SUBQ    $8, DX
+    self.Emit("MOVQ" , _DX, jit.Ptr(_ST, 0))            // MOVQ    DX, (ST)
+    self.Emit("MOVQ" , _ARG_sp, _AX)                    // MOVQ    sp, AX
+    self.Emit("MOVQ" , _ARG_sl, _BX)                    // MOVQ    sl, BX
+    self.Emit("MOVQ" , _IC, _CX)                        // MOVQ    IC, CX
+    self.Emit("MOVQ" , jit.Type(p.vt()), _DI)           // MOVQ    ${p.vt()}, DI
+    self.Emit("MOVQ" , _VP, _SI)                        // MOVQ    VP, SI
+    self.Emit("MOVQ" , _ARG_fv, _R9)                    // MOVQ    fv, R9
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , _F_checkStructFields, _IL)       // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                             // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                        // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                       // JNZ     _error
+}
+
+// _asm_OP_presence_reset pushes p.vi() empty masks of the seen and null fields for DecodePresence.
+func (self *_Assembler) _asm_OP_presence_reset(p *_Instr) {
+    n := int64(p.vi())
+    self.Emit("MOVQ", jit.Ptr(_ST, 0), _CX)                         // MOVQ (ST), CX
+    self.Emit("CMPQ", _CX, jit.Imm(_MaxStackBytes - (n - 1) * 8))   // CMPQ CX, ${_MaxStackBytes - (n - 1) * 8}
+    self.Sjmp("JAE" , _LB_stack_error)                              // JA   _stack_error
+    self.Emit("MOVQ", jit.Imm(_SeenTag), _AX)                       // MOVQ ${_SeenTag}, AX
+    for i := int64(1); i <= n; i++ {
+        self.Emit("MOVQ", _AX, jit.Sib(_ST, _CX, 1, i * 8))         // MOVQ AX, ${i * 8}(ST)(CX)
+    }
+    self.Emit("ADDQ", jit.Imm(n * 8), _CX)                          // ADDQ ${n * 8}, CX
+    self.Emit("MOVQ", _CX, jit.Ptr(_ST, 0))                         // MOVQ CX, (ST)
+}
+
+func (self *_Assembler) _asm_OP_presence_seen(p *_Instr) {
+    self.Emit("MOVQ", jit.Ptr(_ST, 0), _AX)                                 // MOVQ (ST), AX
+    self.Emit("MOVQ", jit.Imm(1 << uint(p.vi() & 63)), _CX)                 // MOVQ $(1 << ${p.vi() & 63}), CX
+    self.Emit("ORQ" , _CX, jit.Sib(_ST, _AX, 1, int64(p.vi() >> 6) * -8))   // ORQ  CX, ${-8 * (p.vi() >> 6)}(ST)(AX)
+}
+
+func (self *_Assembler) _asm_OP_presence_null(p *_Instr) {
+    self.Emit("CMPB", jit.Sib(_IP, _IC, 1, 0), jit.Imm('n'))                // CMPB (IP)(IC), $'n'
+    self.Sjmp("JNE" , "_not_null_{n}")                                      // JNE  _not_null_{n}
+    self._asm_OP_presence_seen(p)
+    self.Link("_not_null_{n}")                                              // _not_null_{n}:
+}
+
+func (self *_Assembler) _asm_OP_presence_unknown(_ *_Instr) {
+    self.Emit("MOVQ", _ST, _AX)                 // MOVQ    ST, AX
+    self.Emit("LEAQ", _ARG_sv, _BX)             // LEAQ    sv, BX
+    self.Emit("MOVQ", _IC, _CX)                 // MOVQ    IC, CX
+    self.call_go(_F_presenceUnknown)            // CALL_GO presenceUnknown
+}
+
+func (self *_Assembler) _asm_OP_presence_end(p *_Instr) {
+    self.Emit("MOVQ", _ST, _AX)                         // MOVQ    ST, AX
+    self.Emit("MOVQ", jit.Type(p.vt()), _BX)            // MOVQ    ${p.vt()}, BX
+    self.Emit("MOVQ", _IC, _CX)                         // MOVQ    IC, CX
+    self.Emit("MOVQ", jit.Imm(int64(p.vi())), _DI)      // MOVQ    ${p.vi()}, DI
+    self.call_go(_F_presenceEnd)                        // CALL_GO presenceEnd
+}
+
+func (self *_Assembler) _asm_OP_union(p *_Instr) {
+    self.call_sf(_F_skip_one)                           // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                        // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)             // JS      _parse_error_v
+    self.Emit("MOVQ" , _AX, _SI)                        // MOVQ    AX, SI
+    self.Emit("MOVQ" , jit.Type(p.vt()), _AX)           // MOVQ    ${p.vt()}, AX
+    self.Emit("MOVQ" , _VP, _BX)                        // MOVQ    VP, BX
+    self.Emit("MOVQ" , _ARG_sp, _CX)                    // MOVQ    sp, CX
+    self.Emit("MOVQ" , _ARG_sl, _DI)                    // MOVQ    sl, DI
+    self.Emit("MOVQ" , _ARG_fv, _R8)                    // MOVQ    fv, R8
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , _F_decodeUnion, _IL)             // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                             // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                        // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                       // JNZ     _error
+}
+
+func (self *_Assembler) _asm_OP_big(p *_Instr) {
+    self.call_sf(_F_skip_one)                           // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                        // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)             // JS      _parse_error_v
+    self.Emit("MOVQ" , _AX, _SI)                        // MOVQ    AX, SI
+    self.Emit("MOVQ" , _IC, _R8)                        // MOVQ    IC, R8
+    self.Emit("MOVQ" , jit.Type(p.vt()), _AX)           // MOVQ    ${p.vt()}, AX
+    self.Emit("MOVQ" , _VP, _BX)                        // MOVQ    VP, BX
+    self.Emit("MOVQ" , _ARG_sp, _CX)                    // MOVQ    sp, CX
+    self.Emit("MOVQ" , _ARG_sl, _DI)                    // MOVQ    sl, DI
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , _F_decodeBig, _IL)               // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                             // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                        // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                       // JNZ     _error
+}
+
+func (self *_Assembler) _asm_OP_time(_ *_Instr) {
+    self.call_sf(_F_skip_one)                           // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                        // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)             // JS      _parse_error_v
+    self.Emit("MOVQ" , _AX, _DI)                        // MOVQ    AX, DI
+    self.Emit("MOVQ" , _IC, _SI)                        // MOVQ    IC, SI
+    self.Emit("MOVQ" , _VP, _AX)                        // MOVQ    VP, AX
+    self.Emit("MOVQ" , _ARG_sp, _BX)                    // MOVQ    sp, BX
+    self.Emit("MOVQ" , _ARG_sl, _CX)                    // MOVQ    sl, CX
+    self.Emit("MOVQ" , _ARG_fv, _R8)                    // MOVQ    fv, R8
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , _F_decodeTime, _IL)              // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                             // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                        // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                       // JNZ     _error
+}
+
+func (self *_Assembler) _asm_OP_format(p *_Instr) {
+    self.call_sf(_F_skip_one)                           // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                        // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)             // JS      _parse_error_v
+    self.Emit("MOVQ" , _AX, _SI)                        // MOVQ    AX, SI
+    self.Emit("MOVQ" , _IC, _R8)                        // MOVQ    IC, R8
+    self.Emit("MOVQ" , jit.Type(p.vt()), _AX)           // MOVQ    ${p.vt()}, AX
+    self.Emit("MOVQ" , _VP, _BX)                        // MOVQ    VP, BX
+    self.Emit("MOVQ" , _ARG_sp, _CX)                    // MOVQ    sp, CX
+    self.Emit("MOVQ" , _ARG_sl, _DI)                    // MOVQ    sl, DI
+    self.Emit("MOVQ" , jit.Imm(p.i64()), _R9)           // MOVQ    ${p.vi()}, R9
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , _F_decodeFormat, _IL)            // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                             // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                        // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                       // JNZ     _error
+}
+
+func (self *_Assembler) _asm_OP_duration(p *_Instr) {
+    self.decode_quoted(p, _F_duration_string, _F_decodeDuration)
+}
+
+func (self *_Assembler) _asm_OP_nan_inf(p *_Instr) {
+    self.decode_quoted(p, _F_nan_inf_string, _F_decodeNaNInf)
+}
+
+func (self *_Assembler) _asm_OP_quoted_int(p *_Instr) {
+    self.decode_quoted(p, _F_quoted_int, _F_decodeQuotedInt)
+}
+
+// decode_quoted decodes a string by fn(vt, vp, s, i, e) and jumps to p.vi() if the flag
+// bit is set, otherwise the value is decoded by the following instructions.
+func (self *_Assembler) decode_quoted(p *_Instr, bit int, fn obj.Addr) {
+    self.Emit("BTQ"  , jit.Imm(int64(bit)), _ARG_fv)                       // BTQ     ${bit}, fv
+    self.Sjmp("JNC"  , "_not_quoted_{n}")                                   // JNC     _not_quoted_{n}
+    self.check_eof(1)
+    self.Emit("CMPB" , jit.Sib(_IP, _IC, 1, 0), jit.Imm('"'))              // CMPB    (IP)(IC), $'"'
+    self.Sjmp("JNE"  , "_not_quoted_{n}")                                   // JNE     _not_quoted_{n}
+    self.call_sf(_F_skip_one)                                               // CALL_SF skip_one
+    self.Emit("TESTQ", _AX, _AX)                                            // TESTQ   AX, AX
+    self.Sjmp("JS"   , _LB_parsing_error_v)                                 // JS      _parse_error_v
+    self.Emit("MOVQ" , _AX, _SI)                                            // MOVQ    AX, SI
+    self.Emit("MOVQ" , _IC, _R8)                                            // MOVQ    IC, R8
+    self.Emit("MOVQ" , jit.Type(p.vt()), _AX)                               // MOVQ    ${p.vt()}, AX
+    self.Emit("MOVQ" , _VP, _BX)                                            // MOVQ    VP, BX
+    self.Emit("MOVQ" , _ARG_sp, _CX)                                        // MOVQ    sp, CX
+    self.Emit("MOVQ" , _ARG_sl, _DI)                                        // MOVQ    sl, DI
+    self.save(_REG_rt...)
+    self.Emit("MOVQ" , fn, _IL)                                             // MOVQ    ${fn}, R11
+    self.Rjmp("CALL" , _IL)                                                 // CALL    R11
+    self.load(_REG_rt...)
+    self.Emit("TESTQ", _ET, _ET)                                            // TESTQ   ET, ET
+    self.Sjmp("JNZ"  , _LB_error)                                           // JNZ     _error
+    self.Xjmp("JMP"  , p.vi())                                              // JMP     {p.vi()}
+    self.Link("_not_quoted_{n}")                                            // _not_quoted_{n}:
+}
+
 func (self *_Assembler) _asm_OP_unmarshal(p *_Instr) {
     if iv := p.i64(); iv != 0 {
         self.unmarshal_json(p.vt(), true, _F_decodeJsonUnmarshalerQuoted)
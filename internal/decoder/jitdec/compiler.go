@@ -79,6 +79,7 @@ const (
     _OP_slice_append
     _OP_object_next
     _OP_struct_field
+    _OP_struct_inline
     _OP_unmarshal
     _OP_unmarshal_p
     _OP_unmarshal_text
@@ -99,6 +100,22 @@ const (
     _OP_skip_emtpy
     _OP_add
     _OP_check_empty
+    _OP_optional
+    _OP_field_reset
+    _OP_field_seen
+    _OP_field_check
+    _OP_union
+    _OP_big
+    _OP_time
+    _OP_duration
+    _OP_format
+    _OP_nan_inf
+    _OP_quoted_int
+    _OP_presence_reset
+    _OP_presence_seen
+    _OP_presence_null
+    _OP_presence_unknown
+    _OP_presence_end
     _OP_debug
 )
 
@@ -157,6 +174,7 @@ var _OpNames = [256]string {
     _OP_slice_append     : "slice_append",
     _OP_object_next      : "object_next",
     _OP_struct_field     : "struct_field",
+    _OP_struct_inline    : "struct_inline",
     _OP_unmarshal        : "unmarshal",
     _OP_unmarshal_p      : "unmarshal_p",
     _OP_unmarshal_text   : "unmarshal_text",
@@ -176,6 +194,22 @@ var _OpNames = [256]string {
     _OP_add              : "add",
     _OP_go_skip          : "go_skip",
     _OP_check_empty      : "check_empty",
+    _OP_optional         : "optional",
+    _OP_field_reset      : "field_reset",
+    _OP_field_seen       : "field_seen",
+    _OP_field_check      : "field_check",
+    _OP_union            : "union",
+    _OP_big              : "big",
+    _OP_time             : "time",
+    _OP_duration         : "duration",
+    _OP_format           : "format",
+    _OP_nan_inf          : "nan_inf",
+    _OP_quoted_int       : "quoted_int",
+    _OP_presence_reset   : "presence_reset",
+    _OP_presence_seen    : "presence_seen",
+    _OP_presence_null    : "presence_null",
+    _OP_presence_unknown : "presence_unknown",
+    _OP_presence_end     : "presence_end",
     _OP_debug            : "debug",
 }
 
@@ -284,6 +318,13 @@ func newInsVf(op _Op, vf *caching.FieldMap) _Instr {
     }
 }
 
+func newInsVfI(op _Op, vf *caching.FieldMap, iv int) _Instr {
+    return _Instr {
+        u: packOp(op) | rt.PackInt(iv),
+        p: unsafe.Pointer(vf),
+    }
+}
+
 func (self _Instr) op() _Op {
     return _Op(self.u >> 56)
 }
@@ -329,6 +370,9 @@ func (self _Instr) isBranch() bool {
         case _OP_switch        : fallthrough
         case _OP_is_null       : fallthrough
         case _OP_is_null_quote : fallthrough
+        case _OP_duration      : fallthrough
+        case _OP_nan_inf       : fallthrough
+        case _OP_quoted_int    : fallthrough
         case _OP_check_char    : return true
         default                : return false
     }
@@ -353,13 +397,20 @@ func (self _Instr) disassemble() string {
         case _OP_map_key_utext_p  : fallthrough
         case _OP_slice_init       : fallthrough
         case _OP_slice_append     : fallthrough
+        case _OP_struct_inline    : fallthrough
         case _OP_unmarshal        : fallthrough
         case _OP_unmarshal_p      : fallthrough
         case _OP_unmarshal_text   : fallthrough
         case _OP_unmarshal_text_p : fallthrough
+        case _OP_union            : fallthrough
+        case _OP_big              : fallthrough
+        case _OP_format           : fallthrough
         case _OP_recurse          : return fmt.Sprintf("%-18s%s", self.op(), self.vt())
         case _OP_goto             : fallthrough
         case _OP_is_null_quote    : fallthrough
+        case _OP_duration         : fallthrough
+        case _OP_nan_inf          : fallthrough
+        case _OP_quoted_int       : fallthrough
         case _OP_is_null          : return fmt.Sprintf("%-18sL_%d", self.op(), self.vi())
         case _OP_index            : fallthrough
         case _OP_array_clear      : fallthrough
@@ -466,6 +517,10 @@ func (self *_Program) fmv(op _Op, vf *caching.FieldMap) {
     *self = append(*self, newInsVf(op, vf))
 }
 
+func (self *_Program) fmvi(op _Op, vf *caching.FieldMap, iv int) {
+    *self = append(*self, newInsVfI(op, vf, iv))
+}
+
 func (self _Program) disassemble() string {
     nb  := len(self)
     tab := make([]bool, nb + 1)
@@ -506,6 +561,9 @@ type _Compiler struct {
     opts option.CompileOptions
     tab  map[reflect.Type]bool
     rec  map[reflect.Type]bool
+
+    // presence tracks the fields of structs for DecodePresence
+    presence bool
 }
 
 func newCompiler() *_Compiler {
@@ -544,6 +602,14 @@ const (
 func (self *_Compiler) checkMarshaler(p *_Program, vt reflect.Type, flags int, exec bool) bool {
     pt := reflect.PtrTo(vt)
 
+    /* optional values, big numbers and times are decoded natively */
+    if resolver.IsOptional(vt) || resolver.IsBig(vt) || resolver.IsTime(vt) {
+        return false
+    }
+    if vt.Kind() == reflect.Ptr && (resolver.IsBig(vt.Elem()) || resolver.IsTime(vt.Elem())) {
+        return false
+    }
+
     /* check for `json.Unmarshaler` with pointer receiver */
     if pt.Implements(jsonUnmarshalerType) {
         if exec {
@@ -610,19 +676,19 @@ func (self *_Compiler) compileOne(p *_Program, sp int, vt reflect.Type) {
 func (self *_Compiler) compileOps(p *_Program, sp int, vt reflect.Type) {
     switch vt.Kind() {
         case reflect.Bool      : self.compilePrimitive (vt, p, _OP_bool)
-        case reflect.Int       : self.compilePrimitive (vt, p, _OP_int())
-        case reflect.Int8      : self.compilePrimitive (vt, p, _OP_i8)
-        case reflect.Int16     : self.compilePrimitive (vt, p, _OP_i16)
-        case reflect.Int32     : self.compilePrimitive (vt, p, _OP_i32)
-        case reflect.Int64     : self.compilePrimitive (vt, p, _OP_i64)
-        case reflect.Uint      : self.compilePrimitive (vt, p, _OP_uint())
-        case reflect.Uint8     : self.compilePrimitive (vt, p, _OP_u8)
-        case reflect.Uint16    : self.compilePrimitive (vt, p, _OP_u16)
-        case reflect.Uint32    : self.compilePrimitive (vt, p, _OP_u32)
-        case reflect.Uint64    : self.compilePrimitive (vt, p, _OP_u64)
-        case reflect.Uintptr   : self.compilePrimitive (vt, p, _OP_uintptr())
-        case reflect.Float32   : self.compilePrimitive (vt, p, _OP_f32)
-        case reflect.Float64   : self.compilePrimitive (vt, p, _OP_f64)
+        case reflect.Int       : self.compileInt       (vt, p, _OP_int())
+        case reflect.Int8      : self.compileInt       (vt, p, _OP_i8)
+        case reflect.Int16     : self.compileInt       (vt, p, _OP_i16)
+        case reflect.Int32     : self.compileInt       (vt, p, _OP_i32)
+        case reflect.Int64     : self.compileInt64     (vt, p)
+        case reflect.Uint      : self.compileInt       (vt, p, _OP_uint())
+        case reflect.Uint8     : self.compileInt       (vt, p, _OP_u8)
+        case reflect.Uint16    : self.compileInt       (vt, p, _OP_u16)
+        case reflect.Uint32    : self.compileInt       (vt, p, _OP_u32)
+        case reflect.Uint64    : self.compileInt       (vt, p, _OP_u64)
+        case reflect.Uintptr   : self.compileInt       (vt, p, _OP_uintptr())
+        case reflect.Float32   : self.compileFloat     (vt, p, _OP_f32)
+        case reflect.Float64   : self.compileFloat     (vt, p, _OP_f64)
         case reflect.String    : self.compileString    (p, vt)
         case reflect.Array     : self.compileArray     (p, sp, vt)
         case reflect.Interface : self.compileInterface (p, vt)
@@ -634,6 +700,23 @@ func (self *_Compiler) compileOps(p *_Program, sp int, vt reflect.Type) {
     }
 }
 
+func (self *_Compiler) compileOptional(p *_Program, sp int, vt reflect.Type) {
+    et, off := resolver.OptionalValue(vt)
+    i := p.pc()
+    p.add(_OP_is_null)
+    p.int(_OP_optional, resolver.OptionalSet)
+    p.tag(sp + 1)
+    p.add(_OP_save)
+    p.int(_OP_index, int(off))
+    self.compileOne(p, sp + 1, et)
+    p.add(_OP_drop)
+    j := p.pc()
+    p.add(_OP_goto)
+    p.pin(i)
+    p.int(_OP_optional, resolver.OptionalNull)
+    p.pin(j)
+}
+
 func (self *_Compiler) compileMap(p *_Program, sp int, vt reflect.Type) {
     if reflect.PtrTo(vt.Key()).Implements(encodingTextUnmarshalerType) {
         self.compileMapOp(p, sp, vt, _OP_map_key_utext_p)
@@ -881,7 +964,52 @@ func (self *_Compiler) compileStringBody(vt reflect.Type, p *_Program) {
     p.pin(skip)
 }
 
+func (self *_Compiler) compileInt(vt reflect.Type, p *_Program, op _Op) {
+    i := p.pc()
+    p.rtt(_OP_quoted_int, vt)
+    self.compilePrimitive(vt, p, op)
+    p.pin(i)
+}
+
+func (self *_Compiler) compileInt64(vt reflect.Type, p *_Program) {
+    if !resolver.IsDuration(vt) {
+        self.compileInt(vt, p, _OP_i64)
+        return
+    }
+
+    /* durations are also decoded from strings with OptionDurationString */
+    i := p.pc()
+    p.rtt(_OP_duration, vt)
+    self.compilePrimitive(vt, p, _OP_i64)
+    p.pin(i)
+}
+
+func (self *_Compiler) compileFloat(vt reflect.Type, p *_Program, op _Op) {
+    i := p.pc()
+    p.rtt(_OP_nan_inf, vt)
+    self.compilePrimitive(vt, p, op)
+    p.pin(i)
+}
+
 func (self *_Compiler) compileStruct(p *_Program, sp int, vt reflect.Type) {
+    if resolver.IsOptional(vt) {
+        self.compileOptional(p, sp, vt)
+        return
+    }
+    if resolver.IsBig(vt) {
+        i := p.pc()
+        p.add(_OP_is_null)
+        p.rtt(_OP_big, vt)
+        p.pin(i)
+        return
+    }
+    if resolver.IsTime(vt) {
+        i := p.pc()
+        p.add(_OP_is_null)
+        p.add(_OP_time)
+        p.pin(i)
+        return
+    }
     if sp >= self.opts.MaxInlineDepth || p.pc() >= _MAX_ILBUF || (sp > 0 && vt.NumField() >= _MAX_FIELDS) {
         p.rtt(_OP_recurse, vt)
         if self.opts.RecursiveDepth > 0 {
@@ -893,8 +1021,32 @@ func (self *_Compiler) compileStruct(p *_Program, sp int, vt reflect.Type) {
 }
 
 func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
+    if resolver.IsArrayStruct(vt) {
+        self.compileStructArray(p, sp, vt)
+        return
+    }
+
     fv := resolver.ResolveStruct(vt)
     fm, sw := caching.CreateFieldMap(len(fv)), make([]int, len(fv))
+    ix, err := resolver.InlineField(vt, fv)
+    if err != nil {
+        panic(err)
+    }
+    nx := make([]int, 0, 2)
+
+    /* the catch-all field must be able to hold any unknown key */
+    if ix >= 0 {
+        checkInlineField(vt, &fv[ix])
+    }
+
+    /* the required and defaulted fields are tracked when seen */
+    ck := checkedFields(vt)
+
+    /* so are all the fields for DecodePresence, in words of seen and null masks */
+    pw := 0
+    if self.presence {
+        pw = (len(fv) + _MaxSeen - 1) / _MaxSeen
+    }
 
     /* start of object */
     p.tag(sp)
@@ -905,8 +1057,8 @@ func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
     p.chr(_OP_check_char_0, '{')
     p.rtt(_OP_dismatch_err, vt)
 
-    /* special case for empty object */
-    if len(fv) == 0 {
+    /* special case for empty object, whose keys are all unknown */
+    if len(fv) == 0 && !self.presence {
         p.pin(j)
         s := p.pc()
         p.add(_OP_skip_emtpy)
@@ -920,16 +1072,22 @@ func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
     p.pin(j)
     p.int(_OP_add, 1)
     
+    if pw != 0 {
+        p.int(_OP_presence_reset, pw * 2)
+    }
+    if len(ck) != 0 {
+        p.add(_OP_field_reset)
+    }
     p.add(_OP_save)
     p.add(_OP_lspace)
     x := p.pc()
     p.chr(_OP_check_char, '}')
     p.chr(_OP_match_char, '"')
-    p.fmv(_OP_struct_field, fm)
+    self.compileStructFieldKey(p, fm, ix)
     p.add(_OP_lspace)
     p.chr(_OP_match_char, ':')
     p.tab(_OP_switch, sw)
-    p.add(_OP_object_next)
+    nx = self.compileStructFieldUnknown(p, nx, ix)
     y0 := p.pc()
     p.add(_OP_lspace)
     y1 := p.pc()
@@ -940,17 +1098,42 @@ func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
     /* match the remaining fields */
     p.add(_OP_lspace)
     p.chr(_OP_match_char, '"')
-    p.fmv(_OP_struct_field, fm)
+    self.compileStructFieldKey(p, fm, ix)
     p.add(_OP_lspace)
     p.chr(_OP_match_char, ':')
     p.tab(_OP_switch, sw)
-    p.add(_OP_object_next)
+    nx = self.compileStructFieldUnknown(p, nx, ix)
     p.int(_OP_goto, y0)
 
     /* process each field */
     for i, f := range fv {
         sw[i] = p.pc()
-        fm.Set(f.Name, i)
+
+        /* the catch-all field is never matched by name */
+        if i == ix {
+            p.rel(nx)
+            if self.presence {
+                p.add(_OP_presence_unknown)
+            }
+        } else {
+            fm.Set(f.Name, i)
+        }
+
+        /* mark the field as seen */
+        if b, ok := ck[i]; ok {
+            p.int(_OP_field_seen, b)
+        }
+
+        /* and record its presence, the masks are under the checked one */
+        if pw != 0 && i != ix {
+            d, w, b := 1, i / _MaxSeen, i % _MaxSeen
+            if len(ck) != 0 {
+                d++
+            }
+            p.int(_OP_presence_seen, presenceSlot(d + pw * 2 - 1 - w, b))
+            p.add(_OP_lspace)
+            p.int(_OP_presence_null, presenceSlot(d + pw - 1 - w, b))
+        }
 
         /* index to the field */
         for _, o := range f.Path {
@@ -959,11 +1142,11 @@ func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
             }
         }
 
-        /* check for "stringnize" option */
-        if (f.Opts & resolver.F_stringize) == 0 {
-            self.compileOne(p, sp + 1, f.Type)
+        /* check for "inline" and "stringnize" option */
+        if i == ix {
+            p.rtt(_OP_struct_inline, f.Type)
         } else {
-            self.compileStructFieldStr(p, sp + 1, f.Type)
+            self.compileStructFieldValue(p, sp + 1, &f)
         }
 
         /* load the state, and try next field */
@@ -974,10 +1157,143 @@ func (self *_Compiler) compileStructBody(p *_Program, sp int, vt reflect.Type) {
     p.pin(x)
     p.pin(y1)
     p.add(_OP_drop)
+    if len(ck) != 0 {
+        p.rtt(_OP_field_check, vt)
+    }
+    if pw != 0 {
+        p.rtti(_OP_presence_end, vt, pw)
+    }
     p.pin(n)
     p.pin(skip)
 }
 
+// presenceSlot packs the depth of a presence mask below the top of the stack
+// and the bit of a field in it into the operand of _OP_presence_seen and _OP_presence_null.
+func presenceSlot(depth int, bit int) int {
+    return depth << 6 | bit
+}
+
+func (self *_Compiler) compileStructArray(p *_Program, sp int, vt reflect.Type) {
+    x := p.pc()
+    p.add(_OP_is_null)
+    p.tag(sp)
+    skip := self.checkIfSkip(p, vt, '[')
+
+    p.add(_OP_save)
+    p.add(_OP_lspace)
+    v := []int{p.pc()}
+    p.chr(_OP_check_char, ']')
+
+    /* decode the fields by their positions */
+    for _, f := range resolver.ResolveStruct(vt) {
+        for _, o := range f.Path {
+            if p.int(_OP_index, int(o.Size)); o.Kind == resolver.F_deref {
+                p.rtt(_OP_deref, o.Type)
+            }
+        }
+
+        self.compileStructFieldValue(p, sp + 1, &f)
+        p.add(_OP_load)
+        p.add(_OP_lspace)
+        v = append(v, p.pc())
+        p.chr(_OP_check_char, ']')
+        p.chr(_OP_match_char, ',')
+    }
+
+    /* drop the extra items, and keep the fields without items */
+    p.add(_OP_array_skip)
+    p.rel(v)
+    p.add(_OP_drop)
+
+    p.pin(skip)
+    p.pin(x)
+}
+
+// checkedFields maps the index of each required or defaulted field to its bit in the seen mask.
+func checkedFields(vt reflect.Type) map[int]int {
+    ck, err := resolver.CheckedFields(vt)
+    if err != nil {
+        panic(err)
+    }
+    if len(ck) > _MaxSeen {
+        panic(fmt.Errorf("json: too many required or defaulted fields in %s", vt))
+    }
+    ret := make(map[int]int, len(ck))
+    for b, i := range ck {
+        ret[i] = b
+    }
+    return ret
+}
+
+func (self *_Compiler) compileStructFieldKey(p *_Program, fm *caching.FieldMap, ix int) {
+    if ix < 0 {
+        p.fmv(_OP_struct_field, fm)
+    } else {
+        p.fmvi(_OP_struct_field, fm, 1)
+    }
+}
+
+func (self *_Compiler) compileStructFieldUnknown(p *_Program, nx []int, ix int) []int {
+    if ix < 0 && self.presence {
+        p.add(_OP_presence_unknown)
+    }
+    if ix < 0 {
+        p.add(_OP_object_next)
+        return nx
+    }
+    p.add(_OP_goto)
+    return append(nx, p.pc()-1)
+}
+
+func checkInlineField(vt reflect.Type, f *resolver.FieldMeta) {
+    if f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String {
+        return
+    }
+    if resolver.FindInlineSetter(f.Type) == nil {
+        panic(resolver.InlineTypeError(vt, f))
+    }
+}
+
+func (self *_Compiler) compileStructFieldValue(p *_Program, sp int, f *resolver.FieldMeta) {
+    /* check for "format:" option, floats are decoded as usual in any format */
+    if f.Format != "" {
+        id, err := resolver.ResolveFormat(f.Type, f.Format)
+        if err != nil {
+            panic(err)
+        }
+        if id != 0 && resolver.FormatOf(id).Kind != resolver.FormatPrintf {
+            self.compileStructFieldFormat(p, f.Type, id)
+            return
+        }
+    }
+
+    /* check for "stringnize" option */
+    if (f.Opts & resolver.F_stringize) == 0 {
+        self.compileOne(p, sp, f.Type)
+    } else {
+        self.compileStructFieldStr(p, sp, f.Type)
+    }
+}
+
+func (self *_Compiler) compileStructFieldFormat(p *_Program, vt reflect.Type, id int) {
+    i := p.pc()
+    p.add(_OP_is_null)
+
+    /* null keeps the value, and clears the pointer */
+    if vt.Kind() != reflect.Ptr {
+        p.rtti(_OP_format, vt, id)
+        p.pin(i)
+        return
+    }
+    p.rtt(_OP_deref, vt.Elem())
+    p.rtti(_OP_format, vt.Elem(), id)
+    j := p.pc()
+    p.add(_OP_goto)
+    p.pin(i)
+    p.add(_OP_nil_1)
+    p.pin(j)
+}
+
 func (self *_Compiler) compileStructFieldStrUnmarshal(p *_Program, vt reflect.Type) {
     p.add(_OP_lspace)
     n0 := p.pc()
@@ -1120,8 +1436,10 @@ func (self *_Compiler) compileInterface(p *_Program, vt reflect.Type) {
     i := p.pc()
     p.add(_OP_is_null)
 
-    /* check for empty interface */
-    if vt.NumMethod() == 0 {
+    /* check for registered unions and empty interface */
+    if resolver.FindUnion(vt) != nil {
+        p.rtt(_OP_union, vt)
+    } else if vt.NumMethod() == 0 {
         p.add(_OP_any)
     } else {
         p.rtt(_OP_dyn, vt)
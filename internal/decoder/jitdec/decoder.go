@@ -28,6 +28,11 @@ const (
 	_F_no_validate_json = consts.F_no_validate_json
 	_F_validate_string = consts.F_validate_string
     _F_case_sensitive = consts.F_case_sensitive
+    _F_duration_string = consts.F_duration_string
+    _F_nan_inf_string = consts.F_nan_inf_string
+    _F_quoted_int = consts.F_quoted_int
+    _F_use_big_int = consts.F_use_big_int
+    _F_presence = consts.F_presence
 )
 
 var (
@@ -43,6 +48,21 @@ var (
 // Decode parses the JSON-encoded data from current position and stores the result
 // in the value pointed to by val.
 func Decode(s *string, i *int, f uint64, val interface{}) error {
+    return decode(s, i, f, val, nil)
+}
+
+// DecodePresence decodes like Decode, and reports the state of each struct field
+// and unknown key in the input by its Go path. The fields are tracked by the
+// programs compiled for it, and the paths are built after decoding.
+func DecodePresence(s *string, i *int, f uint64, val interface{}) (map[string]consts.FieldState, error) {
+    pr, pos := new(presence), *i
+    if err := decode(s, i, f | 1 << _F_presence, val, pr); err != nil {
+        return nil, err
+    }
+    return pr.states(*s, pos, rt.PtrElem(rt.UnpackEface(val).Type).Pack()), nil
+}
+
+func decode(s *string, i *int, f uint64, val interface{}, pr *presence) error {
     /* validate json if needed */
     if (f & (1 << _F_validate_string)) != 0  && !utf8.ValidateString(*s){
         dbuf := utf8.CorrectWith(nil, rt.Str2Mem(*s), "\ufffd")
@@ -73,6 +93,7 @@ func Decode(s *string, i *int, f uint64, val interface{}) error {
 
     /* create a new stack, and call the decoder */
     sb := newStack()
+    sb.pr = pr
     nb, err := decodeTypedPointer(*s, *i, etp, vp, sb, f)
     /* return the stack back */
     *i = nb
@@ -80,7 +101,7 @@ func Decode(s *string, i *int, f uint64, val interface{}) error {
 
     /* avoid GC ahead */
     runtime.KeepAlive(vv)
-    return err
+    return errors.WithRootType(err, rt.PtrElem(vv.Type).Pack())
 }
 
 
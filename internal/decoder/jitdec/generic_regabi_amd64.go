@@ -21,6 +21,7 @@ package jitdec
 import (
     `encoding/json`
     `fmt`
+    `math/big`
     `reflect`
 
     `github.com/bytedance/sonic/internal/jit`
@@ -177,6 +178,7 @@ var (
     _F_convTslice    = jit.Func(rt.ConvTslice)
     _F_convTstring   = jit.Func(rt.ConvTstring)
     _F_invalid_vtype = jit.Func(invalid_vtype)
+    _F_bigInteger    = jit.Func(bigInteger)
 )
 
 var (
@@ -188,6 +190,7 @@ var (
     _T_string  = jit.Type(reflect.TypeOf(""))
     _T_number  = jit.Type(reflect.TypeOf(json.Number("")))
     _T_float64 = jit.Type(reflect.TypeOf(float64(0)))
+    _T_big_int = jit.Type(reflect.TypeOf((*big.Int)(nil)))
 )
 
 var _R_tab = map[int]string {
@@ -485,6 +488,9 @@ func (self *_ValueDecoder) compile() {
 
     /** V_DOUBLE **/
     self.Link("_decode_V_DOUBLE")                           // _decode_V_DOUBLE:
+    self.Emit("BTQ"  , jit.Imm(_F_use_big_int), _VAR_df)    // BTQ     _F_use_big_int, df
+    self.Sjmp("JC"   , "_use_big_int")                      // JC      _use_big_int
+    self.Link("_use_double")                                // _use_double:
     self.Emit("BTQ"  , jit.Imm(_F_use_number), _VAR_df)     // BTQ     _F_use_number, df
     self.Sjmp("JC"   , "_use_number")                       // JC      _use_number
     self.Emit("MOVSD", _VAR_ss_Dv, _X0)                     // MOVSD   ss.Dv, X0
@@ -522,6 +528,22 @@ func (self *_ValueDecoder) compile() {
     self.Emit("MOVQ", _VAR_ss_Ep, _DI)                  // MOVQ    ss.Ep, DI
     self.Sjmp("JMP" , "_set_value")                     // JMP     _set_value
 
+    /* represent integers overflowing `int64` as `*big.Int` */
+    self.Link("_use_big_int")                           // _use_big_int:
+    self.Emit("MOVQ" , _VAR_ss_Ep, _AX)                 // MOVQ    ss.Ep, AX
+    self.Emit("LEAQ" , jit.Sib(_IP, _AX, 1, 0), _SI)    // LEAQ    (IP)(AX), SI
+    self.Emit("MOVQ" , _IC, _CX)                        // MOVQ    IC, CX
+    self.Emit("SUBQ" , _AX, _CX)                        // SUBQ    AX, CX
+    self.Emit("MOVQ" , _SI, _AX)                        // MOVQ    SI, AX
+    self.Emit("MOVQ" , _CX, _BX)                        // MOVQ    CX, BX
+    self.call_go(_F_bigInteger)                         // CALL_GO bigInteger
+    self.Emit("TESTQ", _AX, _AX)                        // TESTQ   AX, AX
+    self.Sjmp("JZ"   , "_use_double")                   // JZ      _use_double
+    self.Emit("MOVQ" , _T_big_int, _R8)                 // MOVQ    _T_big_int, R8
+    self.Emit("MOVQ" , _AX, _R9)                        // MOVQ    AX, R9
+    self.Emit("MOVQ" , _VAR_ss_Ep, _DI)                 // MOVQ    ss.Ep, DI
+    self.Sjmp("JMP"  , "_set_value")                    // JMP     _set_value
+
     /* represent numbers as `int64` */
     self.Link("_use_int64")                     // _use_int64:
     self.Emit("MOVQ", _VAR_ss_Iv, _AX)          // MOVQ    ss.Iv, AX
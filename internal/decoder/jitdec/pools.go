@@ -32,6 +32,11 @@ const (
     _MaxDigitNums = types.MaxDigitNums  // used in atof fallback algorithm
 )
 
+const (
+    _SeenTag    = -1 << 63 // tag of the seen masks of struct fields on the stack
+    _MaxSeen    = 63       // max number of fields tracked in a seen mask
+)
+
 const (
     _PtrBytes   = _PTR_SIZE / 8
     _FsmOffset  = (_MaxStack + 1) * _PtrBytes
@@ -46,6 +51,7 @@ var (
     fieldCache    = []*caching.FieldMap(nil)
     fieldCacheMux = sync.Mutex{}
     programCache  = caching.CreateProgramCache()
+    presenceCache = caching.CreateProgramCache()
 )
 
 type _Stack struct {
@@ -55,6 +61,7 @@ type _Stack struct {
     vp [types.MAX_RECURSE]unsafe.Pointer
     dp [_MaxDigitNums]byte
     ep unsafe.Pointer
+    pr *presence
 }
 
 type _Decoder func(
@@ -107,6 +114,7 @@ func resetStack(p *_Stack) {
 
 func freeStack(p *_Stack) {
     p.sp = 0
+    p.pr = nil
     stackPool.Put(p)
 }
 
@@ -134,10 +142,26 @@ func makeDecoder(vt *rt.GoType, _ ...interface{}) (interface{}, error) {
     }
 }
 
-func findOrCompile(vt *rt.GoType) (_Decoder, error) {
-    if val := programCache.Get(vt); val != nil {
+func makePresenceDecoder(vt *rt.GoType, _ ...interface{}) (interface{}, error) {
+    cc := newCompiler()
+    cc.presence = true
+    if pp, err := cc.compile(vt.Pack()); err != nil {
+        return nil, err
+    } else {
+        return newAssembler(pp).Load(), nil
+    }
+}
+
+// findOrCompile returns the decoder of vt, the programs tracking the fields
+// for DecodePresence are compiled and cached apart from the others.
+func findOrCompile(vt *rt.GoType, fv uint64) (_Decoder, error) {
+    cache, compile := programCache, makeDecoder
+    if (fv & (1 << _F_presence)) != 0 {
+        cache, compile = presenceCache, makePresenceDecoder
+    }
+    if val := cache.Get(vt); val != nil {
         return val.(_Decoder), nil
-    } else if ret, err := programCache.Compute(vt, makeDecoder); err == nil {
+    } else if ret, err := cache.Compute(vt, compile); err == nil {
         return ret.(_Decoder), nil
     } else {
         return nil, err
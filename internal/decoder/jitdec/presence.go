@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jitdec
+
+import (
+    `reflect`
+    `sort`
+    `sync`
+    `unsafe`
+
+    `github.com/bytedance/sonic/internal/decoder/consts`
+    `github.com/bytedance/sonic/internal/decoder/errors`
+    `github.com/bytedance/sonic/internal/resolver`
+    `github.com/bytedance/sonic/internal/rt`
+)
+
+// presenceRecord is either the masks of the seen and null fields of a struct,
+// or an unknown key of it, at a position where the object of the struct is open.
+type presenceRecord struct {
+    pos  int
+    vt   *rt.GoType
+    key  string
+    mask []uint64
+}
+
+// presence collects the records of a decoding, the paths are only built when
+// it is done, with a single pass over the input.
+type presence struct {
+    recs []presenceRecord
+}
+
+// presenceUnknown records the unknown key right after the colon at s[ic].
+func presenceUnknown(sb *_Stack, key *string, ic int) {
+    if sb.pr != nil {
+        sb.pr.recs = append(sb.pr.recs, presenceRecord {
+            pos : ic,
+            key : string(rt.Str2Mem(*key)),
+        })
+    }
+}
+
+// presenceEnd pops the n seen masks and n null masks of the struct vt whose
+// object ends right before s[ic], and records them.
+func presenceEnd(sb *_Stack, vt *rt.GoType, ic int, n int) {
+    top := int(sb.sp / _PtrBytes)
+    words := sb.sb[top - n * 2:top]
+
+    /* the masks are tagged as non-pointers */
+    if sb.pr != nil {
+        mask := make([]uint64, n * 2)
+        for i, w := range words {
+            mask[i] = uint64(uintptr(w)) &^ (1 << _MaxSeen)
+        }
+        sb.pr.recs = append(sb.pr.recs, presenceRecord {
+            pos  : ic - 1,
+            vt   : vt,
+            mask : mask,
+        })
+    }
+
+    /* clear the slots without write barriers */
+    for i := range words {
+        *(*uintptr)(unsafe.Pointer(&words[i])) = 0
+    }
+    sb.sp -= uintptr(n * 2 * _PtrBytes)
+}
+
+// states builds the Go paths of the records, the input starts at s[i] and is decoded into vt.
+func (self *presence) states(s string, i int, vt reflect.Type) map[string]consts.FieldState {
+    ret := make(map[string]consts.FieldState)
+    sort.SliceStable(self.recs, func(a, b int) bool {
+        return self.recs[a].pos < self.recs[b].pos
+    })
+
+    sc := errors.NewPathScanner(s, i)
+    for _, r := range self.recs {
+        path := sc.ObjectPath(vt, r.pos)
+        if r.vt == nil {
+            ret[joinPath(path, r.key)] = consts.FieldUnknown
+            continue
+        }
+
+        /* the null masks follow the seen ones */
+        n := len(r.mask) / 2
+        for ix, name := range presenceNames(r.vt.Pack()) {
+            w, b := ix / _MaxSeen, uint(ix % _MaxSeen)
+            if name == "" || r.mask[w] & (1 << b) == 0 {
+                continue
+            }
+            if r.mask[n + w] & (1 << b) != 0 {
+                ret[joinPath(path, name)] = consts.FieldNull
+            } else {
+                ret[joinPath(path, name)] = consts.FieldPresent
+            }
+        }
+    }
+    return ret
+}
+
+func joinPath(path string, name string) string {
+    if path == "" {
+        return name
+    }
+    return path + "." + name
+}
+
+var presenceNameCache sync.Map
+
+// presenceNames returns the Go names of the fields in ResolveStruct(vt),
+// which is empty for the catch-all field.
+func presenceNames(vt reflect.Type) []string {
+    if v, ok := presenceNameCache.Load(vt); ok {
+        return v.([]string)
+    }
+    fv := resolver.ResolveStruct(vt)
+    ix, _ := resolver.InlineField(vt, fv)
+    ret := make([]string, len(fv))
+    for i, f := range fv {
+        if i == ix {
+            continue
+        }
+        ret[i] = f.Name
+        if sf, ok := resolver.FieldByName(vt, f.Name); ok {
+            ret[i] = sf.Name
+        }
+    }
+    v, _ := presenceNameCache.LoadOrStore(vt, ret)
+    return v.([]string)
+}
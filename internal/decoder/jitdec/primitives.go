@@ -19,14 +19,22 @@ package jitdec
 import (
     `encoding`
     `encoding/json`
+    `math/big`
+    `reflect`
+    `strconv`
+    `time`
     `unsafe`
 
+    `github.com/bytedance/sonic/internal/decoder/consts`
+    `github.com/bytedance/sonic/internal/decoder/errors`
     `github.com/bytedance/sonic/internal/native`
+    `github.com/bytedance/sonic/internal/resolver`
     `github.com/bytedance/sonic/internal/rt`
+    `github.com/bytedance/sonic/internal/utils`
 )
 
 func decodeTypedPointer(s string, i int, vt *rt.GoType, vp unsafe.Pointer, sb *_Stack, fv uint64) (int, error) {
-    if fn, err := findOrCompile(vt); err != nil {
+    if fn, err := findOrCompile(vt, fv); err != nil {
         return 0, err
     } else {
         rt.MoreStack(_FP_size + _VD_size + native.MaxFrameSize)
@@ -35,6 +43,164 @@ func decodeTypedPointer(s string, i int, vt *rt.GoType, vp unsafe.Pointer, sb *_
     }
 }
 
+// decodeInlineField stores an unknown key and its raw JSON value into the catch-all field vp.
+func decodeInlineField(vt *rt.GoType, vp unsafe.Pointer, key *string, raw string, fv uint64) error {
+    k := *key
+    if (fv & (1 << _F_copy_string)) != 0 {
+        k = string(rt.Str2Mem(k))
+    }
+
+    /* non-map catch-alls have their own setters */
+    mt := vt.Pack()
+    if mt.Kind() != reflect.Map {
+        return resolver.FindInlineSetter(mt)(vp, k, raw)
+    }
+
+    /* decode the value with the same options, then put it into the map */
+    mv := reflect.NewAt(mt, vp).Elem()
+    if mv.IsNil() {
+        mv.Set(reflect.MakeMap(mt))
+    }
+    ev, pos := reflect.New(mt.Elem()), 0
+    if err := Decode(&raw, &pos, fv, ev.Interface()); err != nil {
+        return err
+    }
+    mv.SetMapIndex(reflect.ValueOf(k).Convert(mt.Key()), ev.Elem())
+    return nil
+}
+
+// checkStructFields fills the absent fields of the struct vp with their default values,
+// or reports the first absent `required` one. The object ends right before s[i].
+func checkStructFields(s string, i int, vt *rt.GoType, vp unsafe.Pointer, seen uint64, fv uint64) error {
+    st := vt.Pack()
+    ck, _ := resolver.CheckedFields(st)
+    fields := resolver.ResolveStruct(st)
+
+    for b, ix := range ck {
+        if seen & (1 << uint(b)) != 0 {
+            continue
+        }
+        f := &fields[ix]
+        if (f.Opts & resolver.F_required) != 0 {
+            return errors.ErrorMissingField(s, i, st, f.Name)
+        }
+
+        /* seek into the field, allocating embedded pointers */
+        fp := vp
+        for _, o := range f.Path {
+            fp = unsafe.Pointer(uintptr(fp) + o.Size)
+            if o.Kind == resolver.F_deref {
+                deref := rt.UnpackType(o.Type)
+                if *(*unsafe.Pointer)(fp) == nil {
+                    *(*unsafe.Pointer)(fp) = rt.Mallocgc(deref.Size, deref, true)
+                }
+                fp = *(*unsafe.Pointer)(fp)
+            }
+        }
+
+        /* decode the default value with the same options */
+        lit, pos := f.Default, 0
+        if err := Decode(&lit, &pos, fv, reflect.NewAt(f.Type, fp).Interface()); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// decodeBig decodes the number s[i:e] into the `big.Int` or `big.Float` at vp, a
+// `big.Float` is also decoded from a string of the number like its text unmarshaler.
+func decodeBig(vt *rt.GoType, vp unsafe.Pointer, s string, i int, e int) error {
+    ok, num := false, s[i:e]
+    if resolver.IsBigFloat(vt.Pack()) {
+        if len(num) >= 2 && num[0] == '"' {
+            num = num[1:len(num) - 1]
+        }
+        ok = utils.SetBigFloat((*big.Float)(vp), num)
+    } else {
+        ok = utils.SetBigInt((*big.Int)(vp), num)
+    }
+    if !ok {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    return nil
+}
+
+// bigInteger returns the integer literal num as a big.Int if it overflows int64,
+// or nil for the other numbers.
+func bigInteger(num string) *big.Int {
+    if !utils.IsInteger(num) {
+        return nil
+    }
+    if _, err := strconv.ParseInt(num, 10, 64); err == nil {
+        return nil
+    }
+    v, ok := new(big.Int).SetString(num, 10)
+    if !ok {
+        return nil
+    }
+    return v
+}
+
+var timeType = rt.UnpackType(reflect.TypeOf(time.Time{}))
+
+// decodeTime decodes s[i:e] into the `time.Time` at vp in the time format of fv.
+func decodeTime(vp unsafe.Pointer, s string, i int, e int, fv uint64) error {
+    t, ok, err := utils.ParseTime(s[i:e], (fv >> consts.F_time_format) & utils.TimeFormatMask)
+    if !ok {
+        return errors.ErrorMismatch(s, i, timeType)
+    }
+    if err != nil {
+        return err
+    }
+    *(*time.Time)(vp) = t
+    return nil
+}
+
+// decodeDuration decodes the string s[i:e] like `"1.5s"` into the `time.Duration` at vp.
+func decodeDuration(vt *rt.GoType, vp unsafe.Pointer, s string, i int, e int) error {
+    str, ok := utils.Unquote(s[i:e])
+    if !ok {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    d, err := time.ParseDuration(str)
+    if err != nil {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    *(*time.Duration)(vp) = d
+    return nil
+}
+
+// decodeNaNInf decodes the string s[i:e] of NaN or ±Inf into the float of type vt at vp.
+func decodeNaNInf(vt *rt.GoType, vp unsafe.Pointer, s string, i int, e int) error {
+    f, ok := utils.ParseNaNInf(s[i:e])
+    if !ok {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    if vt.Kind() == reflect.Float32 {
+        *(*float32)(vp) = float32(f)
+    } else {
+        *(*float64)(vp) = f
+    }
+    return nil
+}
+
+// decodeQuotedInt decodes the string s[i:e] of an integer into the integer of type vt at vp.
+func decodeQuotedInt(vt *rt.GoType, vp unsafe.Pointer, s string, i int, e int) error {
+    if !utils.DecodeQuotedInt(vt.Kind(), vp, s[i:e]) {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    return nil
+}
+
+// decodeFormat decodes s[i:e] into the value of type vt at vp in the `format:` option of the id.
+func decodeFormat(vt *rt.GoType, vp unsafe.Pointer, s string, i int, e int, id int) error {
+    ok, err := utils.DecodeFormat(resolver.FormatOf(id), vp, s[i:e])
+    if !ok {
+        return errors.ErrorMismatch(s, i, vt)
+    }
+    return err
+}
+
 func decodeJsonUnmarshaler(vv interface{}, s string) error {
     return vv.(json.Unmarshaler).UnmarshalJSON(rt.Str2Mem(s))
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jitdec
+
+import (
+    `encoding/json`
+    `strings`
+    `unsafe`
+    `reflect`
+
+    `github.com/bytedance/sonic/internal/decoder/errors`
+    `github.com/bytedance/sonic/internal/native`
+    `github.com/bytedance/sonic/internal/native/types`
+    `github.com/bytedance/sonic/internal/resolver`
+    `github.com/bytedance/sonic/internal/rt`
+)
+
+// decodeUnion decodes the object at s[i] into the union interface vp, as the
+// member type named by its discriminator. The key is never an unknown field.
+func decodeUnion(vt *rt.GoType, vp unsafe.Pointer, s string, i int, fv uint64) error {
+    it := vt.Pack()
+    u := resolver.FindUnion(it)
+
+    /* find the discriminator at first */
+    pv, end := reflect.New(u.Probe), i
+    if err := Decode(&s, &end, fv &^ (1 << _F_disable_unknown), pv.Interface()); err != nil {
+        return err
+    }
+    name := pv.Elem().Field(0).Interface().(*string)
+    if name == nil {
+        return errors.ErrorUnion(s, i, it, u.Key, "", true)
+    }
+    mt, ok := u.Types[*name]
+    if !ok {
+        return errors.ErrorUnion(s, i, it, u.Key, *name, false)
+    }
+
+    et := mt
+    if mt.Kind() == reflect.Ptr {
+        et = mt.Elem()
+    }
+    mv := reflect.New(et)
+
+    /* then decode the member with the same options, the key is blanked out
+     * of a copy of the object if the member would take it as unknown */
+    if (fv & (1 << _F_disable_unknown)) == 0 || u.Keyed(mt) {
+        pos := i
+        if err := Decode(&s, &pos, fv, mv.Interface()); err != nil {
+            return err
+        }
+    } else {
+        obj, pos := blankKey(s[i:end], u.Key, (fv & (1 << _F_case_sensitive)) != 0), 0
+        if err := Decode(&obj, &pos, fv, mv.Interface()); err != nil {
+            return shiftError(err, s, i)
+        }
+    }
+
+    if mt.Kind() != reflect.Ptr {
+        mv = mv.Elem()
+    }
+    reflect.NewAt(it, vp).Elem().Set(mv)
+    return nil
+}
+
+// blankKey returns a copy of the object s, with its members of the key
+// replaced by spaces, so the positions in the copy are kept.
+func blankKey(s string, key string, cs bool) string {
+    buf := []byte(s)
+    fsm := types.NewStateMachine()
+    defer types.FreeStateMachine(fsm)
+
+    /* the object has been validated by the probe */
+    p, prev := 1, -1
+    for {
+        p = skipSpace(s, p)
+        if p >= len(s) || s[p] == '}' {
+            break
+        }
+
+        /* the key and the value */
+        ks := native.SkipOne(&s, &p, fsm, 0)
+        if ks < 0 {
+            break
+        }
+        name := s[ks + 1:p - 1]
+        if strings.IndexByte(name, '\\') >= 0 {
+            _ = json.Unmarshal([]byte(s[ks:p]), &name)
+        }
+        p = skipSpace(s, p) + 1
+        if native.SkipOne(&s, &p, fsm, 0) < 0 {
+            break
+        }
+        ve := p
+        p = skipSpace(s, p)
+        next := p < len(s) && s[p] == ','
+
+        /* blank the member along with one of its commas */
+        if name == key || (!cs && strings.EqualFold(name, key)) {
+            from, to := ks, ve
+            if next {
+                to = p + 1
+            } else if prev >= 0 {
+                from = prev
+            }
+            for j := from; j < to; j++ {
+                buf[j] = ' '
+            }
+        }
+        if !next {
+            break
+        }
+        prev = p
+        p++
+    }
+    return rt.Mem2Str(buf)
+}
+
+func skipSpace(s string, p int) int {
+    for p < len(s) && (types.SPACE_MASK & (1 << s[p])) != 0 {
+        p++
+    }
+    return p
+}
+
+// shiftError moves the error of the object copied from s[i:] back into s.
+func shiftError(err error, s string, i int) error {
+    switch e := err.(type) {
+        case errors.SyntaxError         : e.Pos += i; e.Src = s; return e
+        case errors.MismatchTypeError   : e.Pos += i; e.Src = s; return e
+        case *errors.MismatchTypeError  : e.Pos += i; e.Src = s
+        case *errors.MissingFieldError  : e.Pos += i; e.Src = s
+        case *errors.UnionError         : e.Pos += i; e.Src = s
+    }
+    return err
+}
@@ -139,18 +139,44 @@ func (c *compiler) compileStruct(vt reflect.Type) decFunc {
 }
 
 func (c *compiler) compileStructBody(vt reflect.Type) decFunc {
+	if resolver.IsArrayStruct(vt) {
+		return c.compileStructArray(vt)
+	}
+
 	fv := resolver.ResolveStruct(vt)
 	entries := make([]fieldEntry, 0, len(fv))
+	fields := make([]resolver.FieldMeta, 0, len(fv))
+	ix, err := resolver.InlineField(vt, fv)
+	if err != nil {
+		panic(err)
+	}
 
-	for _, f := range fv {
-		var dec decFunc
-		/* dealt with field tag options */
-		if f.Opts&resolver.F_stringize != 0 {
-			dec = c.compileFieldStringOption(f.Type)
-		} else {
-			dec = c.compile(f.Type)
+	/* the required and defaulted fields are tracked when seen */
+	ck, err := resolver.CheckedFields(vt)
+	if err != nil {
+		panic(err)
+	}
+	if len(ck) > 64 {
+		panic(fmt.Errorf("json: too many required or defaulted fields in %s", vt))
+	}
+	seen := make(map[int]uint64, len(ck))
+	checked := []resolver.FieldMeta(nil)
+	for b, i := range ck {
+		seen[i] = 1 << uint(b)
+		checked = append(checked, fv[i])
+	}
+
+	var inline *inlineDecoder
+	for i, f := range fv {
+		/* the catch-all field is never matched by name */
+		if i == ix {
+			inline = c.compileInlineField(vt, f)
+			continue
 		}
 
+		/* dealt with field tag options */
+		dec := c.compileFieldValue(&f)
+
 		/* deal with embedded pointer fields */
 		if f.Path[0].Kind == resolver.F_deref {
 			dec = &embeddedFieldPtrDecoder{
@@ -163,12 +189,83 @@ func (c *compiler) compileStructBody(vt reflect.Type) decFunc {
 		entries = append(entries, fieldEntry{
 			FieldMeta: f,
 			fieldDec:  dec,
+			seen:      seen[i],
 		})
+		fields = append(fields, f)
 	}
 	return &structDecoder{
-		fieldMap:  	caching.NewFieldLookup(fv),
+		fieldMap:   caching.NewFieldLookup(fields),
 		fields:     entries,
+		inline:     inline,
+		checked:    checked,
 		structName: vt.Name(),
 		typ: 		vt,
 	}
 }
+
+func (c *compiler) compileFieldValue(f *resolver.FieldMeta) decFunc {
+	/* floats are decoded as usual in any format */
+	if f.Format != "" {
+		id, err := resolver.ResolveFormat(f.Type, f.Format)
+		if err != nil {
+			panic(err)
+		}
+		if id != 0 && resolver.FormatOf(id).Kind != resolver.FormatPrintf {
+			return c.compileFieldFormat(f.Type, id)
+		}
+	}
+
+	if f.Opts&resolver.F_stringize != 0 {
+		return c.compileFieldStringOption(f.Type)
+	}
+	return c.compile(f.Type)
+}
+
+func (c *compiler) compileFieldFormat(vt reflect.Type, id int) decFunc {
+	if vt.Kind() != reflect.Ptr {
+		return &formatDecoder{typ: vt, id: id}
+	}
+	return &ptrDecoder{
+		typ:   rt.UnpackType(vt.Elem()),
+		deref: &formatDecoder{typ: vt.Elem(), id: id},
+	}
+}
+
+func (c *compiler) compileStructArray(vt reflect.Type) decFunc {
+	fv := resolver.ResolveStruct(vt)
+	entries := make([]fieldEntry, 0, len(fv))
+	for _, f := range fv {
+		dec := c.compileFieldValue(&f)
+		if f.Path[0].Kind == resolver.F_deref {
+			dec = &embeddedFieldPtrDecoder{
+				field:    	f,
+				fieldDec:   dec,
+				fieldName:  f.Name,
+			}
+		}
+		entries = append(entries, fieldEntry{
+			FieldMeta: f,
+			fieldDec:  dec,
+		})
+	}
+	return &structArrayDecoder{
+		fields: entries,
+		typ:    vt,
+	}
+}
+
+func (c *compiler) compileInlineField(vt reflect.Type, f resolver.FieldMeta) *inlineDecoder {
+	if f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String {
+		return &inlineDecoder{
+			field:   f,
+			elemDec: c.compile(f.Type.Elem()),
+		}
+	}
+	if fn := resolver.FindInlineSetter(f.Type); fn != nil {
+		return &inlineDecoder{
+			field:  f,
+			setter: fn,
+		}
+	}
+	panic(resolver.InlineTypeError(vt, &f))
+}
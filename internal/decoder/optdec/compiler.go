@@ -8,6 +8,7 @@ import (
 	"github.com/bytedance/sonic/option"
 	"github.com/bytedance/sonic/internal/rt"
 	"github.com/bytedance/sonic/internal/caching"
+	"github.com/bytedance/sonic/internal/resolver"
 )
 
 var (
@@ -135,6 +136,9 @@ func (c *compiler) compileBasic(vt reflect.Type) decFunc {
 	case reflect.Int32:
 		return &i32Decoder{}
 	case reflect.Int64:
+		if resolver.IsDuration(vt) {
+			return &durationDecoder{}
+		}
 		return &i64Decoder{}
 	case reflect.Uint8:
 		return &u8Decoder{}
@@ -167,6 +171,15 @@ func (c *compiler) compileBasic(vt reflect.Type) decFunc {
 	case reflect.Slice:
 		return c.compileSlice(vt)
 	case reflect.Struct:
+		if resolver.IsOptional(vt) {
+			return c.compileOptional(vt)
+		}
+		if resolver.IsBig(vt) {
+			return &bigDecoder{typ: vt}
+		}
+		if resolver.IsTime(vt) {
+			return &timeDecoder{}
+		}
 		return c.compileStruct(vt)
 	default:
 		panic(&json.UnmarshalTypeError{Type: vt})
@@ -192,6 +205,16 @@ func (c *compiler) compilePtr(vt reflect.Type) decFunc {
 	}
 }
 
+func (c *compiler) compileOptional(vt reflect.Type) decFunc {
+	c.enter(vt)
+	defer c.exit(vt)
+	et, off := resolver.OptionalValue(vt)
+	return &optionalDecoder{
+		offset:  off,
+		elemDec: c.compile(et),
+	}
+}
+
 func (c *compiler) compileArray(vt reflect.Type) decFunc {
 	c.enter(vt)
 	defer c.exit(vt)
@@ -248,30 +271,31 @@ func (c *compiler) compileSlice(vt reflect.Type) decFunc {
 		return dec
 	}
 
+	slow := &sliceDecoder{
+		elemType: rt.UnpackType(vt.Elem()),
+		elemDec:  c.compile(vt.Elem()),
+		typ: vt,
+	}
+
 	if vt == reflect.TypeOf([]interface{}{}) {
-		return &sliceEfaceDecoder{}
+		return &weakDecoder{&sliceEfaceDecoder{}, slow}
 	}
 	if et.IsInt32() {
-		return &sliceI32Decoder{}
+		return &weakDecoder{&sliceI32Decoder{}, slow}
 	}
-	if et.IsInt64() {
-		return &sliceI64Decoder{}
+	if et.IsInt64() && !resolver.IsDuration(vt.Elem()) {
+		return &weakDecoder{&sliceI64Decoder{}, slow}
 	}
 	if et.IsUint32() {
-		return &sliceU32Decoder{}
+		return &weakDecoder{&sliceU32Decoder{}, slow}
 	}
 	if et.IsUint64() {
-		return &sliceU64Decoder{}
+		return &weakDecoder{&sliceU64Decoder{}, slow}
 	}
 	if et.Kind() == reflect.String {
-		return &sliceStringDecoder{}
-	}
-
-	return &sliceDecoder{
-		elemType: rt.UnpackType(vt.Elem()),
-		elemDec:  c.compile(vt.Elem()),
-		typ: vt,
+		return &weakDecoder{&sliceStringDecoder{}, slow}
 	}
+	return slow
 }
 
 func (c *compiler) compileSliceBytes(vt reflect.Type) decFunc {
@@ -315,6 +339,13 @@ func (c *compiler) compileInterface(vt reflect.Type) decFunc {
 		}
 	}
 
+	if u := resolver.FindUnion(vt); u != nil {
+		return &unionDecoder{
+			typ:   vt,
+			union: u,
+		}
+	}
+
 	return &ifaceDecoder{
 		typ: rt.UnpackType(vt),
 	}
@@ -337,7 +368,11 @@ func (c *compiler) compileMap(vt reflect.Type) decFunc {
 	if vt == reflect.TypeOf(map[string]interface{}{}) {
 		return &mapEfaceDecoder{}
 	} else if vt == reflect.TypeOf(map[string]string{}) {
-		return &mapStringDecoder{}
+		return &weakDecoder{&mapStringDecoder{}, &mapStrKeyDecoder{
+			mapType: rt.MapType(rt.UnpackType(vt)),
+			assign: rt.GetMapStrAssign(vt),
+			elemDec: c.compile(vt.Elem()),
+		}}
 	}
 
 	// Some common integer map later
@@ -422,6 +457,11 @@ func (c *compiler) compileMapKey(vt reflect.Type) decKey {
 func (c *compiler) tryCompilePtrUnmarshaler(vt reflect.Type, strOpt bool) decFunc {
 	pt := reflect.PtrTo(vt)
 
+	/* optional values, big numbers and times are decoded natively */
+	if resolver.IsOptional(vt) || resolver.IsBig(vt) || resolver.IsTime(vt) {
+		return nil
+	}
+
 	/* check for `json.Unmarshaler` with pointer receiver */
 	if pt.Implements(jsonUnmarshalerType) {
 		return &unmarshalJSONDecoder{
@@ -15,6 +15,7 @@ import (
 type (
 	MismatchTypeError = errors.MismatchTypeError
 	SyntaxError = errors.SyntaxError
+	DecodeErrors = errors.DecodeErrors
 )
 
 const (
@@ -25,6 +26,11 @@ const (
 	_F_use_int64 = consts.F_use_int64
 	_F_use_number = consts.F_use_number
 	_F_validate_string = consts.F_validate_string
+	_F_use_big_int = consts.F_use_big_int
+	_F_duration_string = consts.F_duration_string
+	_F_nan_inf_string = consts.F_nan_inf_string
+	_F_quoted_int = consts.F_quoted_int
+	_F_time_format = consts.F_time_format
 )
 
 type Options = consts.Options
@@ -40,6 +46,28 @@ const (
 
 
 func Decode(s *string, i *int, f uint64, val interface{}) error {
+	return decode(s, i, f, val, nil, nil)
+}
+
+// DecodePresence decodes like Decode, and reports the state of each struct field
+// and unknown key in the input by its Go path, reusing the parsed document.
+func DecodePresence(s *string, i *int, f uint64, val interface{}) (map[string]FieldState, error) {
+	ret := make(map[string]FieldState)
+	if err := decode(s, i, f, val, ret, nil); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// DecodeCoercions decodes like Decode, and reports the values that were
+// coerced across JSON types with the enabled coercion options.
+func DecodeCoercions(s *string, i *int, f uint64, val interface{}) ([]Coercion, error) {
+	var ret []Coercion
+	err := decode(s, i, f, val, nil, &ret)
+	return ret, err
+}
+
+func decode(s *string, i *int, f uint64, val interface{}, pr map[string]FieldState, co *[]Coercion) error {
 	vv := rt.UnpackEface(val)
 	vp := vv.Value
 
@@ -76,14 +104,27 @@ func Decode(s *string, i *int, f uint64, val interface{}) error {
 	if err != nil {
 		goto fix_error;
 	}
+	ctx.reportCoercions = co != nil
 	err = dec.FromDom(vp, ctx.Root(), &ctx)
+	if pr != nil {
+		p := presence{ctx: &ctx, out: pr}
+		p.walk(rt.PtrElem(vv.Type).Pack(), ctx.Root(), "")
+	}
+	if co != nil {
+		*co = coercions(&ctx, *s, *i)
+	}
+	if ctx.collecting() {
+		err = collected_errors(&ctx, *s, *i, err)
+		*i += ctx.Parser.Pos()
+		return errors.WithRootType(err, rt.PtrElem(vv.Type).Pack())
+	}
 
 fix_error:
 	err = fix_error(*s, *i, err)
 
 	// update position at last
 	*i += ctx.Parser.Pos()
-	return err
+	return errors.WithRootType(err, rt.PtrElem(vv.Type).Pack())
 }
 
 func fix_error(json string, pos int, err error) error {
@@ -103,6 +144,26 @@ func fix_error(json string, pos int, err error) error {
 		}
 	}
 
+	if e, ok := err.(*errors.UnionError); ok {
+		return &errors.UnionError {
+			Pos: e.Pos + pos,
+			Src: json,
+			Type: e.Type,
+			Key: e.Key,
+			Value: e.Value,
+			Missing: e.Missing,
+		}
+	}
+
+	if e, ok := err.(*errors.MissingFieldError); ok {
+		return &errors.MissingFieldError {
+			Pos: e.Pos + pos,
+			Src: json,
+			Field: e.Field,
+			Type: e.Type,
+		}
+	}
+
 	return err
 }
 
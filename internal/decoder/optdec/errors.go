@@ -22,6 +22,8 @@
 	 "reflect"
 	 "strconv"
  
+	 "github.com/bytedance/sonic/internal/decoder/consts"
+	 derrors "github.com/bytedance/sonic/internal/decoder/errors"
 	 "github.com/bytedance/sonic/internal/rt"
  )
 
@@ -37,8 +39,12 @@
  }
  
  func error_mismatch(node Node, ctx *context, typ reflect.Type) error {
+	 pos := node.Position()
+	 if node.IsStr() {
+		 pos -= 1 // points to the opening quote, same as the JIT decoder
+	 }
 	 return MismatchTypeError{
-		 Pos:  node.Position(),
+		 Pos:  pos,
 		 Src:  ctx.Parser.Json,
 		 Type: typ,
 	 }
@@ -70,4 +76,64 @@
 		 Msg: msg,
 	 }
  }
- 
\ No newline at end of file
+ 
+
+ /** Error Collecting Helpers **/
+
+ // errCollected is returned by the containers in `OptionCollectErrors` mode,
+ // after the errors of their elements have been recorded into the context.
+ var errCollected = errors.New("json: errors have been collected")
+
+ type collectedError struct {
+	pos int
+	err error
+ }
+
+ func (ctx *context) collecting() bool {
+	return ctx.Options() & (1 << consts.F_collect_errors) != 0
+ }
+
+ // collect merges the error of an element decoded from node into gerr.
+ // Only the first one is kept by default, otherwise all of them are recorded.
+ func (ctx *context) collect(gerr error, err error, node Node) error {
+	if err == nil {
+		return gerr
+	}
+	if !ctx.collecting() {
+		if gerr == nil {
+			return err
+		}
+		return gerr
+	}
+	if err != errCollected {
+		pos := node.Position()
+		if node.IsStr() {
+			pos -= 1 // points to the opening quote
+		}
+		ctx.errs = append(ctx.errs, collectedError{pos, err})
+	}
+	return errCollected
+ }
+
+ func collected_errors(ctx *context, src string, start int, err error) error {
+	if _, ok := err.(SyntaxError); ok {
+		return err
+	}
+
+	/* the root value itself may fail */
+	if err != nil && err != errCollected {
+		ctx.collect(nil, err, ctx.Root())
+	}
+	if len(ctx.errs) == 0 {
+		return nil
+	}
+
+	/* the errors are mostly in order, so their paths are scanned in one pass */
+	ret := make(DecodeErrors, 0, len(ctx.errs))
+	sc := derrors.NewPathScanner(src, start)
+	for _, e := range ctx.errs {
+		ret = append(ret, sc.DecodeError(start + e.pos, fix_error(src, start, e.err)))
+	}
+	return ret
+ }
+
@@ -3,10 +3,14 @@ package optdec
 import (
 	"encoding/json"
 	"math"
+	"math/big"
+	"reflect"
+	"time"
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/rt"
 	"github.com/bytedance/sonic/internal/resolver"
+	"github.com/bytedance/sonic/internal/utils"
 )
 
 type decFunc interface {
@@ -20,7 +24,7 @@ type ptrDecoder struct {
 
 // Pointer Value is allocated in the Caller
 func (d *ptrDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
-	if node.IsNull() {
+	if node.IsNull() || (node.IsStr() && weakNil(node, ctx, d.typ)) {
 		*(*unsafe.Pointer)(vp) = nil
 		return nil
 	}
@@ -32,6 +36,21 @@ func (d *ptrDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	return d.deref.FromDom(*(*unsafe.Pointer)(vp), node, ctx)
 }
 
+type optionalDecoder struct {
+	offset  uintptr
+	elemDec decFunc
+}
+
+func (d *optionalDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		*(*uint16)(vp) = resolver.OptionalNull
+		return nil
+	}
+
+	*(*uint16)(vp) = resolver.OptionalSet
+	return d.elemDec.FromDom(unsafe.Pointer(uintptr(vp) + d.offset), node, ctx)
+}
+
 type embeddedFieldPtrDecoder struct {
 	field      resolver.FieldMeta
 	fieldDec   decFunc
@@ -67,6 +86,12 @@ func (d *i8Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsI64(ctx)
+	if !ok {
+		ret, ok = quotedInt(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakInt(node, ctx, math.MinInt8, math.MaxInt8, int8Type)
+	}
 	if !ok ||  ret > math.MaxInt8 || ret < math.MinInt8 {
 		return error_mismatch(node, ctx, int8Type)
 	}
@@ -83,6 +108,12 @@ func (d *i16Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsI64(ctx)
+	if !ok {
+		ret, ok = quotedInt(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakInt(node, ctx, math.MinInt16, math.MaxInt16, int16Type)
+	}
 	if !ok || ret > math.MaxInt16 || ret < math.MinInt16 {
 		return error_mismatch(node, ctx, int16Type)
 	}
@@ -99,6 +130,12 @@ func (d *i32Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsI64(ctx)
+	if !ok {
+		ret, ok = quotedInt(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakInt(node, ctx, math.MinInt32, math.MaxInt32, int32Type)
+	}
 	if !ok ||  ret > math.MaxInt32 || ret < math.MinInt32 {
 		return error_mismatch(node, ctx, int32Type)
 	}
@@ -115,6 +152,12 @@ func (d *i64Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsI64(ctx)
+	if !ok {
+		ret, ok = quotedInt(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakInt(node, ctx, math.MinInt64, math.MaxInt64, int64Type)
+	}
 	if !ok  {
 		return error_mismatch(node, ctx, int64Type)
 	}
@@ -131,6 +174,12 @@ func (d *u8Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsU64(ctx)
+	if !ok {
+		ret, ok = quotedUint(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakUint(node, ctx, math.MaxUint8, uint8Type)
+	}
 	if !ok || ret > math.MaxUint8 {
 		err := error_mismatch(node, ctx, uint8Type)
 		return err
@@ -148,6 +197,12 @@ func (d *u16Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsU64(ctx)
+	if !ok {
+		ret, ok = quotedUint(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakUint(node, ctx, math.MaxUint16, uint16Type)
+	}
 	if !ok || ret > math.MaxUint16 {
 		return error_mismatch(node, ctx, uint16Type)
 	}
@@ -163,6 +218,12 @@ func (d *u32Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsU64(ctx)
+	if !ok {
+		ret, ok = quotedUint(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakUint(node, ctx, math.MaxUint32, uint32Type)
+	}
 	if !ok || ret > math.MaxUint32 {
 		return error_mismatch(node, ctx, uint32Type)
 	}
@@ -179,6 +240,12 @@ func (d *u64Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsU64(ctx)
+	if !ok {
+		ret, ok = quotedUint(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakUint(node, ctx, math.MaxUint64, uint64Type)
+	}
 	if !ok {
 		return error_mismatch(node, ctx, uint64Type)
 	}
@@ -187,6 +254,26 @@ func (d *u64Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	return nil
 }
 
+// quotedInt decodes the string of an integer with OptionQuotedInt.
+func quotedInt(node Node, ctx *context) (int64, bool) {
+	if !node.IsStr() || ctx.Options() & (1 << _F_quoted_int) == 0 {
+		return 0, false
+	}
+	var ret int64
+	ok := utils.DecodeQuotedInt(reflect.Int64, unsafe.Pointer(&ret), node.AsRaw(ctx))
+	return ret, ok
+}
+
+// quotedUint decodes the string of an unsigned integer with OptionQuotedInt.
+func quotedUint(node Node, ctx *context) (uint64, bool) {
+	if !node.IsStr() || ctx.Options() & (1 << _F_quoted_int) == 0 {
+		return 0, false
+	}
+	var ret uint64
+	ok := utils.DecodeQuotedInt(reflect.Uint64, unsafe.Pointer(&ret), node.AsRaw(ctx))
+	return ret, ok
+}
+
 type f32Decoder struct{}
 
 func (d *f32Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
@@ -195,7 +282,13 @@ func (d *f32Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsF64(ctx)
-	if !ok || ret > math.MaxFloat32 || ret < -math.MaxFloat32 {
+	if !ok {
+		ret, ok = nanInf(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakFloat(node, ctx, float32Type)
+	}
+	if !ok || (!math.IsInf(ret, 0) && (ret > math.MaxFloat32 || ret < -math.MaxFloat32)) {
 		return error_mismatch(node, ctx, float32Type)
 	}
 
@@ -211,6 +304,12 @@ func (d *f64Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	}
 
 	ret, ok := node.AsF64(ctx)
+	if !ok {
+		ret, ok = nanInf(node, ctx)
+	}
+	if !ok {
+		ret, ok = weakFloat(node, ctx, float64Type)
+	}
 	if !ok {
 		return  error_mismatch(node, ctx, float64Type)
 	}
@@ -219,6 +318,14 @@ func (d *f64Decoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 	return nil
 }
 
+// nanInf decodes the strings of NaN and ±Inf with OptionNaNInfString.
+func nanInf(node Node, ctx *context) (float64, bool) {
+	if !node.IsStr() || ctx.Options() & (1 << _F_nan_inf_string) == 0 {
+		return 0, false
+	}
+	return utils.ParseNaNInf(node.AsRaw(ctx))
+}
+
 type boolDecoder struct {
 }
 
@@ -228,6 +335,9 @@ func (d *boolDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error
 	}
 
 	ret, ok := node.AsBool()
+	if !ok {
+		ret, ok = weakBool(node, ctx)
+	}
 	if !ok {
 		return error_mismatch(node, ctx, boolType)
 	}
@@ -245,6 +355,9 @@ func (d *stringDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) erro
 	}
 
 	ret, ok := node.AsStr(ctx)
+	if !ok {
+		ret, ok = weakString(node, ctx)
+	}
 	if !ok {
 		return error_mismatch(node, ctx, stringType)
 	}
@@ -268,6 +381,92 @@ func (d *numberDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) erro
 	return nil
 }
 
+// bigDecoder decodes a number into `big.Int` or `big.Float`, a `big.Float`
+// is also decoded from a string of the number like its text unmarshaler.
+type bigDecoder struct {
+	typ reflect.Type
+}
+
+func (d *bigDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		return nil
+	}
+
+	var num json.Number
+	var ok bool
+	if resolver.IsBigFloat(d.typ) {
+		num, ok = node.AsNumber(ctx)
+		ok = ok && utils.SetBigFloat((*big.Float)(vp), string(num))
+	} else {
+		num, ok = node.NonstrAsNumber(ctx)
+		ok = ok && utils.SetBigInt((*big.Int)(vp), string(num))
+	}
+	if !ok {
+		return error_mismatch(node, ctx, d.typ)
+	}
+	return nil
+}
+
+// timeDecoder decodes `time.Time` in the time format of the options.
+type timeDecoder struct{}
+
+func (d *timeDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		return nil
+	}
+
+	t, ok, err := utils.ParseTime(node.AsRaw(ctx), (ctx.Options() >> _F_time_format) & utils.TimeFormatMask)
+	if !ok {
+		return error_mismatch(node, ctx, timeType)
+	}
+	if err != nil {
+		return err
+	}
+	*(*time.Time)(vp) = t
+	return nil
+}
+
+// durationDecoder decodes `time.Duration` as an int64, and also from a string
+// like "1.5s" with OptionDurationString.
+type durationDecoder struct {
+	i64Decoder
+}
+
+func (d *durationDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if !node.IsStr() || ctx.Options() & (1 << _F_duration_string) == 0 {
+		return d.i64Decoder.FromDom(vp, node, ctx)
+	}
+
+	str, ok := node.AsStr(ctx)
+	if !ok {
+		return error_mismatch(node, ctx, durationType)
+	}
+	dur, err := time.ParseDuration(str)
+	if err != nil {
+		return error_mismatch(node, ctx, durationType)
+	}
+	*(*time.Duration)(vp) = dur
+	return nil
+}
+
+// formatDecoder decodes a field in the `format:` option of the id.
+type formatDecoder struct {
+	typ reflect.Type
+	id  int
+}
+
+func (d *formatDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		return nil
+	}
+
+	ok, err := utils.DecodeFormat(resolver.FormatOf(d.id), vp, node.AsRaw(ctx))
+	if !ok {
+		return error_mismatch(node, ctx, d.typ)
+	}
+	return err
+}
+
 type recuriveDecoder struct {
 	typ *rt.GoType
 }
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"unsafe"
 	"reflect"
+	"strings"
 
+	"github.com/bytedance/sonic/internal/decoder/consts"
+	derrors "github.com/bytedance/sonic/internal/decoder/errors"
+	"github.com/bytedance/sonic/internal/resolver"
 	"github.com/bytedance/sonic/internal/rt"
 )
 
@@ -167,3 +171,91 @@ func (d *unmarshalJSONDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *contex
 
 	return error_type(d.typ)
 }
+
+type unionDecoder struct {
+	typ   reflect.Type
+	union *resolver.Union
+}
+
+func (d *unionDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		*(*rt.GoIface)(vp) = rt.GoIface{}
+		return nil
+	}
+
+	obj, ok := node.AsObj()
+	if !ok {
+		return error_mismatch(node, ctx, d.typ)
+	}
+
+	/* find the discriminator like a field of the key */
+	val, ok := d.discriminator(obj, ctx)
+	if !ok {
+		return derrors.ErrorUnion("", node.Position(), d.typ, d.union.Key, "", true)
+	}
+	name, ok := val.AsStr(ctx)
+	if !ok {
+		return error_mismatch(val, ctx, stringType)
+	}
+	mt, ok := d.union.Types[name]
+	if !ok {
+		return derrors.ErrorUnion("", node.Position(), d.typ, d.union.Key, name, false)
+	}
+
+	/* the key is never an unknown field of the member */
+	if !d.union.Keyed(mt) {
+		key, obj := ctx.unionKey, ctx.unionObj
+		ctx.unionKey, ctx.unionObj = d.union.Key, node.cptr
+		defer func() { ctx.unionKey, ctx.unionObj = key, obj }()
+	}
+
+	et := mt
+	if mt.Kind() == reflect.Ptr {
+		et = mt.Elem()
+	}
+	dec, err := findOrCompile(rt.UnpackType(et))
+	if err != nil {
+		return err
+	}
+	mv := reflect.New(et)
+	if err := dec.FromDom(unsafe.Pointer(mv.Pointer()), node, ctx); err != nil {
+		return err
+	}
+	if mt.Kind() != reflect.Ptr {
+		mv = mv.Elem()
+	}
+	reflect.NewAt(d.typ, vp).Elem().Set(mv)
+	return nil
+}
+
+func (d *unionDecoder) discriminator(obj Object, ctx *context) (Node, bool) {
+	var fold Node
+	found := false
+	cs := ctx.Options()&uint64(consts.OptionCaseSensitive) != 0
+	next := obj.Children()
+	for i := 0; i < obj.Len(); i++ {
+		kn := NewNode(next)
+		key, _ := kn.AsStr(ctx)
+		val := NewNode(PtrOffset(next, 1))
+		next = val.Next()
+		if key == d.union.Key {
+			return val, true
+		}
+		if !found && !cs && strings.EqualFold(key, d.union.Key) {
+			fold, found = val, true
+		}
+	}
+	return fold, found
+}
+
+// isUnionKey tells whether the key of the object node is the discriminator
+// of the union member being decoded.
+func (ctx *context) isUnionKey(node Node, key string) bool {
+	if ctx.unionObj != node.cptr {
+		return false
+	}
+	if key == ctx.unionKey {
+		return true
+	}
+	return ctx.Options()&uint64(consts.OptionCaseSensitive) == 0 && strings.EqualFold(key, ctx.unionKey)
+}
@@ -71,9 +71,7 @@ func (d *mapStrKeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		valn := NewNode(PtrOffset(next, 1))
 		valp := d.assign(d.mapType, m, key)
 		err := d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 		next = valn.Next()
 	}
 
@@ -112,9 +110,7 @@ func (d *mapI32KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		keyn := NewNode(next)
 		k, ok := keyn.ParseI64(ctx)
 		if !ok || k > math.MaxInt32 || k < math.MinInt32 {
-			if gerr == nil {
-				gerr = error_mismatch(keyn, ctx, d.mapType.Pack())
-			}
+			gerr = ctx.collect(gerr, error_mismatch(keyn, ctx, d.mapType.Pack()), keyn)
 			valn := NewNode(PtrOffset(next, 1))
 			next = valn.Next()
 			continue
@@ -125,9 +121,7 @@ func (d *mapI32KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		valn := NewNode(PtrOffset(next, 1))
 		valp := d.assign(d.mapType, m, ku32)
 		err := d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 
 		next = valn.Next()
 	}
@@ -166,9 +160,7 @@ func (d *mapI64KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		key, ok := keyn.ParseI64(ctx)
 
 		if !ok {
-			if gerr == nil {
-				gerr = error_mismatch(keyn, ctx, d.mapType.Pack())
-			}
+			gerr = ctx.collect(gerr, error_mismatch(keyn, ctx, d.mapType.Pack()), keyn)
 			valn := NewNode(PtrOffset(next, 1))
 			next = valn.Next()
 			continue
@@ -178,9 +170,7 @@ func (d *mapI64KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		valn := NewNode(PtrOffset(next, 1))
 		valp := d.assign(d.mapType, m, ku64)
 		err := d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 		next = valn.Next()
 	}
 
@@ -219,9 +209,7 @@ func (d *mapU32KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		keyn := NewNode(next)
 		k, ok := keyn.ParseU64(ctx)
 		if !ok || k > math.MaxUint32 {
-			if gerr == nil {
-				gerr = error_mismatch(keyn, ctx, d.mapType.Pack())
-			}
+			gerr = ctx.collect(gerr, error_mismatch(keyn, ctx, d.mapType.Pack()), keyn)
 			valn := NewNode(PtrOffset(next, 1))
 			next = valn.Next()
 			continue
@@ -231,9 +219,7 @@ func (d *mapU32KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		valn := NewNode(PtrOffset(next, 1))
 		valp := d.assign(d.mapType, m, key)
 		err := d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 		next = valn.Next()
 	}
 
@@ -269,9 +255,7 @@ func (d *mapU64KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		keyn := NewNode(next)
 		key, ok := keyn.ParseU64(ctx)
 		if !ok {
-			if gerr == nil {
-				gerr = error_mismatch(keyn, ctx, d.mapType.Pack())
-			}
+			gerr = ctx.collect(gerr, error_mismatch(keyn, ctx, d.mapType.Pack()), keyn)
 			valn := NewNode(PtrOffset(next, 1))
 			next = valn.Next()
 			continue
@@ -280,9 +264,7 @@ func (d *mapU64KeyDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) e
 		valn := NewNode(PtrOffset(next, 1))
 		valp := d.assign(d.mapType, m, key)
 		err := d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 		next = valn.Next()
 	}
 
@@ -406,9 +388,7 @@ func (d *mapDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 		raw := keyn.AsRaw(ctx)
 		key, err := d.keyDec(d, raw, ctx)
 		if err != nil {
-			if gerr == nil {
-				gerr = error_mismatch(keyn, ctx, d.mapType.Pack())
-			}
+			gerr = ctx.collect(gerr, error_mismatch(keyn, ctx, d.mapType.Pack()), keyn)
 			valn := NewNode(PtrOffset(next, 1))
 			next = valn.Next()
 			continue
@@ -418,9 +398,7 @@ func (d *mapDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
 		keyp := rt.UnpackEface(key).Value
 		valp := rt.Mapassign(d.mapType, m, keyp)
 		err = d.elemDec.FromDom(valp, valn, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, valn)
 
 		next = valn.Next()
 	}
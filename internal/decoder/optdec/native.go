@@ -186,11 +186,26 @@ func (p *Parser) parse() ErrorCode {
 	if !p.isEface {
 		p.options &^= 1 << _F_use_number
 	}
+	err := p.parseOnce()
+
+	// keep the numbers as raw text if some float overflows, so that `big.Float`
+	// can decode it, and the other types report the mismatch by themselves
+	if err == SONIC_FLOAT_INF && !p.isEface {
+		p.options |= 1 << _F_use_number
+		p.cur = p.start
+		p.nbuf = nodeBuf{}
+		p._nbk = _nospaceBlock{}
+		p.nbuf.init(p.nodes)
+		err = p.parseOnce()
+	}
+	p.options = old
+	return err
+}
 
+func (p *Parser) parseOnce() ErrorCode {
 	// fast path with limited node buffer
 	err := ErrorCode(native.ParseWithPadding(unsafe.Pointer(p)))
 	if err != SONIC_VISIT_FAILED {
-		p.options = old
 		return err
 	}
 
@@ -219,9 +234,7 @@ func (p *Parser) parse() ErrorCode {
 	p.nbuf.ncur = p.nbuf.nstart + offset
 
 	// continue parse json
-	err = ErrorCode(native.ParseWithPadding(unsafe.Pointer(p)))
-	p.options = old
-	return err
+	return ErrorCode(native.ParseWithPadding(unsafe.Pointer(p)))
 }
 
 func (p *Parser) reset() {
@@ -1,11 +1,14 @@
 package optdec
 
 import (
+	"math/big"
 	"strings"
 	"testing"
 
+	"github.com/bytedance/sonic/internal/decoder/consts"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseNative(t *testing.T) {
@@ -70,3 +73,32 @@ func TestParseNativeRetryLargeJson(t *testing.T) {
 		assert.Equal(t, int(p.nbuf.stat.max_depth), 1)
 	})
 }
+
+func TestDecodeBigFloatOutOfRange(t *testing.T) {
+	type bigFloats struct {
+		F *big.Float
+		G big.Float
+		S []big.Float
+		A interface{}
+		X float64
+	}
+	long := "[" + strings.Repeat("1,", 1 << 18) + "1.5e400]"
+	for _, opts := range []uint64{0, 1 << consts.F_collect_errors} {
+		var v bigFloats
+		src := `{"F":1.5e400,"G":-2.5e-400,"A":1,"S":` + long + `}`
+		i := 0
+		require.NoError(t, Decode(&src, &i, opts, &v))
+		assert.Equal(t, "1.5e+400", v.F.Text('g', -1))
+		assert.Equal(t, "-2.5e-400", v.G.Text('g', -1))
+		assert.Equal(t, "1.5e+400", v.S[len(v.S)-1].Text('g', -1))
+		assert.Equal(t, float64(1), v.A)
+
+		/* other types still reject the overflowing floats */
+		for _, src := range []string{`{"F":1e400,"X":1e400}`, `{"F":1e400,"A":-1e400}`} {
+			i = 0
+			err := Decode(&src, &i, opts, &v)
+			require.Error(t, err, src)
+			assert.Contains(t, err.Error(), "float64", src)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package optdec
 import (
 	"encoding/json"
 	"math"
+	"math/big"
+	"strconv"
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/envs"
 	"github.com/bytedance/sonic/internal/rt"
+	"github.com/bytedance/sonic/internal/utils"
 )
 
 type Context struct {
@@ -14,6 +17,15 @@ type Context struct {
 	efacePool   *efacePool
 	Stack       bounedStack
 	Utf8Inv     bool
+	errs        []collectedError
+
+	reportCoercions bool
+	coercions       []coercedValue
+
+	// unionKey is the discriminator key of the union member object at unionObj,
+	// which is not an unknown field of the member
+	unionKey string
+	unionObj uintptr
 }
 
 func (ctx *Context) Options() uint64 {
@@ -111,7 +123,7 @@ func (self *efacePool) ConvTnum(val json.Number, dst unsafe.Pointer) {
 /********************************************************/
 
 func canUseFastMap( opts uint64, root *rt.GoType) bool {
-	return envs.UseFastMap && (opts & (1 << _F_copy_string)) == 0 &&  (opts & (1 << _F_use_int64)) == 0  && (root == rt.AnyType || root == rt.MapEfaceType || root == rt.SliceEfaceType) 
+	return envs.UseFastMap && (opts & (1 << _F_copy_string)) == 0 &&  (opts & (1 << _F_use_int64)) == 0 && (opts & (1 << _F_use_big_int)) == 0 && (root == rt.AnyType || root == rt.MapEfaceType || root == rt.SliceEfaceType) 
 }
 
 func NewContext(json string, pos int, opts uint64, root *rt.GoType) (Context, error) {
@@ -675,9 +687,7 @@ func (node *Node) AsMapString(ctx *Context, vp unsafe.Pointer) error {
 		val := NewNode(PtrOffset(next, 1))
 		m[key], ok = val.AsStr(ctx)
 		if !ok {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.StringType)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.StringType), val)
 			next = val.Next()
 		} else {
 			next = PtrOffset(val.cptr, 1)
@@ -736,9 +746,7 @@ func (node *Node) AsSliceI32(ctx *Context, vp unsafe.Pointer) error {
 		val := NewNode(next)
 		ret, ok := val.AsI64(ctx)
 		if !ok || ret > math.MaxInt32 || ret < math.MinInt32 {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.Int32Type)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.Int32Type), val)
 			next = val.Next()
 		} else {
 			s[i] = int32(ret)
@@ -766,9 +774,7 @@ func (node *Node) AsSliceI64(ctx *Context, vp unsafe.Pointer) error {
 
 		ret, ok := val.AsI64(ctx)
 		if !ok {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.Int64Type)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.Int64Type), val)
 			next = val.Next()
 		} else {
 			s[i] = ret
@@ -795,9 +801,7 @@ func (node *Node) AsSliceU32(ctx *Context, vp unsafe.Pointer) error {
 		val := NewNode(next)
 		ret, ok := val.AsU64(ctx)
 		if !ok ||  ret > math.MaxUint32 {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.Uint32Type)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.Uint32Type), val)
 			next = val.Next()
 		} else {
 			s[i] = uint32(ret)
@@ -824,9 +828,7 @@ func (node *Node) AsSliceU64(ctx *Context, vp unsafe.Pointer) error {
 		val := NewNode(next)
 		ret, ok := val.AsU64(ctx)
 		if !ok {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.Uint64Type)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.Uint64Type), val)
 			next = val.Next()
 		} else {
 			s[i] = ret
@@ -853,9 +855,7 @@ func (node *Node) AsSliceString(ctx *Context, vp unsafe.Pointer) error {
 		val := NewNode(next)
 		ret, ok := val.AsStr(ctx)
 		if !ok {
-			if gerr == nil {
-				gerr = newUnmatched(val.Position(), rt.StringType)
-			}
+			gerr = ctx.collect(gerr, newUnmatched(val.Position(), rt.StringType), val)
 			next = val.Next()
 		} else {
 			s[i] = ret
@@ -1229,13 +1229,22 @@ func (node *Node) AsEfaceFallback(ctx *Context) (interface{}, error) {
 		*node = NewNode(PtrOffset(node.cptr, 1))
 		return nil, nil
 	default:
+		// keep the integers overflowing int64
+		if ctx.Parser.options & (1 << _F_use_big_int) != 0 {
+			if v, ok := node.asBigInt(ctx); ok {
+				*node = NewNode(PtrOffset(node.cptr, 1))
+				return v, nil
+			}
+		}
+
 		// use float64
 		if ctx.Parser.options & (1 << _F_use_number) != 0 {
 			num, ok := node.AsNumber(ctx)
 			if !ok {
 				// skip the unmacthed type
+				pos := node.Position()
 				*node = NewNode(node.Next())
-				return nil, newUnmatched(node.Position(), rt.JsonNumberType)
+				return nil, newUnmatched(pos, rt.JsonNumberType)
 			} else {
 				*node = NewNode(PtrOffset(node.cptr, 1))
 				return num, nil
@@ -1256,14 +1265,16 @@ func (node *Node) AsEfaceFallback(ctx *Context) (interface{}, error) {
 			}
 		
 			// skip the unmacthed type
+			pos := node.Position()
 			*node = NewNode(node.Next())
-			return nil, newUnmatched(node.Position(), rt.Int64Type)
+			return nil, newUnmatched(pos, rt.Int64Type)
 		} else {
 			num, ok := node.AsF64(ctx)
 			if !ok {
 				// skip the unmacthed type
+				pos := node.Position()
 				*node = NewNode(node.Next())
-				return nil, newUnmatched(node.Position(), rt.Float64Type)
+				return nil, newUnmatched(pos, rt.Float64Type)
 			} else {
 				*node = NewNode(PtrOffset(node.cptr, 1))
 				return num, nil
@@ -1272,6 +1283,19 @@ func (node *Node) AsEfaceFallback(ctx *Context) (interface{}, error) {
 	}
 }
 
+// asBigInt returns the integer node as a *big.Int, if it overflows int64.
+func (val Node) asBigInt(ctx *Context) (*big.Int, bool) {
+	num, ok := val.NonstrAsNumber(ctx)
+	if !ok || !utils.IsInteger(string(num)) {
+		return nil, false
+	}
+	if _, err := strconv.ParseInt(string(num), 10, 64); err == nil {
+		return nil, false
+	}
+	ret := new(big.Int)
+	return ret, utils.SetBigInt(ret, string(num))
+}
+
 //go:nosplit
 func PtrOffset(ptr uintptr, off int64) uintptr {
 	return uintptr(int64(ptr) + off * int64(unsafe.Sizeof(node{})))
@@ -0,0 +1,161 @@
+package optdec
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/bytedance/sonic/internal/decoder/consts"
+	caching "github.com/bytedance/sonic/internal/optcaching"
+	"github.com/bytedance/sonic/internal/resolver"
+)
+
+type FieldState = consts.FieldState
+
+// presenceFields is the field matching table of a struct, the same one
+// as its decoder uses, along with the Go names of the fields.
+type presenceFields struct {
+	lookup caching.FieldLookup
+	names  []string
+	types  []reflect.Type
+}
+
+var presenceCache sync.Map
+
+func findPresenceFields(vt reflect.Type) *presenceFields {
+	if v, ok := presenceCache.Load(vt); ok {
+		return v.(*presenceFields)
+	}
+
+	fv := resolver.ResolveStruct(vt)
+	ix, _ := resolver.InlineField(vt, fv)
+	pf := &presenceFields{}
+	fields := make([]resolver.FieldMeta, 0, len(fv))
+	for i, f := range fv {
+		/* the catch-all field is never matched by name */
+		if i == ix {
+			continue
+		}
+		name := f.Name
+		if sf, ok := resolver.FieldByName(vt, f.Name); ok {
+			name = sf.Name
+		}
+		fields = append(fields, f)
+		pf.names = append(pf.names, name)
+		pf.types = append(pf.types, f.Type)
+	}
+	pf.lookup = caching.NewFieldLookup(fields)
+
+	v, _ := presenceCache.LoadOrStore(vt, pf)
+	return v.(*presenceFields)
+}
+
+// presence records the state of each struct field and unknown key of the document
+// by its Go path, walking the document along the type it was decoded into.
+type presence struct {
+	ctx *Context
+	out map[string]FieldState
+}
+
+func (self *presence) walk(vt reflect.Type, node Node, path string) {
+	if node.IsNull() {
+		return
+	}
+	for vt.Kind() == reflect.Ptr || resolver.IsOptional(vt) {
+		if vt.Kind() == reflect.Ptr {
+			vt = vt.Elem()
+		} else {
+			vt, _ = resolver.OptionalValue(vt)
+		}
+	}
+
+	/* unmarshalers own their values entirely */
+	pt := reflect.PtrTo(vt)
+	if pt.Implements(jsonUnmarshalerType) || pt.Implements(encodingTextUnmarshalerType) {
+		return
+	}
+
+	switch vt.Kind() {
+	case reflect.Struct:
+		self.walkStruct(vt, node, path)
+	case reflect.Slice, reflect.Array:
+		self.walkArray(vt, node, path)
+	case reflect.Map:
+		self.walkMap(vt, node, path)
+	}
+}
+
+func (self *presence) mark(path string, node Node) {
+	if node.IsNull() {
+		self.out[path] = consts.FieldNull
+	} else {
+		self.out[path] = consts.FieldPresent
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (self *presence) walkStruct(vt reflect.Type, node Node, path string) {
+	obj, ok := node.AsObj()
+	if !ok {
+		return
+	}
+
+	pf := findPresenceFields(vt)
+	cs := self.ctx.Options()&uint64(consts.OptionCaseSensitive) != 0
+	next := obj.Children()
+	for i := 0; i < obj.Len(); i++ {
+		kn := NewNode(next)
+		key, _ := kn.AsStr(self.ctx)
+		val := NewNode(PtrOffset(next, 1))
+		next = val.Next()
+
+		if idx := pf.lookup.Get(key, cs); idx == -1 {
+			self.out[joinPath(path, key)] = consts.FieldUnknown
+		} else {
+			fp := joinPath(path, pf.names[idx])
+			self.mark(fp, val)
+			self.walk(pf.types[idx], val, fp)
+		}
+	}
+}
+
+func (self *presence) walkArray(vt reflect.Type, node Node, path string) {
+	arr, ok := node.AsArr()
+	if !ok {
+		return
+	}
+
+	/* the rest of the elements are dropped for arrays */
+	n := arr.Len()
+	if vt.Kind() == reflect.Array && vt.Len() < n {
+		n = vt.Len()
+	}
+	next := arr.Children()
+	for i := 0; i < n; i++ {
+		val := NewNode(next)
+		next = val.Next()
+		self.walk(vt.Elem(), val, path+"["+strconv.Itoa(i)+"]")
+	}
+}
+
+func (self *presence) walkMap(vt reflect.Type, node Node, path string) {
+	obj, ok := node.AsObj()
+	if !ok {
+		return
+	}
+
+	next := obj.Children()
+	for i := 0; i < obj.Len(); i++ {
+		kn := NewNode(next)
+		key, _ := kn.AsStr(self.ctx)
+		val := NewNode(PtrOffset(next, 1))
+		next = val.Next()
+		self.walk(vt.Elem(), val, path+"["+strconv.Quote(key)+"]")
+	}
+}
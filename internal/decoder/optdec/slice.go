@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"unsafe"
 
+	"github.com/bytedance/sonic/internal/decoder/consts"
 	"github.com/bytedance/sonic/internal/rt"
 )
 
@@ -24,6 +25,9 @@ func (d *sliceDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error
 	}
 
 	arr, ok := node.AsArr()
+	if !ok && ctx.coercing(consts.CoerceSingleToSlice) {
+		return d.fromSingle(vp, node, ctx)
+	}
 	if !ok {
 		return error_mismatch(node, ctx, d.typ)
 	}
@@ -37,9 +41,7 @@ func (d *sliceDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error
 		val := NewNode(next)
 		elem := unsafe.Pointer(uintptr(elems) + uintptr(i)*d.elemType.Size)
 		err := d.elemDec.FromDom(elem, val, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, val)
 		next = val.Next()
 	}
 
@@ -47,6 +49,17 @@ func (d *sliceDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error
 	return gerr
 }
 
+// fromSingle decodes a value that is not an array as the only element.
+func (d *sliceDecoder) fromSingle(vp unsafe.Pointer, node Node, ctx *context) error {
+	ctx.coerced(node, consts.CoerceSingleToSlice, d.typ)
+	slice := rt.MakeSlice(vp, d.elemType, 1)
+	if err := d.elemDec.FromDom(slice.Ptr, node, ctx); err != nil {
+		return err
+	}
+	*(*rt.GoSlice)(vp) = *slice
+	return nil
+}
+
 type arrayDecoder struct {
 	len      int
 	elemType *rt.GoType
@@ -73,9 +86,7 @@ func (d *arrayDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error
 		elem := unsafe.Pointer(uintptr(vp) + uintptr(i)*d.elemType.Size)
 		val := NewNode(next)
 		err := d.elemDec.FromDom(elem, val, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, val)
 		next = val.Next()
 	}
 
@@ -213,9 +224,7 @@ func (d *sliceBytesUnmarshalerDecoder) FromDom(vp unsafe.Pointer, node Node, ctx
 		child := NewNode(next)
 		elem := unsafe.Pointer(uintptr(elems) + uintptr(i)*d.elemType.Size)
 		err := d.elemDec.FromDom(elem, child, ctx)
-		if gerr == nil && err != nil {
-			gerr = err
-		}
+		gerr = ctx.collect(gerr, err, child)
 		next = child.Next()
 	}
 
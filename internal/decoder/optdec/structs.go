@@ -5,18 +5,23 @@ import (
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/decoder/consts"
+	"github.com/bytedance/sonic/internal/decoder/errors"
 	caching "github.com/bytedance/sonic/internal/optcaching"
 	"github.com/bytedance/sonic/internal/resolver"
+	"github.com/bytedance/sonic/internal/rt"
 )
 
 type fieldEntry struct {
 	resolver.FieldMeta
 	fieldDec decFunc
+	seen     uint64
 }
 
 type structDecoder struct {
 	fieldMap   caching.FieldLookup
 	fields     []fieldEntry
+	inline     *inlineDecoder
+	checked    []resolver.FieldMeta
 	structName string
 	typ        reflect.Type
 }
@@ -27,6 +32,7 @@ func (d *structDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) erro
 	}
 
 	var gerr error
+	var seen uint64
 	obj, ok := node.AsObj()
 	if !ok {
 		return error_mismatch(node, ctx, d.typ)
@@ -34,15 +40,24 @@ func (d *structDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) erro
 
 	next := obj.Children()
 	for i := 0; i < obj.Len(); i++ {
-		key, _ := NewNode(next).AsStrRef(ctx)
+		kn := NewNode(next)
+		key, _ := kn.AsStrRef(ctx)
 		val := NewNode(PtrOffset(next, 1))
 		next = val.Next()
 
 		// find field idx
 		idx := d.fieldMap.Get(key, ctx.Options()&uint64(consts.OptionCaseSensitive) != 0)
         if idx == -1 {
-            if Options(ctx.Options())&OptionDisableUnknown != 0 {
-                return error_field(key)
+			if d.inline != nil {
+				err := d.inline.FromDom(vp, val, kn, ctx)
+				gerr = ctx.collect(gerr, err, val)
+				continue
+			}
+            if Options(ctx.Options())&OptionDisableUnknown != 0 && !ctx.isUnionKey(node, key) {
+                if !ctx.collecting() {
+                    return error_field(key)
+                }
+                gerr = ctx.collect(gerr, error_field(key), val)
             }
             continue
         }
@@ -50,13 +65,121 @@ func (d *structDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) erro
 		offset := d.fields[idx].Path[0].Size
 		elem := unsafe.Pointer(uintptr(vp) + offset)
 		err := d.fields[idx].fieldDec.FromDom(elem, val, ctx)
+		seen |= d.fields[idx].seen
 
 		// deal with mismatch type errors
-		if gerr == nil && err != nil {
-			// TODO: better error info
-			gerr = err
+		gerr = ctx.collect(gerr, err, val)
+	}
+
+	if d.checked != nil {
+		err := d.check(vp, node, seen, ctx)
+		gerr = ctx.collect(gerr, err, node)
+	}
+	return gerr
+}
+
+// check fills the absent fields with their default values, or reports
+// the first absent `required` one.
+func (d *structDecoder) check(vp unsafe.Pointer, node Node, seen uint64, ctx *context) error {
+	for b := range d.checked {
+		f := &d.checked[b]
+		if seen&(1<<uint(b)) != 0 {
+			continue
+		}
+		if f.Opts&resolver.F_required != 0 {
+			end := node.Position() + len(node.AsRaw(ctx))
+			return errors.ErrorMissingField(ctx.Parser.Json, end, d.typ, f.Name)
+		}
+
+		/* seek into the field, allocating embedded pointers */
+		fp := vp
+		for _, o := range f.Path {
+			fp = unsafe.Pointer(uintptr(fp) + o.Size)
+			if o.Kind == resolver.F_deref {
+				deref := rt.UnpackType(o.Type)
+				if *(*unsafe.Pointer)(fp) == nil {
+					*(*unsafe.Pointer)(fp) = rt.Mallocgc(deref.Size, deref, true)
+				}
+				fp = *(*unsafe.Pointer)(fp)
+			}
+		}
+
+		/* decode the default value with the same options */
+		lit, pos := f.Default, 0
+		opts := ctx.Options() &^ (1 << consts.F_collect_errors)
+		if err := Decode(&lit, &pos, opts, reflect.NewAt(f.Type, fp).Interface()); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// structArrayDecoder decodes the items of an array into the fields of a
+// `json:",array"` struct by their positions, the extra items are ignored
+// and the fields without items are left as they are.
+type structArrayDecoder struct {
+	fields []fieldEntry
+	typ    reflect.Type
+}
+
+func (d *structArrayDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if node.IsNull() {
+		return nil
+	}
+
+	arr, ok := node.AsArr()
+	if !ok {
+		return error_mismatch(node, ctx, d.typ)
+	}
+
+	var gerr error
+	next := arr.Children()
+	for i := 0; i < len(d.fields) && i < arr.Len(); i++ {
+		val := NewNode(next)
+		elem := unsafe.Pointer(uintptr(vp) + d.fields[i].Path[0].Size)
+		err := d.fields[i].fieldDec.FromDom(elem, val, ctx)
+		gerr = ctx.collect(gerr, err, val)
+		next = val.Next()
+	}
 	return gerr
 }
 
+// inlineDecoder stores the unknown keys of a struct into its `json:",inline"` catch-all field
+type inlineDecoder struct {
+	field   resolver.FieldMeta
+	elemDec decFunc
+	setter  resolver.InlineSetter
+}
+
+func (d *inlineDecoder) FromDom(vp unsafe.Pointer, val Node, kn Node, ctx *context) error {
+	key, _ := kn.AsStr(ctx)
+
+	/* seek into the field, allocating embedded pointers */
+	for _, f := range d.field.Path {
+		vp = unsafe.Pointer(uintptr(vp) + f.Size)
+		if f.Kind == resolver.F_deref {
+			deref := rt.UnpackType(f.Type)
+			if *(*unsafe.Pointer)(vp) == nil {
+				*(*unsafe.Pointer)(vp) = rt.Mallocgc(deref.Size, deref, true)
+			}
+			vp = *(*unsafe.Pointer)(vp)
+		}
+	}
+
+	/* non-map catch-alls have their own setters */
+	if d.setter != nil {
+		return d.setter(vp, key, val.AsRaw(ctx))
+	}
+
+	mt := d.field.Type
+	mv := reflect.NewAt(mt, vp).Elem()
+	if mv.IsNil() {
+		mv.Set(reflect.MakeMap(mt))
+	}
+	ev := reflect.New(mt.Elem())
+	if err := d.elemDec.FromDom(unsafe.Pointer(ev.Pointer()), val, ctx); err != nil {
+		return err
+	}
+	mv.SetMapIndex(reflect.ValueOf(key).Convert(mt.Key()), ev.Elem())
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"reflect"
+	"time"
 	"unsafe"
 
 	"github.com/bytedance/sonic/internal/rt"
@@ -44,6 +45,8 @@ var (
 	stringType              = reflect.TypeOf("")
 	bytesType               = reflect.TypeOf([]byte(nil))
 	jsonNumberType          = reflect.TypeOf(json.Number(""))
+	timeType                = reflect.TypeOf(time.Time{})
+	durationType            = reflect.TypeOf(time.Duration(0))
 	base64CorruptInputError = reflect.TypeOf(base64.CorruptInputError(0))
 	anyType                 = rt.UnpackType(reflect.TypeOf((*interface{})(nil)).Elem())
 )
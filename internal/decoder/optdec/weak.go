@@ -0,0 +1,212 @@
+package optdec
+
+import (
+	"math"
+	"reflect"
+	"unsafe"
+
+	"github.com/bytedance/sonic/internal/decoder/consts"
+	derrors "github.com/bytedance/sonic/internal/decoder/errors"
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+type Coercion = consts.Coercion
+
+/** Weakly Typed Helpers **/
+
+// coercedValue is a coercion recorded at the position relative to the parser.
+type coercedValue struct {
+	pos  int
+	kind consts.CoercionKind
+	typ  reflect.Type
+}
+
+// weakOptions are the options that need the decoders to coerce values.
+const weakOptions = uint64(consts.OptionWeaklyTyped)
+
+func (ctx *context) coercing(kind consts.CoercionKind) bool {
+	return ctx.Options()&uint64(kind.Option()) != 0
+}
+
+// coerced records the coercion of node into typ, if they are reported.
+func (ctx *context) coerced(node Node, kind consts.CoercionKind, typ reflect.Type) {
+	if !ctx.reportCoercions {
+		return
+	}
+	pos := node.Position()
+	if node.IsStr() {
+		pos -= 1 // points to the opening quote
+	}
+	ctx.coercions = append(ctx.coercions, coercedValue{pos, kind, typ})
+}
+
+func coercions(ctx *context, src string, start int) []Coercion {
+	ret := make([]Coercion, 0, len(ctx.coercions))
+	for _, c := range ctx.coercions {
+		ret = append(ret, Coercion{
+			Kind: c.kind,
+			Path: derrors.JSONPath(src[start:], c.pos),
+			Pos:  start + c.pos,
+			Type: c.typ,
+		})
+	}
+	return ret
+}
+
+func (val Node) isNumeric() bool {
+	return val.Type()&7 == KNumber
+}
+
+// integral tells whether f is an integer within [min, max].
+func integral(f float64, min float64, max float64) bool {
+	return f == math.Trunc(f) && f >= min && f < max+1
+}
+
+// weakInt coerces the node that is not a plain integer into one within [min, max].
+func weakInt(node Node, ctx *context, min int64, max int64, typ reflect.Type) (int64, bool) {
+	if node.IsStr() && ctx.coercing(consts.CoerceStringToNumber) {
+		s, _ := node.AsStrRef(ctx)
+		if !ValidNumberFast(s) {
+			return 0, false
+		}
+		v, err := ParseI64(s)
+		if err != nil {
+			f, ferr := ParseF64(s)
+			if ferr != nil || !ctx.coercing(consts.CoerceFloatToInt) || !integral(f, float64(min), float64(max)) {
+				return 0, false
+			}
+			v = int64(f)
+		}
+		if v < min || v > max {
+			return 0, false
+		}
+		ctx.coerced(node, consts.CoerceStringToNumber, typ)
+		return v, true
+	}
+
+	if node.isNumeric() && ctx.coercing(consts.CoerceFloatToInt) {
+		f, ok := node.AsF64(ctx)
+		if !ok || !integral(f, float64(min), float64(max)) {
+			return 0, false
+		}
+		ctx.coerced(node, consts.CoerceFloatToInt, typ)
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// weakUint coerces the node that is not a plain unsigned integer into one within [0, max].
+func weakUint(node Node, ctx *context, max uint64, typ reflect.Type) (uint64, bool) {
+	if node.IsStr() && ctx.coercing(consts.CoerceStringToNumber) {
+		s, _ := node.AsStrRef(ctx)
+		if !ValidNumberFast(s) {
+			return 0, false
+		}
+		v, err := ParseU64(s)
+		if err != nil {
+			f, ferr := ParseF64(s)
+			if ferr != nil || !ctx.coercing(consts.CoerceFloatToInt) || !integral(f, 0, float64(max)) {
+				return 0, false
+			}
+			v = uint64(f)
+		}
+		if v > max {
+			return 0, false
+		}
+		ctx.coerced(node, consts.CoerceStringToNumber, typ)
+		return v, true
+	}
+
+	if node.isNumeric() && ctx.coercing(consts.CoerceFloatToInt) {
+		f, ok := node.AsF64(ctx)
+		if !ok || !integral(f, 0, float64(max)) {
+			return 0, false
+		}
+		ctx.coerced(node, consts.CoerceFloatToInt, typ)
+		return uint64(f), true
+	}
+	return 0, false
+}
+
+// weakFloat coerces a string node into a float.
+func weakFloat(node Node, ctx *context, typ reflect.Type) (float64, bool) {
+	if !node.IsStr() || !ctx.coercing(consts.CoerceStringToNumber) {
+		return 0, false
+	}
+	s, _ := node.AsStrRef(ctx)
+	if !ValidNumberFast(s) {
+		return 0, false
+	}
+	f, err := ParseF64(s)
+	if err != nil {
+		return 0, false
+	}
+	ctx.coerced(node, consts.CoerceStringToNumber, typ)
+	return f, true
+}
+
+// weakBool coerces `1`, `0`, `"true"` and `"false"` into a bool.
+func weakBool(node Node, ctx *context) (bool, bool) {
+	if !ctx.coercing(consts.CoerceBool) {
+		return false, false
+	}
+
+	var ret bool
+	if node.IsStr() {
+		s, _ := node.AsStrRef(ctx)
+		switch s {
+		case "true":
+			ret = true
+		case "false":
+			ret = false
+		default:
+			return false, false
+		}
+	} else if v, ok := node.AsU64(ctx); ok && v <= 1 {
+		ret = v == 1
+	} else {
+		return false, false
+	}
+
+	ctx.coerced(node, consts.CoerceBool, boolType)
+	return ret, true
+}
+
+// weakString coerces a number node into its text.
+func weakString(node Node, ctx *context) (string, bool) {
+	if !node.isNumeric() || !ctx.coercing(consts.CoerceNumberToString) {
+		return "", false
+	}
+	num, ok := node.NonstrAsNumber(ctx)
+	if !ok {
+		return "", false
+	}
+	ctx.coerced(node, consts.CoerceNumberToString, stringType)
+	return string(num), true
+}
+
+// weakNil tells whether the node is an empty string to be decoded as a nil pointer to et.
+func weakNil(node Node, ctx *context, et *rt.GoType) bool {
+	if et.Kind() == reflect.String || !ctx.coercing(consts.CoerceEmptyToNil) {
+		return false
+	}
+	if s, ok := node.AsStrRef(ctx); !ok || s != "" {
+		return false
+	}
+	ctx.coerced(node, consts.CoerceEmptyToNil, reflect.PtrTo(et.Pack()))
+	return true
+}
+
+// weakDecoder switches to the generic decoder in weakly typed modes and with
+// OptionQuotedInt, since the fast one decodes the elements without coercions.
+type weakDecoder struct {
+	fast decFunc
+	slow decFunc
+}
+
+func (d *weakDecoder) FromDom(vp unsafe.Pointer, node Node, ctx *context) error {
+	if ctx.Options()&(weakOptions|1<<_F_quoted_int) != 0 {
+		return d.slow.FromDom(vp, node, ctx)
+	}
+	return d.fast.FromDom(vp, node, ctx)
+}
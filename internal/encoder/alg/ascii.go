@@ -0,0 +1,146 @@
+/**
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+const _hexDigits = "0123456789abcdef"
+
+// QuoteOpt quotes val like Quote, with every non-ASCII character escaped if
+// EscapeNonASCII is set in opt.
+func QuoteOpt(buf []byte, val string, double bool, opt uint64) []byte {
+	if opt&(1<<BitEscapeNonASCII) != 0 {
+		return QuoteASCII(buf, val, double)
+	}
+	return Quote(buf, val, double)
+}
+
+// EncodeString writes the string at vp in the quoting options of opt, for the
+// strings that the native quoter cannot write.
+func EncodeString(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	*buf = QuoteOpt(*buf, *(*string)(vp), false, opt)
+	return nil
+}
+
+// EncodeQuotedString is like EncodeString, but quotes the string twice for the `string` option.
+func EncodeQuotedString(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	*buf = QuoteOpt(*buf, *(*string)(vp), true, opt)
+	return nil
+}
+
+// QuoteASCII quotes val with the escapes of Quote, and every non-ASCII character
+// changed to \uXXXX, or a surrogate pair of them beyond the BMP. The bytes of
+// invalid UTF-8 are changed to \ufffd. If double is true, the quoted string is
+// quoted again, as the string option does.
+func QuoteASCII(buf []byte, val string, double bool) []byte {
+	if double {
+		buf = append(buf, '"', '\\', '"')
+	} else {
+		buf = append(buf, '"')
+	}
+	start := 0
+	for i := 0; i < len(val); {
+		c := val[i]
+		if c < utf8.RuneSelf && rt.SafeSet[c] {
+			i++
+			continue
+		}
+		buf = append(buf, val[start:i]...)
+		if c < utf8.RuneSelf {
+			buf = appendEscape(buf, c, double)
+			i++
+		} else {
+			r, n := utf8.DecodeRuneInString(val[i:])
+			buf = appendEscapedRune(buf, r, double)
+			i += n
+		}
+		start = i
+	}
+	buf = append(buf, val[start:]...)
+	if double {
+		return append(buf, '\\', '"', '"')
+	}
+	return append(buf, '"')
+}
+
+// ASCIIEscape appends to dst the JSON value src with every non-ASCII character
+// changed like QuoteASCII, for the outputs of json.Marshaler, whose non-ASCII
+// bytes can only appear inside the string literals of a valid JSON.
+func ASCIIEscape(dst []byte, src []byte) []byte {
+	start := 0
+	for i := 0; i < len(src); {
+		if src[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		dst = append(dst, src[start:i]...)
+		r, n := utf8.DecodeRune(src[i:])
+		dst = appendEscapedRune(dst, r, false)
+		i += n
+		start = i
+	}
+	return append(dst, src[start:]...)
+}
+
+func appendEscape(dst []byte, c byte, double bool) []byte {
+	if double {
+		dst = append(dst, '\\')
+	}
+	switch c {
+	case '"', '\\':
+		if double {
+			return append(dst, '\\', '\\', c)
+		}
+		return append(dst, '\\', c)
+	case '\n':
+		return append(dst, '\\', 'n')
+	case '\r':
+		return append(dst, '\\', 'r')
+	case '\t':
+		return append(dst, '\\', 't')
+	default:
+		return appendUnicode(dst, rune(c))
+	}
+}
+
+func appendEscapedRune(dst []byte, r rune, double bool) []byte {
+	r1, r2 := utf16.EncodeRune(r)
+	if r1 == utf8.RuneError {
+		r1 = r
+	} else {
+		if double {
+			dst = append(dst, '\\')
+		}
+		dst = appendUnicode(dst, r1)
+		r1 = r2
+	}
+	if double {
+		dst = append(dst, '\\')
+	}
+	return appendUnicode(dst, r1)
+}
+
+func appendUnicode(dst []byte, r rune) []byte {
+	return append(dst, '\\', 'u',
+		_hexDigits[r>>12&0xf], _hexDigits[r>>8&0xf], _hexDigits[r>>4&0xf], _hexDigits[r&0xf])
+}
@@ -0,0 +1,542 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+// errUnsorted stops rewriting the text at the first object with unsorted keys.
+var errUnsorted = errors.New("unsorted keys")
+
+// Canonicalize appends to dst the JSON value src in the JSON Canonicalization Scheme
+// of RFC 8785: no whitespace, object keys sorted by their UTF-16 code units, numbers
+// in the ES6 notation of IEEE doubles, and strings with only the mandatory escapes.
+//
+// Strings must be valid UTF-8 without lone surrogates, as required by I-JSON. Their
+// non-ASCII characters are escaped if EscapeNonASCII is set in opt.
+//
+// The encoder writes the keys sorted under Canonical, so src is rewritten to dst as it
+// is read, and parsed into a tree only if some object, like the output of a marshaler,
+// has unsorted keys. The memory of dst must not overlap src.
+func Canonicalize(dst []byte, src []byte, opt uint64) ([]byte, error) {
+	c := canonicalizer{src: src, ascii: opt&(1<<BitEscapeNonASCII) != 0}
+	ret, err := c.rewrite(dst, 0)
+	if err == nil {
+		if c.skip(); c.pos != len(src) {
+			return dst, c.error("trailing characters")
+		}
+		return ret, nil
+	} else if err != errUnsorted {
+		return dst, err
+	}
+
+	/* sort the objects in the tree */
+	c.pos = 0
+	v, err := c.value()
+	if err != nil {
+		return dst, err
+	}
+	if c.skip(); c.pos != len(src) {
+		return dst, c.error("trailing characters")
+	}
+	return c.emit(dst, &v), nil
+}
+
+// canonicalizer parses the values into a tree, whose members of objects are
+// sorted by keys, and the texts of the scalars are appended to buf in the input
+// order, so that every byte is copied only once when the tree is written out.
+// The last keys of the objects being rewritten are kept in keys by depth.
+type canonicalizer struct {
+	src   []byte
+	pos   int
+	str   []byte
+	buf   []byte
+	stk   []canonicalValue
+	keys  [][]byte
+	ascii bool
+}
+
+// CanonicalOrder returns the indices of keys in the order of their UTF-16 code units,
+// or nil if the keys are in order already.
+func CanonicalOrder(keys []string) []int {
+	if sort.SliceIsSorted(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) }) {
+		return nil
+	}
+	ret := make([]int, len(keys))
+	for i := range ret {
+		ret[i] = i
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return lessUTF16(keys[ret[i]], keys[ret[j]]) })
+	return ret
+}
+
+// canonicalValue is either the text buf[from:to] of a scalar or an array of
+// scalars, or the items of an array, or the sorted members of an object.
+type canonicalValue struct {
+	from    int
+	to      int
+	items   []canonicalValue
+	members []canonicalMember
+}
+
+type canonicalMember struct {
+	key string
+	pos int
+	val canonicalValue
+}
+
+func (self *canonicalizer) error(msg string) error {
+	return vars.Error_canonical(self.pos, msg)
+}
+
+func (self *canonicalizer) skip() {
+	for self.pos < len(self.src) {
+		switch self.src[self.pos] {
+		case ' ', '\t', '\n', '\r':
+			self.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (self *canonicalizer) next(c byte) bool {
+	if self.skip(); self.pos < len(self.src) && self.src[self.pos] == c {
+		self.pos++
+		return true
+	}
+	return false
+}
+
+func (self *canonicalizer) value() (canonicalValue, error) {
+	if self.skip(); self.pos == len(self.src) {
+		return canonicalValue{}, self.error("unexpected end of input")
+	}
+
+	var err error
+	v := canonicalValue{from: len(self.buf)}
+	switch c := self.src[self.pos]; {
+	case c == '{':
+		return self.object()
+	case c == '[':
+		return self.array()
+	case c == '"':
+		s, err := self.string()
+		if err != nil {
+			return v, err
+		}
+		self.buf = appendCanonicalString(self.buf, s, self.ascii)
+	case c == '-' || c >= '0' && c <= '9':
+		if self.buf, err = self.number(self.buf); err != nil {
+			return v, err
+		}
+	default:
+		if self.buf, err = self.literal(self.buf); err != nil {
+			return v, err
+		}
+	}
+	v.to = len(self.buf)
+	return v, nil
+}
+
+// literal copies the literals.
+func (self *canonicalizer) literal(dst []byte) ([]byte, error) {
+	for _, lit := range []string{"true", "false", "null"} {
+		if end := self.pos + len(lit); end <= len(self.src) && string(self.src[self.pos:end]) == lit {
+			self.pos = end
+			return append(dst, lit...), nil
+		}
+	}
+	return dst, self.error("invalid character")
+}
+
+// rewrite writes the value to dst as it is read, with the numbers and strings in the
+// canonical form, and returns errUnsorted at the first object with unsorted keys.
+func (self *canonicalizer) rewrite(dst []byte, depth int) ([]byte, error) {
+	if self.skip(); self.pos == len(self.src) {
+		return dst, self.error("unexpected end of input")
+	}
+	switch c := self.src[self.pos]; {
+	case c == '{':
+		return self.rewriteObject(dst, depth)
+	case c == '[':
+		return self.rewriteArray(dst, depth)
+	case c == '"':
+		s, err := self.string()
+		if err != nil {
+			return dst, err
+		}
+		return appendCanonicalString(dst, s, self.ascii), nil
+	case c == '-' || c >= '0' && c <= '9':
+		return self.number(dst)
+	default:
+		return self.literal(dst)
+	}
+}
+
+func (self *canonicalizer) rewriteObject(dst []byte, depth int) ([]byte, error) {
+	var err error
+	var key []byte
+
+	self.pos++
+	dst = append(dst, '{')
+	if self.next('}') {
+		return append(dst, '}'), nil
+	}
+	for len(self.keys) <= depth {
+		self.keys = append(self.keys, nil)
+	}
+	for i := 0; ; i++ {
+		if self.skip(); self.pos == len(self.src) || self.src[self.pos] != '"' {
+			return dst, self.error("expect a key")
+		}
+		pos := self.pos
+		if key, err = self.string(); err != nil {
+			return dst, err
+		}
+
+		/* each key must be greater than the last one */
+		if i != 0 {
+			last := self.keys[depth]
+			if string(key) == string(last) {
+				self.pos = pos
+				return dst, self.error("duplicate key")
+			} else if lessUTF16(rt.Mem2Str(key), rt.Mem2Str(last)) {
+				return dst, errUnsorted
+			}
+			dst = append(dst, ',')
+		}
+		self.keys[depth] = append(self.keys[depth][:0], key...)
+
+		if !self.next(':') {
+			return dst, self.error("expect ':'")
+		}
+		dst = appendCanonicalString(dst, key, self.ascii)
+		dst = append(dst, ':')
+		if dst, err = self.rewrite(dst, depth+1); err != nil {
+			return dst, err
+		}
+		if self.next(',') {
+			continue
+		} else if self.next('}') {
+			return append(dst, '}'), nil
+		}
+		return dst, self.error("expect ',' or '}'")
+	}
+}
+
+func (self *canonicalizer) rewriteArray(dst []byte, depth int) ([]byte, error) {
+	var err error
+
+	self.pos++
+	dst = append(dst, '[')
+	if self.next(']') {
+		return append(dst, ']'), nil
+	}
+	for {
+		if dst, err = self.rewrite(dst, depth+1); err != nil {
+			return dst, err
+		}
+		if self.next(',') {
+			dst = append(dst, ',')
+		} else if self.next(']') {
+			return append(dst, ']'), nil
+		} else {
+			return dst, self.error("expect ',' or ']'")
+		}
+	}
+}
+
+func (self *canonicalizer) object() (canonicalValue, error) {
+	var err error
+	var key []byte
+	var ms []canonicalMember
+
+	self.pos++
+	if self.next('}') {
+		self.buf = append(self.buf, '{', '}')
+		return canonicalValue{from: len(self.buf) - 2, to: len(self.buf)}, nil
+	}
+	for {
+		if self.skip(); self.pos == len(self.src) || self.src[self.pos] != '"' {
+			return canonicalValue{}, self.error("expect a key")
+		}
+		m := canonicalMember{pos: self.pos}
+		if key, err = self.string(); err != nil {
+			return canonicalValue{}, err
+		}
+		if !self.next(':') {
+			return canonicalValue{}, self.error("expect ':'")
+		}
+		m.key = string(key)
+		if m.val, err = self.value(); err != nil {
+			return canonicalValue{}, err
+		}
+		ms = append(ms, m)
+		if self.next(',') {
+			continue
+		} else if self.next('}') {
+			break
+		}
+		return canonicalValue{}, self.error("expect ',' or '}'")
+	}
+
+	/* the members are written in the order of keys, which must be unique */
+	sort.SliceStable(ms, func(i, j int) bool { return lessUTF16(ms[i].key, ms[j].key) })
+	for i := 1; i < len(ms); i++ {
+		if ms[i].key == ms[i-1].key {
+			self.pos = ms[i].pos
+			return canonicalValue{}, self.error("duplicate key")
+		}
+	}
+	return canonicalValue{members: ms}, nil
+}
+
+func (self *canonicalizer) array() (canonicalValue, error) {
+	self.pos++
+	v := canonicalValue{from: len(self.buf)}
+	self.buf = append(self.buf, '[')
+	if self.next(']') {
+		self.buf = append(self.buf, ']')
+		v.to = len(self.buf)
+		return v, nil
+	}
+
+	/* the items are kept only if some of them are objects */
+	flat, base := true, len(self.stk)
+	for {
+		e, err := self.value()
+		if err != nil {
+			self.stk = self.stk[:base]
+			return v, err
+		}
+		flat = flat && e.items == nil && e.members == nil
+		self.stk = append(self.stk, e)
+		if self.next(',') {
+			self.buf = append(self.buf, ',')
+		} else if self.next(']') {
+			break
+		} else {
+			self.stk = self.stk[:base]
+			return v, self.error("expect ',' or ']'")
+		}
+	}
+	if self.buf = append(self.buf, ']'); flat {
+		v.to = len(self.buf)
+	} else {
+		v.items = append([]canonicalValue(nil), self.stk[base:]...)
+	}
+	self.stk = self.stk[:base]
+	return v, nil
+}
+
+// emit writes the value v out.
+func (self *canonicalizer) emit(dst []byte, v *canonicalValue) []byte {
+	switch {
+	case v.members != nil:
+		dst = append(dst, '{')
+		for i := range v.members {
+			if i != 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendCanonicalString(dst, []byte(v.members[i].key), self.ascii)
+			dst = append(dst, ':')
+			dst = self.emit(dst, &v.members[i].val)
+		}
+		return append(dst, '}')
+	case v.items != nil:
+		dst = append(dst, '[')
+		for i := range v.items {
+			if i != 0 {
+				dst = append(dst, ',')
+			}
+			dst = self.emit(dst, &v.items[i])
+		}
+		return append(dst, ']')
+	default:
+		return append(dst, self.buf[v.from:v.to]...)
+	}
+}
+
+func (self *canonicalizer) number(dst []byte) ([]byte, error) {
+	start := self.pos
+	for self.pos < len(self.src) {
+		if c := self.src[self.pos]; c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.' || c == 'e' || c == 'E' {
+			self.pos++
+		} else {
+			break
+		}
+	}
+
+	/* every number is an IEEE double, and the zeros are all `0` */
+	v, err := strconv.ParseFloat(string(self.src[start:self.pos]), 64)
+	if err != nil {
+		self.pos = start
+		return dst, self.error("invalid number")
+	} else if v == 0 {
+		return append(dst, '0'), nil
+	}
+	return AppendFloat(dst, v, 64, 0)
+}
+
+// string returns the unquoted string, which is valid until the next call.
+func (self *canonicalizer) string() ([]byte, error) {
+	self.pos++
+	self.str = self.str[:0]
+	for self.pos < len(self.src) {
+		c := self.src[self.pos]
+		switch {
+		case c == '"':
+			self.pos++
+			return self.str, nil
+		case c < 0x20:
+			return nil, self.error("control character in string")
+		case c >= utf8.RuneSelf:
+			r, n := utf8.DecodeRune(self.src[self.pos:])
+			if r == utf8.RuneError && n == 1 {
+				return nil, self.error("invalid UTF-8 in string")
+			}
+			self.str = append(self.str, self.src[self.pos:self.pos+n]...)
+			self.pos += n
+		case c != '\\':
+			self.str = append(self.str, c)
+			self.pos++
+		default:
+			if err := self.escape(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, self.error("unterminated string")
+}
+
+func (self *canonicalizer) escape() error {
+	if self.pos+1 >= len(self.src) {
+		return self.error("unterminated string")
+	}
+	switch c := self.src[self.pos+1]; c {
+	case '"', '\\', '/':
+		self.str = append(self.str, c)
+	case 'b':
+		self.str = append(self.str, '\b')
+	case 'f':
+		self.str = append(self.str, '\f')
+	case 'n':
+		self.str = append(self.str, '\n')
+	case 'r':
+		self.str = append(self.str, '\r')
+	case 't':
+		self.str = append(self.str, '\t')
+	case 'u':
+		r := self.hex(self.pos + 2)
+		if r < 0 {
+			return self.error("invalid unicode escape")
+		}
+		self.pos += 6
+
+		/* surrogates come in pairs */
+		if utf16.IsSurrogate(r) {
+			r2 := rune(-1)
+			if self.pos+1 < len(self.src) && self.src[self.pos] == '\\' && self.src[self.pos+1] == 'u' {
+				r2 = self.hex(self.pos + 2)
+			}
+			if r = utf16.DecodeRune(r, r2); r == utf8.RuneError {
+				return self.error("lone surrogate in string")
+			}
+			self.pos += 6
+		}
+		var b [utf8.UTFMax]byte
+		self.str = append(self.str, b[:utf8.EncodeRune(b[:], r)]...)
+		return nil
+	default:
+		return self.error("invalid escape")
+	}
+	self.pos += 2
+	return nil
+}
+
+func (self *canonicalizer) hex(i int) rune {
+	if i+4 > len(self.src) {
+		return -1
+	}
+	v, err := strconv.ParseUint(string(self.src[i:i+4]), 16, 16)
+	if err != nil {
+		return -1
+	}
+	return rune(v)
+}
+
+// appendCanonicalString quotes s with only `"`, `\` and the control characters escaped,
+// and the non-ASCII characters if ascii is true.
+func appendCanonicalString(dst []byte, s []byte, ascii bool) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= utf8.RuneSelf && ascii:
+			r, n := utf8.DecodeRune(s[i:])
+			dst = appendEscapedRune(dst, r, false)
+			i += n - 1
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c >= 0x20:
+			dst = append(dst, c)
+		case c == '\b':
+			dst = append(dst, '\\', 'b')
+		case c == '\f':
+			dst = append(dst, '\\', 'f')
+		case c == '\n':
+			dst = append(dst, '\\', 'n')
+		case c == '\r':
+			dst = append(dst, '\\', 'r')
+		case c == '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			dst = appendUnicode(dst, rune(c))
+		}
+	}
+	return append(dst, '"')
+}
+
+// lessUTF16 compares the strings by their UTF-16 code units.
+func lessUTF16(a string, b string) bool {
+	for len(a) != 0 && len(b) != 0 {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			a1, a2 := utf16Units(ra)
+			b1, b2 := utf16Units(rb)
+			return a1 < b1 || a1 == b1 && a2 < b2
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) < len(b)
+}
+
+func utf16Units(r rune) (rune, rune) {
+	if r >= 0x10000 {
+		return utf16.EncodeRune(r)
+	}
+	return r, 0
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+    `fmt`
+    `strings`
+    `testing`
+)
+
+func TestCanonicalize(t *testing.T) {
+    cases := []struct {
+        src string
+        exp string
+    }{
+        // the samples of RFC 8785
+        {
+            `{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+              "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+              "literals": [null, true, false]}`,
+            `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+        },
+        {
+            `{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`,
+            "{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001F600\":5,\"\ufb33\":3}",
+        },
+        {`[-0, 0.0, 1e21, 1e-7, 0.000001, 5e-324, 9007199254740993, 295147905179352825856, 1.7976931348623157e308]`,
+            `[0,0,1e+21,1e-7,0.000001,5e-324,9007199254740992,295147905179352830000,1.7976931348623157e+308]`},
+        {` { "b" : [ { } , [ ] ] , "a" : { "d" : 1 , "c" : 2 } } `, `{"a":{"c":2,"d":1},"b":[{},[]]}`},
+        {"\"\u2028<>&\x7f\"", "\"\u2028<>&\x7f\""},
+        {`[{"a":1.0,"b":{"d":1,"c":2}},{"x":[]}]`, `[{"a":1,"b":{"c":2,"d":1}},{"x":[]}]`},
+    }
+    for _, c := range cases {
+        out, err := Canonicalize(nil, []byte(c.src), 0)
+        if err != nil {
+            t.Fatalf("canonicalize %s: %v", c.src, err)
+        }
+        if string(out) != c.exp {
+            t.Fatalf("canonicalize %s:\n\texpect %s\n\tgot    %s", c.src, c.exp, out)
+        }
+    }
+
+    for _, src := range []string{`{"a":1,}`, `[1 2]`, `"\ud800"`, "\"\xff\"", `1e400`, `nul`, `{} x`, `"a`, `{"a":1,"b":2,"a":3}`, `[{"\u0061":1,"a":{}}]`, `{"a":1,"a":2}`} {
+        if _, err := Canonicalize(nil, []byte(src), 0); err == nil {
+            t.Fatalf("invalid %s is canonicalized", src)
+        }
+    }
+
+    /* deep values are copied once */
+    src := strings.Repeat(`{ "b" : [1.0, {"a":`, 1000) + `null` + strings.Repeat(`}]}`, 1000)
+    exp := strings.Repeat(`{"b":[1,{"a":`, 1000) + `null` + strings.Repeat(`}]}`, 1000)
+    out, err := Canonicalize([]byte(`x`), []byte(src), 0)
+    if err != nil || string(out) != `x` + exp {
+        t.Fatalf("canonicalize deep value: %v", err)
+    }
+}
+
+func TestCanonicalOrder(t *testing.T) {
+    if order := CanonicalOrder([]string{"a", "b", "\u00f6", "\U0001F600", "\ufb33"}); order != nil {
+        t.Fatalf("sorted keys are reordered: %v", order)
+    }
+    order := CanonicalOrder([]string{"\ufb33", "b", "\U0001F600", "a"})
+    if fmt.Sprint(order) != "[3 1 2 0]" {
+        t.Fatalf("unexpected order: %v", order)
+    }
+}
@@ -0,0 +1,215 @@
+/**
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"unsafe"
+
+	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+const (
+	FloatDigitsBits = 6
+	FloatDigitsMask = 1<<FloatDigitsBits - 1
+
+	// FloatFormatMask is the options that format floats in Go instead of the native kernels
+	FloatFormatMask = 1<<BitFloatExponent | 1<<BitFloatPlain | 1<<BitFloatTrailingZero | 1<<BitFloatNaNInfString |
+		FloatDigitsMask<<BitFloatPrecision | FloatDigitsMask<<BitFloatDigits
+)
+
+// EncodeFloat32 writes the float32 at vp in the float formatting options of opt.
+func EncodeFloat32(buf *[]byte, vp unsafe.Pointer, opt uint64) (err error) {
+	*buf, err = AppendFloat(*buf, float64(*(*float32)(vp)), 32, opt)
+	return
+}
+
+// EncodeFloat64 writes the float64 at vp in the float formatting options of opt.
+func EncodeFloat64(buf *[]byte, vp unsafe.Pointer, opt uint64) (err error) {
+	*buf, err = AppendFloat(*buf, *(*float64)(vp), 64, opt)
+	return
+}
+
+var (
+	complex64Type  = rt.UnpackType(reflect.TypeOf(complex64(0)))
+	complex128Type = rt.UnpackType(reflect.TypeOf(complex128(0)))
+)
+
+// EncodeComplex64 writes the complex64 at vp as `[re, im]` in the float formatting options of opt,
+// or by the policy of unsupported types without ComplexArray.
+func EncodeComplex64(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	if opt&(1<<BitComplexArray) == 0 {
+		return EncodeUnsupported(buf, complex64Type, opt)
+	}
+	v := *(*complex64)(vp)
+	return appendComplex(buf, float64(real(v)), float64(imag(v)), 32, opt)
+}
+
+// EncodeComplex128 writes the complex128 at vp as `[re, im]` in the float formatting options of opt,
+// or by the policy of unsupported types without ComplexArray.
+func EncodeComplex128(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	if opt&(1<<BitComplexArray) == 0 {
+		return EncodeUnsupported(buf, complex128Type, opt)
+	}
+	v := *(*complex128)(vp)
+	return appendComplex(buf, real(v), imag(v), 64, opt)
+}
+
+func appendComplex(buf *[]byte, re float64, im float64, bits int, opt uint64) (err error) {
+	b := append(*buf, '[')
+	if b, err = AppendFloat(b, re, bits, opt); err != nil {
+		return err
+	}
+	b = append(b, ',')
+	if b, err = AppendFloat(b, im, bits, opt); err != nil {
+		return err
+	}
+	*buf = append(b, ']')
+	return nil
+}
+
+// AppendFloat appends v of the bit size in the float formatting options of opt. Without
+// the precision or significant digits, it is the shortest text that reads back as v,
+// in the notation of encoding/json unless either notation is chosen.
+//
+// It runs in Go rather than in the native float kernels, which only write the shortest
+// text. The digits are formatted by strconv and read back from its text by splitFloat,
+// once more for rounding when the precision or significant digits drop some.
+func AppendFloat(buf []byte, v float64, bits int, opt uint64) ([]byte, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		switch {
+		case opt&(1<<BitFloatNaNInfString) != 0 && math.IsNaN(v):
+			return append(buf, `"NaN"`...), nil
+		case opt&(1<<BitFloatNaNInfString) != 0 && v > 0:
+			return append(buf, `"Infinity"`...), nil
+		case opt&(1<<BitFloatNaNInfString) != 0:
+			return append(buf, `"-Infinity"`...), nil
+		case opt&(1<<BitEncodeNullForInfOrNan) != 0:
+			return append(buf, "null"...), nil
+		default:
+			return buf, vars.ERR_nan_or_infinite
+		}
+	}
+
+	/* scan the shortest digits of v from strconv, and rescan the rounded ones if the precision or significant digits drop some */
+	var d [32]byte
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, v, 'e', -1, bits)
+	neg, nd, exp := splitFloat(buf[start:], d[:])
+	if prec := int(opt>>BitFloatPrecision&FloatDigitsMask) - 1; prec >= 0 {
+		if keep := exp + 1 + prec; keep <= 0 && nd > 0 {
+			buf = strconv.AppendFloat(buf[:start], v, 'f', prec, bits)
+			neg, nd, exp = splitFloat(buf[start:], d[:])
+		} else if keep < nd {
+			buf = strconv.AppendFloat(buf[:start], v, 'e', keep-1, bits)
+			neg, nd, exp = splitFloat(buf[start:], d[:])
+		}
+	} else if digits := int(opt >> BitFloatDigits & FloatDigitsMask); digits > 0 && digits < nd {
+		buf = strconv.AppendFloat(buf[:start], v, 'e', digits-1, bits)
+		neg, nd, exp = splitFloat(buf[start:], d[:])
+	}
+
+	/* write the digits in the notation, e-9 and e+21 like encoding/json */
+	buf = buf[:start]
+	if neg {
+		buf = append(buf, '-')
+	}
+	if nd == 0 && opt&(1<<BitFloatExponent) != 0 {
+		return append(buf, "0e+0"...), nil
+	} else if nd == 0 {
+		buf = append(buf, '0')
+	} else if opt&(1<<BitFloatExponent) != 0 || opt&(1<<BitFloatPlain) == 0 && (exp < -6 || exp >= 21) {
+		buf = append(buf, d[0])
+		if nd > 1 {
+			buf = append(buf, '.')
+			buf = append(buf, d[1:nd]...)
+		}
+		if exp < 0 {
+			buf = append(buf, 'e', '-')
+			return strconv.AppendInt(buf, int64(-exp), 10), nil
+		}
+		buf = append(buf, 'e', '+')
+		return strconv.AppendInt(buf, int64(exp), 10), nil
+	} else if exp < 0 {
+		buf = append(buf, '0', '.')
+		for i := exp + 1; i < 0; i++ {
+			buf = append(buf, '0')
+		}
+		return append(buf, d[:nd]...), nil
+	} else if exp+1 < nd {
+		buf = append(buf, d[:exp+1]...)
+		buf = append(buf, '.')
+		return append(buf, d[exp+1:nd]...), nil
+	} else {
+		buf = append(buf, d[:nd]...)
+		for i := nd; i <= exp; i++ {
+			buf = append(buf, '0')
+		}
+	}
+
+	/* mark whole floats */
+	if opt&(1<<BitFloatTrailingZero) != 0 {
+		buf = append(buf, '.', '0')
+	}
+	return buf, nil
+}
+
+// splitFloat reads the text of strconv.AppendFloat in either 'e' or 'f' format into the
+// significant digits without trailing zeros, and the decimal exponent of the first one.
+// Zeros have no digits.
+func splitFloat(s []byte, d []byte) (neg bool, nd int, exp int) {
+	i, n, point := 0, 0, -1
+	if len(s) > 0 && s[0] == '-' {
+		neg, i = true, 1
+	}
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '.':
+			point = n
+			continue
+		case c == 'e':
+			e, _ := strconv.Atoi(string(s[i+1:]))
+			exp += e
+			i = len(s)
+			continue
+		case c != '0' && nd == 0:
+			exp = -n - 1
+		}
+		if n++; nd > 0 || s[i] != '0' {
+			if nd < len(d) {
+				d[nd] = s[i]
+			}
+			nd++
+		}
+	}
+	if point < 0 {
+		point = n
+	}
+	if nd > len(d) {
+		nd = len(d)
+	}
+	for nd > 0 && d[nd-1] == '0' {
+		nd--
+	}
+	if nd == 0 {
+		return neg, 0, 0
+	}
+	return neg, nd, exp + point
+}
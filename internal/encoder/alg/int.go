@@ -0,0 +1,55 @@
+/**
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+	"unsafe"
+)
+
+const (
+	// MaxSafeInteger is the largest integer that JavaScript numbers hold exactly, 2^53-1
+	MaxSafeInteger = 1<<53 - 1
+
+	// Int64StringMask is the options that write 64-bit integers as strings
+	Int64StringMask = 1<<BitInt64String | 1<<BitUnsafeInt64String
+)
+
+// EncodeI64 writes the int64 at vp, as a string if the options of opt ask for it.
+func EncodeI64(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	v := *(*int64)(vp)
+	if opt&(1<<BitInt64String) == 0 && (opt&(1<<BitUnsafeInt64String) == 0 || v >= -MaxSafeInteger && v <= MaxSafeInteger) {
+		*buf = I64toa(*buf, v)
+		return nil
+	}
+	*buf = append(*buf, '"')
+	*buf = I64toa(*buf, v)
+	*buf = append(*buf, '"')
+	return nil
+}
+
+// EncodeU64 writes the uint64 at vp, as a string if the options of opt ask for it.
+func EncodeU64(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	v := *(*uint64)(vp)
+	if opt&(1<<BitInt64String) == 0 && (opt&(1<<BitUnsafeInt64String) == 0 || v <= MaxSafeInteger) {
+		*buf = U64toa(*buf, v)
+		return nil
+	}
+	*buf = append(*buf, '"')
+	*buf = U64toa(*buf, v)
+	*buf = append(*buf, '"')
+	return nil
+}
@@ -19,6 +19,7 @@ package alg
 import (
 	"encoding"
 	"reflect"
+	"sort"
 	"strconv"
 	"sync"
 	"unsafe"
@@ -37,6 +38,9 @@ type MapIterator struct {
     It rt.GoMapIterator     // must be the first field
     kv rt.GoSlice           // slice of _MapPair
     ki int
+    ek unsafe.Pointer       // key of the pair being encoded
+    ev unsafe.Pointer       // value of the pair being encoded
+    sk map[string]struct{}  // keys to be skipped
 }
 
 var (
@@ -44,6 +48,54 @@ var (
     iteratorPair = rt.UnpackType(reflect.TypeOf(_MapPair{}))
 )
 
+// skipKeys holds the key sets registered by RegisterSkipKeys, the
+// first set is empty so that id 0 skips nothing. The sets are interned
+// in skipIDs, so recompiling a type does not register its set again.
+var (
+    skipLock = sync.RWMutex{}
+    skipKeys = []map[string]struct{}{nil}
+    skipIDs  = map[string]int{}
+)
+
+// RegisterSkipKeys registers the set of keys that the map iterators started
+// with the returned id leave out, which are the names of the struct fields
+// that an inline map must not write again. Equal sets share the same id.
+func RegisterSkipKeys(keys []string) int {
+    if len(keys) == 0 {
+        return 0
+    }
+    sorted := append([]string(nil), keys...)
+    sort.Strings(sorted)
+    var name []byte
+    for _, k := range sorted {
+        name = strconv.AppendQuote(name, k)
+    }
+
+    skipLock.Lock()
+    defer skipLock.Unlock()
+    if id, ok := skipIDs[string(name)]; ok {
+        return id
+    }
+    m := make(map[string]struct{}, len(keys))
+    for _, k := range keys {
+        m[k] = struct{}{}
+    }
+    id := len(skipKeys)
+    skipKeys = append(skipKeys, m)
+    skipIDs[string(name)] = id
+    return id
+}
+
+func loadSkipKeys(id int) map[string]struct{} {
+    if id == 0 {
+        return nil
+    }
+    skipLock.RLock()
+    m := skipKeys[id]
+    skipLock.RUnlock()
+    return m
+}
+
 func init() {
     if unsafe.Offsetof(MapIterator{}.It) != 0 {
         panic("_MapIterator.it is not the first field")
@@ -63,9 +115,18 @@ func resetIterator(p *MapIterator) *MapIterator {
     p.ki = 0
     p.It = rt.GoMapIterator{}
     p.kv.Len = 0
+    p.ek = nil
+    p.ev = nil
+    p.sk = nil
     return p
 }
 
+// Entry returns the key and value of the pair being encoded, the key
+// points to its string form if the keys are sorted.
+func (self *MapIterator) Entry() (k unsafe.Pointer, v unsafe.Pointer, sorted bool) {
+    return self.ek, self.ev, self.ki >= 0
+}
+
 func (self *MapIterator) at(i int) *_MapPair {
     return (*_MapPair)(unsafe.Pointer(uintptr(self.kv.Ptr) + uintptr(i) * unsafe.Sizeof(_MapPair{})))
 }
@@ -142,13 +203,27 @@ func IteratorStop(p *MapIterator) {
     iteratorPool.Put(p)
 }
 
+func (self *MapIterator) skipped(k unsafe.Pointer) bool {
+    _, ok := self.sk[*(*string)(k)]
+    return ok
+}
+
+// skipNext advances the unordered iteration past the skipped keys.
+func (self *MapIterator) skipNext() {
+    for self.sk != nil && self.It.K != nil && self.skipped(self.It.K) {
+        rt.Mapiternext(&self.It)
+    }
+}
+
 func IteratorNext(p *MapIterator) {
     i := p.ki
     t := &p.It
+    p.ek, p.ev = t.K, t.V
 
     /* check for unordered iteration */
     if i < 0 {
         rt.Mapiternext(t)
+        p.skipNext()
         return
     }
 
@@ -165,13 +240,17 @@ func IteratorNext(p *MapIterator) {
     p.ki++
 }
 
-func IteratorStart(t *rt.GoMapType, m *rt.GoMap, fv uint64) (*MapIterator, error) {
+// IteratorStart starts iterating over the map, the keys in the set registered
+// as skip are left out, which must be strings.
+func IteratorStart(t *rt.GoMapType, m *rt.GoMap, fv uint64, skip int) (*MapIterator, error) {
     it := newIterator()
+    it.sk = loadSkipKeys(skip)
     rt.Mapiterinit(t, m, &it.It)
 
     /* check for key-sorting, empty map don't need sorting */
-    if m.Count == 0 || (fv & (1<<BitSortMapKeys)) == 0 {
+    if m.Count == 0 || (fv & (1<<BitSortMapKeys | 1<<BitCanonical)) == 0 {
         it.ki = -1
+        it.skipNext()
         return it, nil
     }
 
@@ -182,6 +261,9 @@ func IteratorStart(t *rt.GoMapType, m *rt.GoMap, fv uint64) (*MapIterator, error
 
     /* dump all the key-value pairs */
     for ; it.It.K != nil; rt.Mapiternext(&it.It) {
+        if it.sk != nil && it.skipped(it.It.K) {
+            continue
+        }
         if err := it.append(t.Key, it.It.K, it.It.V); err != nil {
             IteratorStop(it)
             return nil, err
@@ -189,8 +271,18 @@ func IteratorStart(t *rt.GoMapType, m *rt.GoMap, fv uint64) (*MapIterator, error
     }
 
     /* sort the keys, map with only 1 item don't need sorting */
-    if it.ki = 1; m.Count > 1 {
+    if it.ki = 1; it.kv.Len > 1 {
         radixQsort(it.data(), 0, maxDepth(it.kv.Len))
+        if (fv & (1<<BitCanonical)) != 0 {
+            sortUTF16(it.data())
+        }
+    }
+
+    /* all the keys may have been skipped */
+    if it.kv.Len == 0 {
+        it.It.K = nil
+        it.It.V = nil
+        return it, nil
     }
 
     /* load the first pair into iterator */
@@ -199,6 +291,27 @@ func IteratorStart(t *rt.GoMapType, m *rt.GoMap, fv uint64) (*MapIterator, error
     return it, nil
 }
 
+// sortUTF16 reorders the pairs sorted by bytes into the order of UTF-16 code units,
+// which only differs for the characters from U+E000 to U+FFFF against those beyond.
+// Only the keys and values are moved, since the keys of integers point into m.
+func sortUTF16(kvs []_MapPair) {
+    i := 1
+    for i < len(kvs) && !lessUTF16(kvs[i].k, kvs[i - 1].k) {
+        i++
+    }
+    if i == len(kvs) {
+        return
+    }
+    tmp := make([]_MapPair, len(kvs))
+    for i := range kvs {
+        tmp[i].k, tmp[i].v = kvs[i].k, kvs[i].v
+    }
+    sort.SliceStable(tmp, func(i, j int) bool { return lessUTF16(tmp[i].k, tmp[j].k) })
+    for i := range kvs {
+        kvs[i].k, kvs[i].v = tmp[i].k, tmp[i].v
+    }
+}
+
 func asText(v unsafe.Pointer) (string, error) {
 	text := rt.AssertI2I(rt.UnpackType(vars.EncodingTextMarshalerType), *(*rt.GoIface)(v))
 	r, e := (*(*encoding.TextMarshaler)(unsafe.Pointer(&text))).MarshalText()
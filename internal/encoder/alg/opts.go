@@ -26,6 +26,30 @@ const (
     BitNoValidateJSONMarshaler
     BitNoEncoderNewline 
     BitEncodeNullForInfOrNan 
+    BitDetectCycles
+    BitDurationString
+    BitFloatExponent
+    BitFloatPlain
+    BitFloatTrailingZero
+    BitFloatNaNInfString
+    BitEscapeNonASCII
+    BitInt64String
+    BitUnsafeInt64String
+    BitUnsupportedNull
+    BitUnsupportedSkip
+    BitErrorString
+    BitRedact
+    BitRedactHash
+    BitRedactOmit
+    BitCanonical
+    BitComplexArray
+
+    // the time format id takes utils.TimeFormatBits bits from here
+    BitTimeFormat = 32
+
+    // the float precision and significant digits take FloatDigitsBits bits from here
+    BitFloatPrecision = 48
+    BitFloatDigits    = 54
 	
     BitPointerValue = 63
 )
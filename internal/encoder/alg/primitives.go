@@ -17,11 +17,23 @@
 package alg
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+	"unsafe"
 
 	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/resolver"
 	"github.com/bytedance/sonic/internal/rt"
+	"github.com/bytedance/sonic/internal/utils"
 )
 
 func Compact(p *[]byte, v []byte) error {
@@ -42,6 +54,130 @@ func Compact(p *[]byte, v []byte) error {
 	return nil
 }
 
+// EncodeUnionTag writes the discriminator of the member type mt of the union vt at vp,
+// as the first key of the object that has been encoded since buf[start].
+func EncodeUnionTag(buf *[]byte, start int, vt *rt.GoType, mt *rt.GoType, vp unsafe.Pointer, opt uint64) error {
+	u := resolver.FindUnion(vt.Pack())
+	name, ok := u.Name(mt.Pack())
+	if !ok {
+		return vars.Error_union(vt.Pack(), mt.Pack())
+	}
+
+	/* members with the key field write it by themselves, nil pointers are `null` */
+	tag := u.Tag(mt.Pack())
+	obj := (*buf)[start:]
+	if len(obj) < 2 || obj[0] != '{' {
+		return nil
+	} else if tag == "" {
+		return checkUnionKey(u, vt, mt, vp, name)
+	}
+	if obj[1] != '}' {
+		tag += ","
+	}
+	if opt&(1<<BitEscapeNonASCII) != 0 {
+		tag = string(ASCIIEscape(nil, []byte(tag)))
+	}
+
+	/* shift the fields to make room for the tag */
+	n := len(*buf)
+	*buf = append(*buf, tag...)
+	copy((*buf)[start+1+len(tag):], (*buf)[start+1:n])
+	copy((*buf)[start+1:], tag)
+	return nil
+}
+
+// checkUnionKey checks the key field of the keyed member at vp against its name.
+func checkUnionKey(u *resolver.Union, vt *rt.GoType, mt *rt.GoType, vp unsafe.Pointer, name string) error {
+	p := rt.Add(vp, 8)
+	if mt.Kind() == reflect.Ptr || mt.Indirect() {
+		p = *(*unsafe.Pointer)(p)
+	}
+	if key, _ := u.KeyOf(mt.Pack(), p); key != name {
+		return vars.Error_union_key(vt.Pack(), mt.Pack(), key, name)
+	}
+	return nil
+}
+
+// EncodeBig writes the `big.Int` or `big.Float` at vp as a JSON number,
+// in the shortest text that decodes into the same value.
+func EncodeBig(buf *[]byte, vt *rt.GoType, vp unsafe.Pointer) error {
+	if !resolver.IsBigFloat(vt.Pack()) {
+		*buf = (*big.Int)(vp).Append(*buf, 10)
+		return nil
+	}
+	f := (*big.Float)(vp)
+	if f.IsInf() {
+		return vars.ERR_nan_or_infinite
+	}
+	*buf = f.Append(*buf, 'g', -1)
+	return nil
+}
+
+// EncodeTime writes the `time.Time` at vp in the time format selected by opt,
+// which is a quoted RFC 3339 string with nanoseconds by default.
+func EncodeTime(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	t := (*time.Time)(vp)
+	switch id := (opt >> BitTimeFormat) & utils.TimeFormatMask; id {
+	case utils.TimeUnix:
+		*buf = I64toa(*buf, t.Unix())
+	case utils.TimeUnixMilli:
+		*buf = I64toa(*buf, t.Unix() * 1e3 + int64(t.Nanosecond()) / 1e6)
+	case utils.TimeRFC3339Nano:
+		if y := t.Year(); y < 0 || y >= 10000 {
+			_, err := t.MarshalJSON()
+			return err
+		}
+		*buf = append(*buf, '"')
+		*buf = t.AppendFormat(*buf, time.RFC3339Nano)
+		*buf = append(*buf, '"')
+	default:
+		*buf = QuoteOpt(*buf, t.Format(utils.TimeLayout(id)), false, opt)
+	}
+	return nil
+}
+
+// EncodeDuration writes the `time.Duration` at vp as a quoted string like "1.5s".
+func EncodeDuration(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	*buf = QuoteOpt(*buf, (*time.Duration)(vp).String(), false, opt)
+	return nil
+}
+
+// EncodeFormat writes the value of type vt at vp in the `format:` option of the id,
+// see resolver.ResolveFormat.
+func EncodeFormat(buf *[]byte, vt *rt.GoType, vp unsafe.Pointer, id int, opt uint64) error {
+	f := resolver.FormatOf(id)
+	switch f.Kind {
+	case resolver.FormatTime:
+		return EncodeTime(buf, vp, utils.TimeFormatID(f.Text) << BitTimeFormat | opt & (1<<BitEscapeNonASCII))
+	case resolver.FormatDuration:
+		return EncodeDuration(buf, vp, opt)
+	case resolver.FormatBase64URL, resolver.FormatHex:
+		b := *(*[]byte)(vp)
+		if b == nil {
+			return EncodeNil(buf)
+		}
+		*buf = append(*buf, '"')
+		if f.Kind == resolver.FormatHex {
+			*buf = append(*buf, hex.EncodeToString(b)...)
+		} else {
+			*buf = append(*buf, base64.URLEncoding.EncodeToString(b)...)
+		}
+		*buf = append(*buf, '"')
+	case resolver.FormatPrintf:
+		var v float64
+		if vt.Kind() == reflect.Float32 {
+			v = float64(*(*float32)(vp))
+		} else {
+			v = *(*float64)(vp)
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return vars.ERR_nan_or_infinite
+		}
+		*buf = append(*buf, fmt.Sprintf(f.Text, v)...)
+	}
+	return nil
+}
+
 func EncodeNil(rb *[]byte) error {
 	*rb = append(*rb, 'n', 'u', 'l', 'l')
 	return nil
@@ -63,10 +199,88 @@ func EncodeNil(rb *[]byte) error {
 // 	}
 // }
 
+var _T_IsZeroer = rt.UnpackType(vars.IsZeroerType)
+
+// IsZero reports whether the value of type vt at p is its zero value, as reflect.Value.IsZero does.
+func IsZero(p unsafe.Pointer, vt *rt.GoType) bool {
+	return reflect.NewAt(vt.Pack(), p).Elem().IsZero()
+}
+
+// IsZeroer calls the IsZero method of the value of type vt at p through itab.
+// When itab belongs to *vt, the method has a pointer receiver and is called on p itself.
+// Nil pointers and nil interfaces are always reported as zero.
+func IsZeroer(p unsafe.Pointer, vt *rt.GoType, itab *rt.GoItab) bool {
+	var it rt.GoIface
+	switch vt.Kind() {
+	case reflect.Interface:
+		v := *(*rt.GoIface)(p)
+		if v.Itab == nil || (v.Itab.Vt.Kind() == reflect.Ptr && v.Value == nil) {
+			return true
+		}
+		it = rt.AssertI2I(_T_IsZeroer, v)
+	case reflect.Ptr:
+		v := *(*unsafe.Pointer)(p)
+		if v == nil {
+			return true
+		}
+		it = rt.GoIface{Itab: itab, Value: v}
+	default:
+		if itab.Vt != vt || vt.Indirect() {
+			it = rt.GoIface{Itab: itab, Value: p}
+		} else {
+			it = rt.GoIface{Itab: itab, Value: *(*unsafe.Pointer)(p)}
+		}
+	}
+	return (*(*vars.IsZeroer)(unsafe.Pointer(&it))).IsZero()
+}
+
+// EncodeUnsupported writes the value of the unsupported type vt by the policy of opt,
+// which is null for both the null and skip policies, or an error otherwise.
+func EncodeUnsupported(buf *[]byte, vt *rt.GoType, opt uint64) error {
+	if opt&(1<<BitUnsupportedNull|1<<BitUnsupportedSkip) == 0 {
+		return vars.Error_type(vt.Pack())
+	}
+	*buf = append(*buf, "null"...)
+	return nil
+}
+
+// EncodeErrorString writes the non-nil error value at vp as its Error() string,
+// unless it has its own JSON or text encoding.
+func EncodeErrorString(buf *[]byte, vp unsafe.Pointer, opt uint64) error {
+	if it := (*rt.GoIface)(vp); it.Itab.Vt.Kind() == reflect.Ptr && it.Value == nil {
+		*buf = append(*buf, "null"...)
+		return nil
+	}
+	switch v := (*(*error)(vp)).(type) {
+	case json.Marshaler:
+		return EncodeJsonMarshaler(buf, v, opt)
+	case encoding.TextMarshaler:
+		return EncodeTextMarshaler(buf, v, opt)
+	default:
+		*buf = QuoteOpt(*buf, v.Error(), false, opt)
+		return nil
+	}
+}
+
+// RedactMask is the options that write the redacted fields
+const RedactMask = 1<<BitRedact | 1<<BitRedactHash
+
+// AppendRedactHash appends the redacted string of the JSON value v, which is `"sha256:"`
+// followed by the hex SHA-256 sum of v.
+func AppendRedactHash(buf []byte, v []byte) []byte {
+	sum := sha256.Sum256(v)
+	buf = append(buf, `"sha256:`...)
+	buf = append(buf, hex.EncodeToString(sum[:])...)
+	return append(buf, '"')
+}
+
 func EncodeJsonMarshaler(buf *[]byte, val json.Marshaler, opt uint64) error {
 	if ret, err := val.MarshalJSON(); err != nil {
 		return err
 	} else {
+		if opt&(1<<BitEscapeNonASCII) != 0 {
+			ret = ASCIIEscape(nil, ret)
+		}
 		if opt&(1<<BitCompactMarshaler) != 0 {
 			return Compact(buf, ret)
 		}
@@ -80,15 +294,61 @@ func EncodeJsonMarshaler(buf *[]byte, val json.Marshaler, opt uint64) error {
 	}
 }
 
+// EncodeInlineMarshaler writes the members of the JSON object returned by val without
+// its braces, as if they were fields of the enclosing struct. A JSON null writes nothing.
+func EncodeInlineMarshaler(buf *[]byte, val json.Marshaler, opt uint64, first bool) error {
+	ret, err := val.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if opt&(1<<BitEscapeNonASCII) != 0 {
+		ret = ASCIIEscape(nil, ret)
+	}
+
+	/* the output must be a valid object */
+	if opt&(1<<BitCompactMarshaler) != 0 {
+		var buf []byte
+		if err := Compact(&buf, ret); err != nil {
+			return err
+		}
+		ret = buf
+	} else if opt&(1<<BitNoValidateJSONMarshaler) == 0 {
+		if ok, s := Valid(ret); !ok {
+			return vars.Error_marshaler(ret, s)
+		}
+	}
+	obj := bytes.TrimSpace(ret)
+	if string(obj) == "null" {
+		return nil
+	}
+	if len(obj) < 2 || obj[0] != '{' || obj[len(obj)-1] != '}' {
+		return vars.Error_marshaler(ret, 0)
+	}
+
+	/* empty objects write nothing */
+	obj = bytes.TrimSpace(obj[1 : len(obj)-1])
+	if len(obj) == 0 {
+		return nil
+	}
+	if !first {
+		*buf = append(*buf, ',')
+	}
+	*buf = append(*buf, obj...)
+	return nil
+}
+
 func EncodeTextMarshaler(buf *[]byte, val encoding.TextMarshaler, opt uint64) error {
 	if ret, err := val.MarshalText(); err != nil {
 		return err
 	} else {
-		if opt&(1<<BitNoQuoteTextMarshaler) != 0 {
+		if opt&(1<<BitNoQuoteTextMarshaler) != 0 && opt&(1<<BitEscapeNonASCII) != 0 {
+			*buf = ASCIIEscape(*buf, ret)
+			return nil
+		} else if opt&(1<<BitNoQuoteTextMarshaler) != 0 {
 			*buf = append(*buf, ret...)
 			return nil
 		}
-		*buf = Quote(*buf, rt.Mem2Str(ret), false)
+		*buf = QuoteOpt(*buf, rt.Mem2Str(ret), false, opt)
 		return nil
 	}
 }
@@ -166,3 +166,20 @@ func getRandKvs(kn int, kl int) kvSlice {
     }
     return kvs
 }
+
+func TestRegisterSkipKeys(t *testing.T) {
+    if RegisterSkipKeys(nil) != 0 {
+        t.Fatal("empty set must have id 0")
+    }
+    a := RegisterSkipKeys([]string{"x", "y"})
+    n := len(skipKeys)
+    if b := RegisterSkipKeys([]string{"y", "x"}); b != a || len(skipKeys) != n {
+        t.Fatalf("equal sets are registered again: %d, %d", a, b)
+    }
+    if c := RegisterSkipKeys([]string{"x,y"}); c == a {
+        t.Fatal("different sets share the same id")
+    }
+    if _, ok := loadSkipKeys(a)["y"]; !ok {
+        t.Fatal("key not in the set")
+    }
+}
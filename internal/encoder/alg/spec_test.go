@@ -0,0 +1,45 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package alg
+
+import (
+    `bytes`
+    `encoding/json`
+    `strings`
+    `testing`
+)
+
+func TestHtmlEscape_NonEmptyDst(t *testing.T) {
+    src := []byte(`"` + strings.Repeat("<a&b>", 100) + `"`)
+    var exp bytes.Buffer
+    json.HTMLEscape(&exp, src)
+
+    // the dst is grown from its length, not from zero
+    for _, n := range []int{1, 64, 1000} {
+        dst := make([]byte, n, n + 8)
+        for i := range dst {
+            dst[i] = 'x'
+        }
+        out := HtmlEscape(dst, src)
+        if got := string(out[:n]); got != strings.Repeat("x", n) {
+            t.Fatalf("dst prefix changed: %q", got)
+        }
+        if got := string(out[n:]); got != exp.String() {
+            t.Fatalf("expect %q, got %q", exp.String(), got)
+        }
+    }
+}
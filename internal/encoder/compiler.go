@@ -18,8 +18,10 @@ package encoder
 
 import (
 	"reflect"
+	"unicode/utf8"
 	"unsafe"
 
+	"github.com/bytedance/sonic/internal/encoder/alg"
 	"github.com/bytedance/sonic/internal/encoder/ir"
 	"github.com/bytedance/sonic/internal/encoder/vars"
 	"github.com/bytedance/sonic/internal/encoder/vm"
@@ -130,6 +132,14 @@ func (self *Compiler) compileOne(p *ir.Program, sp int, vt reflect.Type, pv bool
 func (self *Compiler) tryCompileMarshaler(p *ir.Program, vt reflect.Type, pv bool) bool {
 	pt := reflect.PtrTo(vt)
 
+	/* optional values, big numbers and times are written natively */
+	if resolver.IsOptional(vt) || resolver.IsBig(vt) || resolver.IsTime(vt) {
+		return false
+	}
+	if vt.Kind() == reflect.Ptr && (resolver.IsBig(vt.Elem()) || resolver.IsTime(vt.Elem())) {
+		return false
+	}
+
 	/* check for addressable `json.Marshaler` with pointer receiver */
 	if pv && pt.Implements(vars.JsonMarshalerType) {
 		addMarshalerOp(p, ir.OP_marshal_p, pt, vars.JsonMarshalerType)
@@ -179,7 +189,7 @@ func (self *Compiler) compileOps(p *ir.Program, sp int, vt reflect.Type) {
 	case reflect.Bool:
 		p.Add(ir.OP_bool)
 	case reflect.Int:
-		p.Add(ir.OP_int())
+		p.Add(ir.OP_int_js())
 	case reflect.Int8:
 		p.Add(ir.OP_i8)
 	case reflect.Int16:
@@ -187,9 +197,13 @@ func (self *Compiler) compileOps(p *ir.Program, sp int, vt reflect.Type) {
 	case reflect.Int32:
 		p.Add(ir.OP_i32)
 	case reflect.Int64:
-		p.Add(ir.OP_i64)
+		if resolver.IsDuration(vt) {
+			p.Add(ir.OP_duration)
+		} else {
+			p.Add(ir.OP_i64_js)
+		}
 	case reflect.Uint:
-		p.Add(ir.OP_uint())
+		p.Add(ir.OP_uint_js())
 	case reflect.Uint8:
 		p.Add(ir.OP_u8)
 	case reflect.Uint16:
@@ -197,13 +211,17 @@ func (self *Compiler) compileOps(p *ir.Program, sp int, vt reflect.Type) {
 	case reflect.Uint32:
 		p.Add(ir.OP_u32)
 	case reflect.Uint64:
-		p.Add(ir.OP_u64)
+		p.Add(ir.OP_u64_js)
 	case reflect.Uintptr:
 		p.Add(ir.OP_uintptr())
 	case reflect.Float32:
 		p.Add(ir.OP_f32)
 	case reflect.Float64:
 		p.Add(ir.OP_f64)
+	case reflect.Complex64:
+		p.Add(ir.OP_c64)
+	case reflect.Complex128:
+		p.Add(ir.OP_c128)
 	case reflect.String:
 		self.compileString(p, vt)
 	case reflect.Array:
@@ -211,18 +229,78 @@ func (self *Compiler) compileOps(p *ir.Program, sp int, vt reflect.Type) {
 	case reflect.Interface:
 		self.compileInterface(p, vt)
 	case reflect.Map:
-		self.compileMap(p, sp, vt)
+		if isUnsupported(vt) {
+			p.Rtt(ir.OP_unsupported, vt)
+		} else {
+			self.compileMap(p, sp, vt)
+		}
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		p.Rtt(ir.OP_unsupported, vt)
 	case reflect.Ptr:
 		self.compilePtr(p, sp, vt.Elem())
 	case reflect.Slice:
 		self.compileSlice(p, sp, vt.Elem())
 	case reflect.Struct:
-		self.compileStruct(p, sp, vt)
+		if resolver.IsOptional(vt) {
+			self.compileOptional(p, sp, vt)
+		} else if resolver.IsBig(vt) {
+			p.Rtt(ir.OP_big, vt)
+		} else if resolver.IsTime(vt) {
+			p.Add(ir.OP_time)
+		} else {
+			self.compileStruct(p, sp, vt)
+		}
 	default:
 		panic(vars.Error_type(vt))
 	}
 }
 
+// isUnsupported tells whether the values of vt have no JSON encoding, which are written
+// by the policy of the unsupported types at runtime.
+func isUnsupported(vt reflect.Type) bool {
+	if reflect.PtrTo(vt).Implements(vars.JsonMarshalerType) || reflect.PtrTo(vt).Implements(vars.EncodingTextMarshalerType) {
+		return false
+	}
+	switch vt.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	case reflect.Map:
+		return !isMapKey(vt.Key())
+	default:
+		return false
+	}
+}
+
+// isASCII tells whether s has only ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// isComplex tells whether vt is a complex number, which is unsupported without ComplexArray.
+func isComplex(vt reflect.Type) bool {
+	if reflect.PtrTo(vt).Implements(vars.JsonMarshalerType) || reflect.PtrTo(vt).Implements(vars.EncodingTextMarshalerType) {
+		return false
+	}
+	return vt.Kind() == reflect.Complex64 || vt.Kind() == reflect.Complex128
+}
+
+// isMapKey tells whether vt can be written as the keys of objects.
+func isMapKey(vt reflect.Type) bool {
+	switch vt.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	default:
+		return vt.Implements(vars.EncodingTextMarshalerType)
+	}
+}
+
 func (self *Compiler) compileNil(p *ir.Program, sp int, vt reflect.Type, nil_op ir.Op, fn func(*ir.Program, int, reflect.Type)) {
 	x := p.PC()
 	p.Add(ir.OP_is_nil)
@@ -246,6 +324,22 @@ func (self *Compiler) compilePtrBody(p *ir.Program, sp int, vt reflect.Type) {
 	p.Add(ir.OP_drop)
 }
 
+func (self *Compiler) compileOptional(p *ir.Program, sp int, vt reflect.Type) {
+	et, off := resolver.OptionalValue(vt)
+	x := p.PC()
+	p.Add(ir.OP_is_zero_1)
+	p.Tag(sp)
+	p.Add(ir.OP_save)
+	p.Int(ir.OP_index, int(off))
+	self.compileOne(p, sp+1, et, self.pv)
+	p.Add(ir.OP_drop)
+	e := p.PC()
+	p.Add(ir.OP_goto)
+	p.Pin(x)
+	p.Add(ir.OP_null)
+	p.Pin(e)
+}
+
 func (self *Compiler) compileMap(p *ir.Program, sp int, vt reflect.Type) {
 	self.compileNil(p, sp, vt, ir.OP_empty_obj, self.compileMapBody)
 }
@@ -434,25 +528,237 @@ func (self *Compiler) compileStruct(p *ir.Program, sp int, vt reflect.Type) {
 }
 
 func (self *Compiler) compileStructBody(p *ir.Program, sp int, vt reflect.Type) {
+	if resolver.IsArrayStruct(vt) {
+		self.compileStructArray(p, sp, vt)
+		return
+	}
+
 	p.Tag(sp)
 	p.Int(ir.OP_byte, '{')
 	p.Add(ir.OP_save)
 	p.Add(ir.OP_cond_set)
 
 	/* compile each field */
-	for _, fv := range resolver.ResolveStruct(vt) {
-		var s []int
-		var o resolver.Offset
+	fields := resolver.ResolveStruct(vt)
+	if _, err := resolver.InlineField(vt, fields); err != nil {
+		panic(err)
+	}
 
-		/* "omitempty" for arrays */
-		if fv.Type.Kind() == reflect.Array {
-			if fv.Type.Len() == 0 && (fv.Opts&resolver.F_omitempty) != 0 {
-				continue
-			}
+	/* the fields are written in the order of keys under Canonical */
+	if order := canonicalOrder(fields); order == nil {
+		for i := range fields {
+			self.compileStructField(p, sp, vt, &fields[i], fields)
+		}
+	} else {
+		self.compileStructFieldsCanonical(p, sp, vt, fields, order)
+	}
+
+	/* end of object */
+	p.Add(ir.OP_drop)
+	p.Int(ir.OP_byte, '}')
+}
+
+// canonicalOrder returns the indices of the fields in the order of keys, or nil if they
+// are in order already, or merged with the members of the catch-all field.
+func canonicalOrder(fields []resolver.FieldMeta) []int {
+	keys := make([]string, len(fields))
+	for i := range fields {
+		if (fields[i].Opts & resolver.F_inline) != 0 {
+			return nil
+		}
+		keys[i] = fields[i].Name
+	}
+	return alg.CanonicalOrder(keys)
+}
+
+// compileStructFieldsCanonical compiles each field once in the declared order, and chains
+// them in the given order of keys by the jumps taken under Canonical.
+func (self *Compiler) compileStructFieldsCanonical(p *ir.Program, sp int, vt reflect.Type, fields []resolver.FieldMeta, order []int) {
+	starts := make([]int, len(fields))
+	jumps := make([]int, len(fields))
+
+	/* the first field in the order of keys */
+	i := p.PC()
+	p.Add(ir.OP_canonical_goto)
+	for j := range fields {
+		starts[j] = p.PC()
+		self.compileStructField(p, sp, vt, &fields[j], fields)
+		jumps[j] = p.PC()
+		p.Add(ir.OP_canonical_goto)
+	}
+	e := p.PC()
+	p.Add(ir.OP_goto)
+
+	/* go on to the next field in the order of keys */
+	p.Pin(i)
+	p.Int(ir.OP_goto, starts[order[0]])
+	x := []int{e}
+	for k, j := range order {
+		p.Pin(jumps[j])
+		if k+1 < len(order) {
+			p.Int(ir.OP_goto, starts[order[k+1]])
+		} else {
+			x = append(x, p.PC())
+			p.Add(ir.OP_goto)
+		}
+	}
+	p.Rel(x)
+}
+
+func (self *Compiler) compileStructField(p *ir.Program, sp int, vt reflect.Type, fv *resolver.FieldMeta, fields []resolver.FieldMeta) {
+	var s []int
+	var o resolver.Offset
+
+	/* "omitempty" for arrays */
+	if fv.Type.Kind() == reflect.Array {
+		if fv.Type.Len() == 0 && (fv.Opts&resolver.F_omitempty) != 0 {
+			return
+		}
+	}
+
+	/* index to the field */
+	for _, o = range fv.Path {
+		if p.Int(ir.OP_index, int(o.Size)); o.Kind == resolver.F_deref {
+			s = append(s, p.PC())
+			p.Add(ir.OP_is_nil)
+			p.Add(ir.OP_deref)
+		}
+	}
+
+	/* the catch-all field writes its members into the enclosing object */
+	if (fv.Opts & resolver.F_inline) != 0 {
+		self.compileStructInline(p, sp, vt, fv, fields)
+		p.Rel(s)
+		p.Add(ir.OP_load)
+		return
+	}
+
+	/* redacted fields are left out with RedactOmit */
+	if (fv.Opts & resolver.F_redact) != 0 {
+		s = append(s, p.PC())
+		p.Add(ir.OP_redact_omit)
+	}
+
+	/* fields of unsupported types are left out by the skip policy */
+	if ft := fv.Type; ft.Kind() == reflect.Ptr && isUnsupported(ft.Elem()) || isUnsupported(ft) {
+		s = append(s, p.PC())
+		p.Add(ir.OP_unsupported_skip)
+	} else if ft.Kind() == reflect.Ptr && isComplex(ft.Elem()) || isComplex(ft) {
+		s = append(s, p.PC())
+		p.Add(ir.OP_complex_skip)
+	}
+
+	/* check for "omitempty" option */
+	if fv.Type.Kind() != reflect.Struct && fv.Type.Kind() != reflect.Array && (fv.Opts&resolver.F_omitempty) != 0 {
+		s = append(s, p.PC())
+		self.compileStructFieldZero(p, fv.Type)
+	}
+
+	/* optional values are empty when absent */
+	if resolver.IsOptional(fv.Type) && (fv.Opts&(resolver.F_omitempty|resolver.F_omitzero)) != 0 {
+		s = append(s, p.PC())
+		p.Add(ir.OP_is_zero_2)
+	}
+
+	/* check for "omitzero" option */
+	if (fv.Opts&resolver.F_omitzero) != 0 && !resolver.IsOptional(fv.Type) {
+		s = append(s, p.PC())
+		self.compileStructFieldIsZero(p, fv.Type)
+	}
+
+	/* add the comma if not the first element */
+	i := p.PC()
+	p.Add(ir.OP_cond_testc)
+	p.Int(ir.OP_byte, ',')
+	p.Pin(i)
+
+	/* compile the key and value, and the escaped key for non-ASCII names */
+	if key := Quote(fv.Name) + ":"; isASCII(key) {
+		p.Str(ir.OP_text, key)
+	} else {
+		j := p.PC()
+		p.Add(ir.OP_ascii_skip)
+		p.Str(ir.OP_text, string(alg.ASCIIEscape(nil, []byte(key))))
+		k := p.PC()
+		p.Add(ir.OP_goto)
+		p.Pin(j)
+		p.Str(ir.OP_text, key)
+		p.Pin(k)
+	}
+	if (fv.Opts & resolver.F_redact) != 0 {
+		self.compileStructFieldRedact(p, sp+1, fv)
+	} else {
+		self.compileStructFieldValue(p, sp+1, fv)
+	}
+
+	/* patch the skipping jumps and reload the struct pointer */
+	p.Rel(s)
+	p.Add(ir.OP_load)
+}
+
+// compileStructFieldRedact writes the redacted value instead of the field with the redaction options.
+func (self *Compiler) compileStructFieldRedact(p *ir.Program, sp int, fv *resolver.FieldMeta) {
+	i := p.PC()
+	p.Rtt(ir.OP_redact, fv.Type)
+	self.compileStructFieldValue(p, sp, fv)
+	p.Pin(i)
+}
+
+func (self *Compiler) compileStructFieldValue(p *ir.Program, sp int, fv *resolver.FieldMeta) {
+	vt := fv.Type
+
+	/* check for "format:" option */
+	if fv.Format != "" {
+		id, err := resolver.ResolveFormat(vt, fv.Format)
+		if err != nil {
+			panic(err)
+		}
+		if id != 0 {
+			self.compileStructFieldFormat(p, vt, id)
+			return
+		}
+	}
+
+	/* check for "stringnize" option */
+	if (fv.Opts & resolver.F_stringize) == 0 {
+		self.compileOne(p, sp, vt, self.pv)
+	} else {
+		self.compileStructFieldStr(p, sp, vt)
+	}
+}
+
+func (self *Compiler) compileStructFieldFormat(p *ir.Program, vt reflect.Type, id int) {
+	if vt.Kind() != reflect.Ptr {
+		p.Rtti(ir.OP_format, vt, id)
+		return
+	}
+
+	/* the pointer is reloaded with the struct after the field */
+	x := p.PC()
+	p.Add(ir.OP_is_nil)
+	p.Add(ir.OP_deref)
+	p.Rtti(ir.OP_format, vt.Elem(), id)
+	e := p.PC()
+	p.Add(ir.OP_goto)
+	p.Pin(x)
+	p.Add(ir.OP_null)
+	p.Pin(e)
+}
+
+func (self *Compiler) compileStructArray(p *ir.Program, sp int, vt reflect.Type) {
+	p.Tag(sp)
+	p.Int(ir.OP_byte, '[')
+	p.Add(ir.OP_save)
+
+	/* every field has its position, so none of them is omitted */
+	for i, fv := range resolver.ResolveStruct(vt) {
+		var s []int
+		if i != 0 {
+			p.Int(ir.OP_byte, ',')
 		}
 
 		/* index to the field */
-		for _, o = range fv.Path {
+		for _, o := range fv.Path {
 			if p.Int(ir.OP_index, int(o.Size)); o.Kind == resolver.F_deref {
 				s = append(s, p.PC())
 				p.Add(ir.OP_is_nil)
@@ -460,37 +766,116 @@ func (self *Compiler) compileStructBody(p *ir.Program, sp int, vt reflect.Type)
 			}
 		}
 
-		/* check for "omitempty" option */
-		if fv.Type.Kind() != reflect.Struct && fv.Type.Kind() != reflect.Array && (fv.Opts&resolver.F_omitempty) != 0 {
-			s = append(s, p.PC())
-			self.compileStructFieldZero(p, fv.Type)
+		self.compileStructFieldValue(p, sp+1, &fv)
+
+		/* fields of nil embedded pointers are null */
+		if len(s) != 0 {
+			e := p.PC()
+			p.Add(ir.OP_goto)
+			p.Rel(s)
+			p.Add(ir.OP_null)
+			p.Pin(e)
 		}
+		p.Add(ir.OP_load)
+	}
+
+	/* end of array */
+	p.Add(ir.OP_drop)
+	p.Int(ir.OP_byte, ']')
+}
+
+func (self *Compiler) compileStructInline(p *ir.Program, sp int, st reflect.Type, fv *resolver.FieldMeta, fields []resolver.FieldMeta) {
+	vt := fv.Type
+	pt := reflect.PtrTo(vt)
+	mt := rt.IfaceType(rt.UnpackType(vars.JsonMarshalerType))
+
+	/* maps are written entry by entry */
+	if vt.Kind() == reflect.Map && vt.Key().Kind() == reflect.String {
+		self.compileStructInlineMap(p, sp, vt, fields)
+		return
+	}
 
-		/* add the comma if not the first element */
+	/* otherwise it must marshal itself into an object */
+	switch {
+	case vt.Kind() == reflect.Interface:
+		panic(resolver.InlineTypeError(st, fv))
+	case vt.Kind() == reflect.Ptr && vt.Implements(vars.JsonMarshalerType):
 		i := p.PC()
-		p.Add(ir.OP_cond_testc)
-		p.Int(ir.OP_byte, ',')
+		p.Add(ir.OP_is_nil)
+		p.Vtab(ir.OP_marshal_inline, vt, rt.GetItab(mt, rt.UnpackType(vt), false))
 		p.Pin(i)
+	case vt.Implements(vars.JsonMarshalerType):
+		i := p.PC()
+		p.Rtt(ir.OP_is_zero, vt)
+		p.Vtab(ir.OP_marshal_inline, vt, rt.GetItab(mt, rt.UnpackType(vt), false))
+		p.Pin(i)
+	case pt.Implements(vars.JsonMarshalerType):
+		i := p.PC()
+		p.Rtt(ir.OP_is_zero, vt)
+		p.Vtab(ir.OP_marshal_inline, vt, rt.GetItab(mt, rt.UnpackType(pt), false))
+		p.Pin(i)
+	default:
+		panic(resolver.InlineTypeError(st, fv))
+	}
+}
 
-		/* compile the key and value */
-		ft := fv.Type
-		p.Str(ir.OP_text, Quote(fv.Name)+":")
+func (self *Compiler) compileStructInlineMap(p *ir.Program, sp int, vt reflect.Type, fields []resolver.FieldMeta) {
+	var keys []string
 
-		/* check for "stringnize" option */
-		if (fv.Opts & resolver.F_stringize) == 0 {
-			self.compileOne(p, sp+1, ft, self.pv)
-		} else {
-			self.compileStructFieldStr(p, sp+1, ft)
+	/* keys of the named fields are not written again */
+	for _, fv := range fields {
+		if (fv.Opts & resolver.F_inline) == 0 {
+			keys = append(keys, fv.Name)
 		}
-
-		/* patch the skipping jumps and reload the struct pointer */
-		p.Rel(s)
-		p.Add(ir.OP_load)
 	}
 
-	/* end of object */
-	p.Add(ir.OP_drop)
-	p.Int(ir.OP_byte, '}')
+	p.Tag(sp + 1)
+	e := p.PC()
+	p.Add(ir.OP_is_zero_map)
+	p.Add(ir.OP_save)
+	p.Rtti(ir.OP_map_iter, vt, alg.RegisterSkipKeys(keys))
+	p.Add(ir.OP_save)
+	i := p.PC()
+	p.Add(ir.OP_map_check_key)
+	c := p.PC()
+	p.Add(ir.OP_cond_testc)
+	p.Int(ir.OP_byte, ',')
+	p.Pin(c)
+	u := p.PC()
+	p.Add(ir.OP_map_write_key)
+	self.compileMapBodyKey(p, vt.Key())
+	p.Pin(u)
+	p.Int(ir.OP_byte, ':')
+	p.Add(ir.OP_map_value_next)
+	self.compileOne(p, sp+2, vt.Elem(), false)
+	j := p.PC()
+	p.Add(ir.OP_map_check_key)
+	p.Int(ir.OP_byte, ',')
+	v := p.PC()
+	p.Add(ir.OP_map_write_key)
+	self.compileMapBodyKey(p, vt.Key())
+	p.Pin(v)
+	p.Int(ir.OP_byte, ':')
+	p.Add(ir.OP_map_value_next)
+	self.compileOne(p, sp+2, vt.Elem(), false)
+	p.Int(ir.OP_goto, j)
+	p.Pin(j)
+	p.Add(ir.OP_map_stop)
+	p.Add(ir.OP_drop_2)
+
+	/* some entries are written, so the following fields need commas */
+	k := p.PC()
+	p.Add(ir.OP_cond_testc)
+	p.Pin(k)
+	x := p.PC()
+	p.Add(ir.OP_goto)
+
+	/* all the entries are skipped */
+	p.Pin(i)
+	p.Add(ir.OP_map_stop)
+	p.Add(ir.OP_drop_2)
+	p.Pin(x)
+	p.Pin(e)
 }
 
 func (self *Compiler) compileStructFieldStr(p *ir.Program, sp int, vt reflect.Type) {
@@ -614,14 +999,63 @@ func (self *Compiler) compileStructFieldZero(p *ir.Program, vt reflect.Type) {
 		p.Add(ir.OP_is_nil)
 	case reflect.Slice:
 		p.Add(ir.OP_is_nil_p1)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		p.Add(ir.OP_is_nil)
 	default:
 		panic(vars.Error_type(vt))
 	}
 }
 
+func (self *Compiler) compileStructFieldIsZero(p *ir.Program, vt reflect.Type) {
+	pt := reflect.PtrTo(vt)
+
+	/* types with an "IsZero() bool" method decide by themselves */
+	if vt.Implements(vars.IsZeroerType) {
+		var itab *rt.GoItab
+		if vt.Kind() != reflect.Interface {
+			itab = rt.GetItab(rt.IfaceType(rt.UnpackType(vars.IsZeroerType)), rt.UnpackType(vt), false)
+		}
+		p.Vtab(ir.OP_is_zero_fn, vt, itab)
+		return
+	} else if vt.Kind() != reflect.Ptr && pt.Implements(vars.IsZeroerType) {
+		itab := rt.GetItab(rt.IfaceType(rt.UnpackType(vars.IsZeroerType)), rt.UnpackType(pt), false)
+		p.Vtab(ir.OP_is_zero_fn, vt, itab)
+		return
+	}
+
+	/* otherwise it's the zero value of the type */
+	switch vt.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		p.Add(ir.OP_is_zero_1)
+	case reflect.Int16, reflect.Uint16:
+		p.Add(ir.OP_is_zero_2)
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		p.Add(ir.OP_is_zero_4)
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		p.Add(ir.OP_is_zero_8)
+	case reflect.Int, reflect.Uint:
+		p.Add(ir.OP_is_zero_ints())
+	case reflect.Uintptr, reflect.Ptr, reflect.Map, reflect.Interface, reflect.Slice:
+		p.Add(ir.OP_is_nil)
+	case reflect.String:
+		p.Add(ir.OP_is_nil_p1)
+	default:
+		p.Rtt(ir.OP_is_zero, vt)
+	}
+}
+
 func (self *Compiler) compileStructFieldQuoted(p *ir.Program, sp int, vt reflect.Type) {
 	p.Int(ir.OP_byte, '"')
-	self.compileOne(p, sp, vt, self.pv)
+
+	/* the 64-bit integers are quoted already, regardless of the int64 string options */
+	switch k := vt.Kind(); {
+	case k == reflect.Int64 && !resolver.IsDuration(vt), k == reflect.Int && ir.OP_int() == ir.OP_i64:
+		p.Add(ir.OP_i64)
+	case k == reflect.Uint64, k == reflect.Uint && ir.OP_uint() == ir.OP_u64:
+		p.Add(ir.OP_u64)
+	default:
+		self.compileOne(p, sp, vt, self.pv)
+	}
 	p.Int(ir.OP_byte, '"')
 }
 
@@ -629,9 +1063,16 @@ func (self *Compiler) compileInterface(p *ir.Program, vt reflect.Type) {
 	x := p.PC()
 	p.Add(ir.OP_is_nil_p1)
 
-	/* iface and efaces are different */
-	if vt.NumMethod() == 0 {
+	/* iface and efaces are different, unions write their discriminators */
+	if resolver.FindUnion(vt) != nil {
+		p.Rtt(ir.OP_union, vt)
+	} else if vt.NumMethod() == 0 {
 		p.Add(ir.OP_eface)
+	} else if vt.Implements(vars.ErrorType) {
+		i := p.PC()
+		p.Add(ir.OP_error)
+		p.Add(ir.OP_iface)
+		p.Pin(i)
 	} else {
 		p.Add(ir.OP_iface)
 	}
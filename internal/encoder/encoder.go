@@ -27,6 +27,7 @@ import (
 	"github.com/bytedance/sonic/internal/encoder/alg"
 	"github.com/bytedance/sonic/internal/encoder/vars"
 	"github.com/bytedance/sonic/internal/rt"
+	"github.com/bytedance/sonic/internal/utils"
 	"github.com/bytedance/sonic/option"
 )
 
@@ -73,8 +74,108 @@ const (
 
     // Encode Infinity or Nan float into `null`, instead of returning an error.
     EncodeNullForInfOrNan Options = 1 << alg.BitEncodeNullForInfOrNan
+
+    // DetectCycles indicates that the encoder should track the pointers, maps and slices
+    // nested deeper than option.CycleDetectionDepth, and return an error once a value
+    // refers to itself, instead of recursing until the nesting is too deep.
+    DetectCycles Options = 1 << alg.BitDetectCycles
+
+    // DurationString indicates that the encoder should write time.Duration as a string
+    // like `"1.5s"`, instead of an integer of nanoseconds.
+    DurationString Options = 1 << alg.BitDurationString
+
+    // FloatExponent indicates that the encoder should always write floats in exponent notation.
+    FloatExponent Options = 1 << alg.BitFloatExponent
+
+    // FloatPlain indicates that the encoder should never write floats in exponent notation.
+    FloatPlain Options = 1 << alg.BitFloatPlain
+
+    // FloatTrailingZero indicates that the encoder should write whole floats
+    // in plain notation with a trailing `.0`, like `1.0` instead of `1`.
+    FloatTrailingZero Options = 1 << alg.BitFloatTrailingZero
+
+    // FloatNaNInfString indicates that the encoder should write NaN and ±Inf floats as the strings
+    // `"NaN"`, `"Infinity"` and `"-Infinity"`, instead of returning an error.
+    FloatNaNInfString Options = 1 << alg.BitFloatNaNInfString
+
+    // EscapeNonASCII indicates encoder to escape all non-ASCII characters as \uXXXX
+    // (with surrogate pairs beyond the BMP) while quoting strings, so the output is
+    // pure ASCII, also with Canonical. U+2028 and U+2029 are always escaped with it,
+    // and invalid UTF-8 is written as \ufffd.
+    EscapeNonASCII Options = 1 << alg.BitEscapeNonASCII
+
+    // Int64String indicates encoder to write all the 64-bit integers (int64, uint64,
+    // and int, uint on 64-bit platforms) as strings like `"123"`, except time.Duration.
+    Int64String Options = 1 << alg.BitInt64String
+
+    // UnsafeInt64String indicates encoder to write the 64-bit integers out of the safe range
+    // of JavaScript numbers, ±(2^53-1), as strings, and the others as numbers.
+    UnsafeInt64String Options = 1 << alg.BitUnsafeInt64String
+
+    // UnsupportedTypeNull indicates encoder to write the values of unsupported types
+    // (channels, functions, unsafe pointers, maps of unsupported keys, and complex numbers
+    // without ComplexArray) as `null`, instead of returning an error.
+    UnsupportedTypeNull Options = 1 << alg.BitUnsupportedNull
+
+    // UnsupportedTypeSkip indicates encoder to leave out the struct fields of unsupported types,
+    // and write the other values of them as `null`, instead of returning an error.
+    UnsupportedTypeSkip Options = 1 << alg.BitUnsupportedSkip
+
+    // ErrorString indicates encoder to write the values of the `error` interface type as their
+    // Error() strings, unless they implement json.Marshaler or encoding.TextMarshaler.
+    ErrorString Options = 1 << alg.BitErrorString
+
+    // Redact indicates encoder to write the struct fields tagged with `json:",redact"` as `"***"`.
+    Redact Options = 1 << alg.BitRedact
+
+    // RedactHash indicates encoder to write the struct fields tagged with `json:",redact"` as
+    // `"sha256:"` followed by the hex SHA-256 sum of their JSON encoding with sorted map keys.
+    RedactHash Options = 1 << alg.BitRedactHash
+
+    // RedactOmit indicates encoder to leave out the struct fields tagged with `json:",redact"`.
+    RedactOmit Options = 1 << alg.BitRedactOmit
+
+    // Canonical indicates encoder to write the JSON Canonicalization Scheme of RFC 8785,
+    // with the keys of objects sorted by UTF-16 code units and numbers in the ES6 notation.
+    // The escaping options do not apply to it, and objects with duplicate keys are rejected.
+    // EncodeIndented indents the canonical text, which is then not canonical anymore.
+    Canonical Options = 1 << alg.BitCanonical
+
+    // ComplexArray indicates encoder to write complex64 and complex128 as `[re, im]`
+    // in the float formatting options, otherwise they are unsupported types as in encoding/json.
+    ComplexArray Options = 1 << alg.BitComplexArray
 )
 
+// FloatPrecision returns the option to round floats to at most n digits after the
+// decimal point, where n is in [0, 62]. The trailing zeros of the fraction are removed.
+func FloatPrecision(n int) Options {
+    if n < 0 || n >= alg.FloatDigitsMask {
+        panic("sonic: float precision out of range")
+    }
+    return Options(uint64(n + 1) << alg.BitFloatPrecision)
+}
+
+// FloatSignificantDigits returns the option to round floats to at most n significant
+// digits, where n is in [1, 63]. It takes precedence over FloatPrecision.
+func FloatSignificantDigits(n int) Options {
+    if n <= 0 || n > alg.FloatDigitsMask {
+        panic("sonic: float significant digits out of range")
+    }
+    return Options(uint64(n) << alg.BitFloatDigits)
+}
+
+// TimeFormat returns the option to write time.Time in format, which is either
+// time.RFC3339Nano (the default), "unix" and "unixmilli" for the integer seconds
+// and milliseconds since the Unix epoch, or a custom layout of time.Format.
+// At most one TimeFormat can be set in a set of options.
+func TimeFormat(format string) Options {
+    return Options(utils.TimeFormatID(format) << alg.BitTimeFormat)
+}
+
+// EncodeError is returned when a nested value fails to encode, and tells
+// the Go path to that value.
+type EncodeError = vars.EncodeError
+
 // Encoder represents a specific set of encoder configurations.
 type Encoder struct {
     Opts Options
@@ -173,6 +274,11 @@ func Encode(val interface{}, opts Options) ([]byte, error) {
     buf := vars.NewBytes()
     err := encodeIntoCheckRace(buf, val, opts)
 
+    /* rewrite in the canonical form */
+    if err == nil && opts & Canonical != 0 {
+        err = encodeCanonical(buf, 0, opts)
+    }
+
     /* check for errors */
     if err != nil {
         vars.FreeBytes(buf)
@@ -207,10 +313,14 @@ func Encode(val interface{}, opts Options) ([]byte, error) {
 // EncodeInto is like Encode but uses a user-supplied buffer instead of allocating
 // a new one.
 func EncodeInto(buf *[]byte, val interface{}, opts Options) error {
+    n := len(*buf)
     err := encodeIntoCheckRace(buf, val, opts)
     if err != nil {
         return err
     }
+    if opts & Canonical != 0 {
+        return encodeCanonical(buf, n, opts)
+    }
     *buf = encodeFinish(*buf, opts)
     return err
 }
@@ -222,6 +332,7 @@ func encodeInto(buf *[]byte, val interface{}, opts Options) error {
 
     /* return the stack into pool */
     if err != nil {
+        err = withPath(err, &efv, stk)
         vars.ResetStack(stk)
     }
     vars.FreeStack(stk)
@@ -232,7 +343,30 @@ func encodeInto(buf *[]byte, val interface{}, opts Options) error {
     return err
 }
 
+// encodeCanonical rewrites the JSON after start of buf in the canonical form, in place
+// of the original text, which is moved into a scratch buffer from the pool.
+func encodeCanonical(buf *[]byte, start int, opts Options) error {
+    tmp := vars.NewBytes()
+    src := (*buf)[start:]
+    if (opts & ValidateString != 0) && !utf8.Validate(src) {
+        src = utf8.CorrectWith(*tmp, src, `\ufffd`)
+    } else {
+        src = append(*tmp, src...)
+    }
+    out, err := alg.Canonicalize((*buf)[:start], src, uint64(opts))
+    *tmp = src
+    vars.FreeBytes(tmp)
+    if err != nil {
+        return err
+    }
+    *buf = out
+    return nil
+}
+
 func encodeFinish(buf []byte, opts Options) []byte {
+    if opts & Canonical != 0 {
+        return buf
+    }
     if opts & EscapeHTML != 0 {
         buf = HTMLEscape(nil, buf)
     }
@@ -18,8 +18,14 @@ package encoder
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
 	"runtime"
 	"runtime/debug"
 	"strconv"
@@ -28,6 +34,7 @@ import (
 	"time"
 
 	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/resolver"
 	"github.com/bytedance/sonic/internal/rt"
 	"github.com/bytedance/sonic/option"
 	"github.com/stretchr/testify/require"
@@ -151,6 +158,533 @@ func TestEncoder_FieldStringize(t *testing.T) {
     println(string(r))
 }
 
+type zeroByValue struct {
+    V int
+}
+
+func (z zeroByValue) IsZero() bool { return z.V < 0 }
+
+type zeroByPtr struct {
+    V int
+}
+
+func (z *zeroByPtr) IsZero() bool { return z.V == 42 }
+
+type OmitZeroStruct struct {
+    I   int               `json:"i,omitzero"`
+    F   float64           `json:"f,omitzero"`
+    S   string            `json:"s,omitzero"`
+    P   *int              `json:"p,omitzero"`
+    L   []int             `json:"l,omitzero"`
+    M   map[string]int    `json:"m,omitzero"`
+    A   [2]int            `json:"a,omitzero"`
+    T   time.Time         `json:"t,omitzero"`
+    N   struct{ X int }   `json:"n,omitzero"`
+    V   zeroByValue       `json:"v,omitzero"`
+    R   zeroByPtr         `json:"r,omitzero"`
+    RP  *zeroByValue      `json:"rp,omitzero"`
+    IZ  vars.IsZeroer     `json:"iz,omitzero"`
+    Both []int            `json:"both,omitempty,omitzero"`
+}
+
+func TestEncoder_OmitZero(t *testing.T) {
+    out, err := Encode(OmitZeroStruct{}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"v":{"V":0},"r":{"V":0}}`, string(out))
+
+    out, err = Encode(OmitZeroStruct{
+        L: []int{}, M: map[string]int{}, A: [2]int{0, 1}, N: struct{ X int }{1},
+        V: zeroByValue{-1}, R: zeroByPtr{42}, RP: &zeroByValue{-1}, IZ: (*zeroByPtr)(nil),
+        Both: []int{},
+    }, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"l":[],"m":{},"a":[0,1],"n":{"X":1}}`, string(out))
+
+    out, err = Encode(OmitZeroStruct{T: time.Unix(0, 0).UTC(), F: math.Copysign(0, -1), IZ: zeroByValue{1}}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"f":-0,"t":"1970-01-01T00:00:00Z","v":{"V":0},"r":{"V":0},"iz":{"V":1}}`, string(out))
+}
+
+type arrayTuple struct{}
+
+type arrayEmbed struct {
+    Z string `json:"z,omitempty"`
+}
+
+type arrayPoint struct {
+    arrayTuple `json:",array"`
+    X int      `json:"x,omitempty"`
+    Y float64  `json:"y,string"`
+    *arrayEmbed
+    Tags []string
+}
+
+func TestEncoder_ArrayStruct(t *testing.T) {
+    out, err := Encode([]arrayPoint{{X: 1, Y: 2.5, arrayEmbed: &arrayEmbed{Z: "z"}}, {}}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `[[1,"2.5","z",null],[0,"0",null,null]]`, string(out))
+
+    out, err = Encode(&struct{ P *arrayPoint }{&arrayPoint{Tags: []string{"a"}}}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"P":[0,"0",null,["a"]]}`, string(out))
+
+    out, err = Encode(struct{ arrayTuple `json:",array"` }{}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `[]`, string(out))
+
+    /* the blank marker field */
+    vt := reflect.StructOf([]reflect.StructField{
+        {Name: "_", PkgPath: "encoder", Type: reflect.TypeOf(struct{}{}), Tag: `json:",array"`},
+        {Name: "A", Type: reflect.TypeOf(""), Tag: `json:"a"`},
+    })
+    v := reflect.New(vt)
+    v.Elem().Field(1).SetString("x")
+    out, err = Encode(v.Interface(), 0)
+    require.NoError(t, err)
+    require.Equal(t, `["x"]`, string(out))
+}
+
+type bigStruct struct {
+    I  *big.Int
+    F  big.Float   `json:"f"`
+    P  *big.Float  `json:"p,omitempty"`
+    S  []big.Int
+}
+
+func TestEncoder_Big(t *testing.T) {
+    i, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+    f, _ := new(big.Float).SetPrec(200).SetString("3.14159265358979323846264338327950288")
+    out, err := Encode(bigStruct{I: i, F: *f, S: []big.Int{*big.NewInt(7)}}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"I":-123456789012345678901234567890,"f":3.14159265358979323846264338327950288,"S":[7]}`, string(out))
+
+    out, err = Encode(bigStruct{}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"I":null,"f":0,"S":null}`, string(out))
+
+    _, err = Encode(new(big.Float).SetInf(false), 0)
+    require.Error(t, err)
+}
+
+type timeStruct struct {
+    T  time.Time
+    P  *time.Time      `json:"p,omitempty"`
+    D  time.Duration
+    DS []time.Duration `json:"ds"`
+}
+
+func TestEncoder_Time(t *testing.T) {
+    tm := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("", 8 * 3600))
+    v := timeStruct{T: tm, P: &tm, D: 1500 * time.Millisecond, DS: []time.Duration{time.Minute}}
+    out, err := Encode(v, 0)
+    require.NoError(t, err)
+    std, _ := json.Marshal(v)
+    require.Equal(t, string(std), string(out))
+
+    out, err = Encode(v, TimeFormat("unix") | DurationString)
+    require.NoError(t, err)
+    require.Equal(t, `{"T":1714950489,"p":1714950489,"D":"1.5s","ds":["1m0s"]}`, string(out))
+
+    out, err = Encode(v, TimeFormat("unixmilli"))
+    require.NoError(t, err)
+    require.Equal(t, `{"T":1714950489123,"p":1714950489123,"D":1500000000,"ds":[60000000000]}`, string(out))
+
+    out, err = Encode(tm, TimeFormat("2006-01-02"))
+    require.NoError(t, err)
+    require.Equal(t, `"2024-05-06"`, string(out))
+
+    _, err = Encode(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+    require.Error(t, err)
+}
+
+type formatStruct struct {
+    TS    time.Time      `json:"ts,format:unixmilli"`
+    Day   *time.Time     `json:"day,format:2006-01-02"`
+    Data  []byte         `json:"data,format:base64url"`
+    Hex   []byte         `json:"hex,format:hex"`
+    Raw   []byte         `json:"raw,format:base64"`
+    Price float64        `json:"price,format:%.2f"`
+    ID    float64        `json:"id,format:string"`
+    D     time.Duration  `json:"d,format:string"`
+}
+
+func TestEncoder_Format(t *testing.T) {
+    tm := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+    v := formatStruct{TS: tm, Day: &tm, Data: []byte{0xfb, 0xff}, Hex: []byte{0xca, 0xfe}, Raw: []byte{0xfb, 0xff}, Price: 1.5, ID: 12, D: time.Second}
+    out, err := Encode(v, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"ts":1714979289000,"day":"2024-05-06","data":"-_8=","hex":"cafe","raw":"+/8=","price":1.50,"id":"12","d":"1s"}`, string(out))
+
+    out, err = Encode(formatStruct{}, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"ts":-62135596800000,"day":null,"data":null,"hex":null,"raw":null,"price":0.00,"id":"0","d":"0s"}`, string(out))
+
+    _, err = Encode(formatStruct{Price: math.Inf(1)}, 0)
+    require.Error(t, err)
+    _, err = Encode(struct{ A int `json:"a,format:hex"` }{}, 0)
+    require.Error(t, err)
+}
+
+type floatStruct struct {
+    A float64
+    B float32
+    C []float64
+}
+
+func TestEncoder_Float(t *testing.T) {
+    v := floatStruct{A: 3.14159, B: 2, C: []float64{1e21, 1e-7, 123456.789}}
+    out, err := Encode(v, 0)
+    require.NoError(t, err)
+    std, _ := json.Marshal(v)
+    require.Equal(t, string(std), string(out))
+
+    out, err = Encode(v, FloatPrecision(2))
+    require.NoError(t, err)
+    require.Equal(t, `{"A":3.14,"B":2,"C":[1e+21,0,123456.79]}`, string(out))
+
+    out, err = Encode(v, FloatSignificantDigits(3))
+    require.NoError(t, err)
+    require.Equal(t, `{"A":3.14,"B":2,"C":[1e+21,1e-7,123000]}`, string(out))
+
+    out, err = Encode(v, FloatPlain | FloatTrailingZero)
+    require.NoError(t, err)
+    require.Equal(t, `{"A":3.14159,"B":2.0,"C":[1000000000000000000000.0,0.0000001,123456.789]}`, string(out))
+
+    out, err = Encode(v, FloatExponent)
+    require.NoError(t, err)
+    require.Equal(t, `{"A":3.14159e+0,"B":2e+0,"C":[1e+21,1e-7,1.23456789e+5]}`, string(out))
+
+    out, err = Encode([]float64{math.MaxFloat64, -0.004, 9.995e-7}, FloatSignificantDigits(1) | FloatTrailingZero)
+    require.NoError(t, err)
+    require.Equal(t, `[2e+308,-0.004,0.000001]`, string(out))
+    out, err = Encode([]float64{-0.004, 0.0095}, FloatPrecision(2) | FloatExponent)
+    require.NoError(t, err)
+    require.Equal(t, `[-0e+0,1e-2]`, string(out))
+
+    nan := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+    _, err = Encode(nan, 0)
+    require.Error(t, err)
+    out, err = Encode(nan, FloatNaNInfString)
+    require.NoError(t, err)
+    require.Equal(t, `["NaN","Infinity","-Infinity"]`, string(out))
+    out, err = Encode(float32(math.Inf(1)), FloatNaNInfString)
+    require.NoError(t, err)
+    require.Equal(t, `"Infinity"`, string(out))
+}
+
+func TestEncoder_EscapeNonASCII(t *testing.T) {
+    v := map[string]string{"héllo": "世界\u2028😀<"}
+    out, err := Encode(v, EscapeNonASCII)
+    require.NoError(t, err)
+    require.Equal(t, `{"h\u00e9llo":"\u4e16\u754c\u2028\ud83d\ude00<"}`, string(out))
+    var back map[string]string
+    require.NoError(t, json.Unmarshal(out, &back))
+    require.Equal(t, v, back)
+
+    out, err = Encode("a\xffb", EscapeNonASCII | EscapeHTML)
+    require.NoError(t, err)
+    require.Equal(t, `"a\ufffdb"`, string(out))
+
+    w := asciiStruct{Name: "é", Quoted: "é\n", D: time.Microsecond, Raw: json.RawMessage(`{"é":1}`), M: map[string]int{"ü": 1, "a": 2}}
+    out, err = Encode(w, EscapeNonASCII | DurationString | SortMapKeys)
+    require.NoError(t, err)
+    require.Equal(t, `{"n\u00e4me":"\u00e9","Quoted":"\"\\u00e9\\n\"","D":"1\u00b5s","Raw":{"\u00e9":1},"M":{"a":2,"\u00fc":1}}`, string(out))
+    out, err = Encode(w, DurationString | SortMapKeys)
+    require.NoError(t, err)
+    require.Equal(t, `{"näme":"é","Quoted":"\"é\\n\"","D":"1µs","Raw":{"é":1},"M":{"a":2,"ü":1}}`, string(out))
+}
+
+type asciiStruct struct {
+    Name   string            `json:"näme"`
+    Quoted string            `json:",string"`
+    D      time.Duration
+    Raw    json.RawMessage
+    M      map[string]int
+}
+
+type int64Struct struct {
+    ID    int64
+    N     uint64
+    I     int
+    S     int64          `json:",string"`
+    Small int32
+    D     time.Duration
+    P     *int64
+}
+
+func TestEncoder_Int64String(t *testing.T) {
+    id := int64(1) << 60
+    v := int64Struct{ID: id, N: 1 << 53, I: -1 << 53, S: 7, Small: 7, D: 7, P: &id}
+    out, err := Encode(v, 0)
+    require.NoError(t, err)
+    std, _ := json.Marshal(v)
+    require.Equal(t, string(std), string(out))
+
+    out, err = Encode(v, Int64String)
+    require.NoError(t, err)
+    require.Equal(t, `{"ID":"1152921504606846976","N":"9007199254740992","I":"-9007199254740992","S":"7","Small":7,"D":7,"P":"1152921504606846976"}`, string(out))
+
+    out, err = Encode(v, UnsafeInt64String)
+    require.NoError(t, err)
+    require.Equal(t, `{"ID":"1152921504606846976","N":"9007199254740992","I":"-9007199254740992","S":"7","Small":7,"D":7,"P":"1152921504606846976"}`, string(out))
+
+    out, err = Encode([]interface{}{int64(1<<53 - 1), uint64(1<<53 - 1), -1<<53 + 1, map[int64]int64{1: 2}}, UnsafeInt64String)
+    require.NoError(t, err)
+    require.Equal(t, `[9007199254740991,9007199254740991,-9007199254740991,{"1":2}]`, string(out))
+    out, err = Encode(map[int64]int64{1: 2}, Int64String)
+    require.NoError(t, err)
+    require.Equal(t, `{"1":"2"}`, string(out))
+}
+
+type textError struct{}
+
+func (textError) Error() string { return "text" }
+func (textError) MarshalText() ([]byte, error) { return []byte("marshaled"), nil }
+
+type logEntry struct {
+    Msg   string
+    Ch    chan int
+    Fn    func()       `json:",omitempty"`
+    PF    *func()
+    M     map[[2]int]int
+    C     complex128
+    C64   complex64
+    Err   error
+    Errs  []error
+    Any   interface{}
+}
+
+func TestEncoder_Unsupported(t *testing.T) {
+    v := logEntry{Msg: "hi", Ch: make(chan int), M: map[[2]int]int{}, C: complex(1.5, -2), C64: complex(0, 1),
+        Err: errors.New("boom"), Errs: []error{textError{}, nil}, Any: func() {}}
+    _, err := Encode(v, 0)
+    var ut *json.UnsupportedTypeError
+    require.ErrorAs(t, err, &ut)
+    require.Equal(t, reflect.TypeOf(v.Ch), ut.Type)
+    require.Contains(t, err.Error(), "logEntry.Ch")
+
+    out, err := Encode(v, UnsupportedTypeNull)
+    require.NoError(t, err)
+    require.Equal(t, `{"Msg":"hi","Ch":null,"PF":null,"M":null,"C":null,"C64":null,"Err":{},"Errs":["marshaled",null],"Any":null}`, string(out))
+
+    out, err = Encode(v, UnsupportedTypeSkip | ErrorString)
+    require.NoError(t, err)
+    require.Equal(t, `{"Msg":"hi","Err":"boom","Errs":["marshaled",null],"Any":null}`, string(out))
+
+    out, err = Encode(v, UnsupportedTypeNull | ComplexArray)
+    require.NoError(t, err)
+    require.Equal(t, `{"Msg":"hi","Ch":null,"PF":null,"M":null,"C":[1.5,-2],"C64":[0,1],"Err":{},"Errs":["marshaled",null],"Any":null}`, string(out))
+
+    out, err = Encode(v, UnsupportedTypeSkip | ComplexArray | ErrorString)
+    require.NoError(t, err)
+    require.Equal(t, `{"Msg":"hi","C":[1.5,-2],"C64":[0,1],"Err":"boom","Errs":["marshaled",null],"Any":null}`, string(out))
+
+    _, err = Encode(complex(1, 0), 0)
+    require.ErrorAs(t, err, &ut)
+    out, err = Encode([]interface{}{make(chan int), complex(math.NaN(), 0)}, UnsupportedTypeSkip | ComplexArray | FloatNaNInfString)
+    require.NoError(t, err)
+    require.Equal(t, `[null,["NaN",0]]`, string(out))
+    _, err = Encode(complex(math.Inf(1), 0), ComplexArray)
+    require.Error(t, err)
+}
+
+type account struct {
+    User     string
+    Password string            `json:"pwd,redact"`
+    Token    *string           `json:",redact,omitempty"`
+    Meta     map[string]int    `json:",redact"`
+    Inner    *account          `json:",omitempty"`
+}
+
+func redactHash(js string) string {
+    sum := sha256.Sum256([]byte(js))
+    return `"sha256:` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func TestEncoder_Redact(t *testing.T) {
+    tok := "tok"
+    v := account{User: "u", Password: "p", Token: &tok, Meta: map[string]int{"b": 2, "a": 1},
+        Inner: &account{User: "i", Password: "q"}}
+    out, err := Encode(v, SortMapKeys)
+    require.NoError(t, err)
+    std, _ := json.Marshal(v)
+    require.Equal(t, string(std), string(out))
+
+    out, err = Encode(v, Redact)
+    require.NoError(t, err)
+    require.Equal(t, `{"User":"u","pwd":"***","Token":"***","Meta":"***","Inner":{"User":"i","pwd":"***","Meta":"***"}}`, string(out))
+
+    out, err = Encode(v, RedactHash)
+    require.NoError(t, err)
+    require.Equal(t, `{"User":"u","pwd":`+redactHash(`"p"`)+`,"Token":`+redactHash(`"tok"`)+
+        `,"Meta":`+redactHash(`{"a":1,"b":2}`)+`,"Inner":{"User":"i","pwd":`+redactHash(`"q"`)+
+        `,"Meta":`+redactHash(`null`)+`}}`, string(out))
+
+    out, err = Encode(v, RedactOmit)
+    require.NoError(t, err)
+    require.Equal(t, `{"User":"u","Inner":{"User":"i"}}`, string(out))
+}
+
+type maskUser struct {
+    Name    string
+    Age     int             `json:"age,string"`
+    Email   string          `json:",omitempty"`
+    Pwd     string          `json:",redact"`
+    Born    time.Time       `json:",format:unix"`
+}
+
+type maskItem struct {
+    ID      int64           `json:"id"`
+    Tags    []string        `json:"tags"`
+    Extra   interface{}     `json:"extra,omitempty"`
+}
+
+type maskDoc struct {
+    *maskUser               `json:"user"`
+    Items   []maskItem      `json:"items"`
+    Meta    map[string]int  `json:"meta"`
+    Next    *maskDoc        `json:"next,omitempty"`
+}
+
+func TestEncoder_FieldMask(t *testing.T) {
+    v := &maskDoc{
+        maskUser: &maskUser{Name: "n", Age: 7, Pwd: "p", Born: time.Unix(100, 0)},
+        Items: []maskItem{{ID: 1, Tags: []string{"a"}}, {ID: 2, Extra: map[string]interface{}{"x": 1, "y": 2}}},
+        Meta: map[string]int{"b": 2, "a": 1},
+    }
+
+    m, err := NewFieldMask("user.Name", "user.age", "user.Email", "items.*.id", "items.extra.x", "meta.a", "next.user")
+    require.NoError(t, err)
+    out, err := EncodeMasked(v, m, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"user":{"Name":"n","age":"7"},"items":[{"id":1},{"id":2,"extra":{"x":1}}],"meta":{"a":1}}`, string(out))
+
+    m, _ = NewFieldMask("user", "items.tags", "meta")
+    out, err = EncodeMasked(v, m, SortMapKeys | Redact)
+    require.NoError(t, err)
+    require.Equal(t, `{"user":{"Name":"n","age":"7","Pwd":"***","Born":100},"items":[{"tags":["a"]},{"tags":null}],"meta":{"a":1,"b":2}}`, string(out))
+
+    m, _ = NewFieldMask("*.Pwd", "user.Born")
+    out, err = EncodeMasked(*v, m, RedactOmit)
+    require.NoError(t, err)
+    require.Equal(t, `{"user":{"Born":100},"items":[{},{}],"meta":{}}`, string(out))
+
+    out, err = EncodeMasked([]interface{}{v, nil}, m, 0)
+    require.NoError(t, err)
+    require.Equal(t, `[{"user":{"Born":100}},null]`, string(out))
+
+    out, err = EncodeMasked(v, nil, SortMapKeys)
+    require.NoError(t, err)
+    std, _ := Encode(v, SortMapKeys)
+    require.Equal(t, string(std), string(out))
+
+    _, err = NewFieldMask("a..b")
+    require.Error(t, err)
+}
+
+type maskShape interface {
+    area() float64
+}
+
+type maskCircle struct {
+    R float64 `json:"r"`
+}
+
+func (c maskCircle) area() float64 { return 3 * c.R * c.R }
+
+// maskOptional is laid out like sonic.Optional[maskCircle].
+type maskOptional struct {
+    _     resolver.OptionalTag
+    set   bool
+    null  bool
+    value maskCircle
+}
+
+type maskWhole struct {
+    Pos  arrayPoint   `json:"pos"`
+    Main maskShape    `json:"main"`
+    Opt  maskOptional `json:"opt"`
+    Skip int          `json:"skip"`
+}
+
+func init() {
+    resolver.RegisterUnion(reflect.TypeOf((*maskShape)(nil)).Elem(), "kind", map[string]reflect.Type{
+        "circle": reflect.TypeOf(maskCircle{}),
+    })
+}
+
+func TestEncoder_FieldMaskWhole(t *testing.T) {
+    v := maskWhole{
+        Pos: arrayPoint{X: 1, Y: 2},
+        Main: maskCircle{R: 1},
+        Opt: maskOptional{set: true, value: maskCircle{R: 2}},
+        Skip: 3,
+    }
+
+    /* tuples, unions and optionals are selected as a whole */
+    m, err := NewFieldMask("pos.x", "main.r", "opt.r")
+    require.NoError(t, err)
+    out, err := EncodeMasked(v, m, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"pos":[1,"2",null,null],"main":{"kind":"circle","r":1},"opt":{"r":2}}`, string(out))
+
+    m, _ = NewFieldMask("opt", "skip")
+    v.Opt = maskOptional{null: true}
+    out, err = EncodeMasked(v, m, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"opt":null,"skip":3}`, string(out))
+}
+
+type canonicalDoc struct {
+    Z       float64
+    A       string
+    Emoji   map[string]int
+    Any     interface{}
+    Raw     json.RawMessage
+}
+
+func TestEncoder_Canonical(t *testing.T) {
+    v := canonicalDoc{
+        Z: 1e21, A: "<\u2028\x01>",
+        Emoji: map[string]int{"\ufb33": 1, "\u00f6": 2, "\U0001F600": 3},
+        Any: []interface{}{-0.0, 100.0, map[string]interface{}{"b": 1e-7, "a": nil}},
+        Raw: json.RawMessage(`{ "y" : 1.50, "x" : "\u0041" }`),
+    }
+    out, err := Encode(v, Canonical | EscapeHTML)
+    require.NoError(t, err)
+    require.Equal(t, "{\"A\":\"<\u2028\\u0001>\",\"Any\":[0,100,{\"a\":null,\"b\":1e-7}],\"Emoji\":{\"\u00f6\":2,\"\U0001F600\":3,\"\ufb33\":1},\"Raw\":{\"x\":\"A\",\"y\":1.5},\"Z\":1e+21}", string(out))
+
+    out, err = Encode(v, Canonical | EscapeNonASCII)
+    require.NoError(t, err)
+    require.Equal(t, `{"A":"<\u2028\u0001>","Any":[0,100,{"a":null,"b":1e-7}],"Emoji":{"\u00f6":2,"\ud83d\ude00":3,"\ufb33":1},"Raw":{"x":"A","y":1.5},"Z":1e+21}`, string(out))
+
+    buf := []byte(`[1]`)
+    require.NoError(t, EncodeInto(&buf, map[string]int{"b": 1, "a": 2}, Canonical))
+    require.Equal(t, `[1]{"a":2,"b":1}`, string(buf))
+
+    /* the caller's buffer is reused */
+    buf = make([]byte, 1, 1024)
+    ptr := &buf[0]
+    require.NoError(t, EncodeInto(&buf, v, Canonical))
+    require.Equal(t, ptr, &buf[0])
+
+    /* the keys of structs and maps are written sorted, only the raw message is left for the post-pass */
+    var raw []byte
+    require.NoError(t, encodeInto(&raw, v, Canonical))
+    require.Equal(t, "{\"A\":\"<\u2028\\u0001>\",\"Any\":[0,100,{\"a\":null,\"b\":1e-7}],\"Emoji\":{\"\u00f6\":2,\"\U0001F600\":3,\"\ufb33\":1},\"Raw\":{ \"y\" : 1.50, \"x\" : \"\\u0041\" },\"Z\":1e+21}", string(raw))
+
+    m, _ := NewFieldMask("Z", "Any")
+    out, err = EncodeMasked(v, m, Canonical)
+    require.NoError(t, err)
+    require.Equal(t, `{"Any":[0,100,{"a":null,"b":1e-7}],"Z":1e+21}`, string(out))
+
+    _, err = Encode("\xff", Canonical)
+    require.Error(t, err)
+    out, err = Encode("\xff", Canonical | ValidateString)
+    require.NoError(t, err)
+    require.Equal(t, "\"\ufffd\"", string(out))
+}
+
 func TestEncodeErrorAndScratchBuf(t *testing.T) {
     var obj = map[string]interface{}{
         "a": json.RawMessage(" [} "),
@@ -211,6 +745,100 @@ func TestMarshalerError(t *testing.T) {
     require.Equal(t, []byte(nil), ret)
 }
 
+type badJSON struct{}
+
+func (badJSON) MarshalJSON() ([]byte, error) {
+    return []byte(`{`), nil
+}
+
+type traceChart struct {
+    Data  []float64
+    Notes map[string]interface{}
+}
+
+type traceSection struct {
+    Name  string
+    Chart *traceChart
+}
+
+type traceReport struct {
+    Sections []traceSection
+    Extra    interface{}
+    Totals   [2]float64
+}
+
+func TestEncoder_ErrorPath(t *testing.T) {
+    ok := &traceChart{Data: []float64{1}}
+    nan := &traceChart{Data: []float64{1, 2, math.NaN()}}
+    _, err := Encode(traceReport{Sections: []traceSection{{Chart: ok}, {Chart: nan}}}, 0)
+    var uv *json.UnsupportedValueError
+    require.ErrorAs(t, err, &uv)
+    require.EqualError(t, err, "json: unsupported value: NaN or ±Infinite at traceReport.Sections[1].Chart.Data[2]")
+
+    _, err = Encode(&traceReport{Totals: [2]float64{0, math.Inf(1)}}, 0)
+    require.EqualError(t, err, "json: unsupported value: NaN or ±Infinite at traceReport.Totals[1]")
+
+    notes := &traceChart{Notes: map[string]interface{}{"a": 1, "b": badJSON{}, "c": 2}}
+    for _, opts := range []Options{0, SortMapKeys} {
+        _, err = Encode(traceReport{Sections: []traceSection{{Chart: notes}}}, opts)
+        var ee *EncodeError
+        require.ErrorAs(t, err, &ee)
+        require.Equal(t, `traceReport.Sections[0].Chart.Notes["b"]`, ee.Path)
+        require.Equal(t, reflect.TypeOf(badJSON{}), ee.Type)
+        require.Contains(t, err.Error(), `json: error encoding traceReport.Sections[0].Chart.Notes["b"]: invalid Marshaler output json syntax`)
+    }
+
+    _, err = Encode(map[int]interface{}{7: []interface{}{1, traceChart{Data: []float64{math.NaN()}}}}, 0)
+    require.EqualError(t, err, "json: unsupported value: NaN or ±Infinite at [7][1].Data[0]")
+
+    _, err = Encode(traceReport{Extra: []interface{}{make(chan int)}}, 0)
+    var ee *EncodeError
+    require.ErrorAs(t, err, &ee)
+    require.Equal(t, "traceReport.Extra[0]", ee.Path)
+    var ut *json.UnsupportedTypeError
+    require.ErrorAs(t, err, &ut)
+
+    /* errors of the root value are returned as is */
+    _, err = Encode(math.NaN(), 0)
+    require.Equal(t, vars.ERR_nan_or_infinite, err)
+    _, err = Encode(traceReport{}, 0)
+    require.NoError(t, err)
+}
+
+type cycleList struct {
+    Next *cycleList
+}
+
+func TestEncoder_DetectCycles(t *testing.T) {
+    l := &cycleList{}
+    l.Next = l
+    m := map[string]interface{}{}
+    m["m"] = m
+    s := []interface{}{nil}
+    s[0] = s
+
+    for _, v := range []interface{}{l, m, s} {
+        _, err := Encode(v, DetectCycles)
+        var uv *json.UnsupportedValueError
+        require.ErrorAs(t, err, &uv)
+        require.True(t, strings.HasPrefix(uv.Str, "encountered a cycle via "), uv.Str)
+
+        /* without the option, it stops at the stack limit */
+        _, err = Encode(v, 0)
+        require.ErrorAs(t, err, &uv)
+        require.True(t, strings.HasPrefix(uv.Str, vars.ERR_too_deep.Str), uv.Str)
+    }
+
+    /* deep values which are not cyclic */
+    var d *cycleList
+    for i := 0; i < 1200; i++ {
+        d = &cycleList{Next: d}
+    }
+    out, err := Encode(d, DetectCycles)
+    require.NoError(t, err)
+    require.Equal(t, strings.Repeat(`{"Next":`, 1200) + "null" + strings.Repeat("}", 1200), string(out))
+}
+
 type RawMessageStruct struct {
     X json.RawMessage
 }
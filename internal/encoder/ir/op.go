@@ -66,6 +66,8 @@ const (
 	OP_is_zero_4
 	OP_is_zero_8
 	OP_is_zero_map
+	OP_is_zero
+	OP_is_zero_fn
 	OP_goto
 	OP_map_iter
 	OP_map_stop
@@ -78,8 +80,26 @@ const (
 	OP_marshal_p
 	OP_marshal_text
 	OP_marshal_text_p
+	OP_marshal_inline
 	OP_cond_set
 	OP_cond_testc
+	OP_union
+	OP_big
+	OP_time
+	OP_duration
+	OP_format
+	OP_i64_js
+	OP_u64_js
+	OP_c64
+	OP_c128
+	OP_unsupported
+	OP_unsupported_skip
+	OP_complex_skip
+	OP_ascii_skip
+	OP_canonical_goto
+	OP_error
+	OP_redact
+	OP_redact_omit
 )
 
 const (
@@ -127,6 +147,8 @@ var OpNames = [256]string{
 	OP_is_zero_4:      "is_zero_4",
 	OP_is_zero_8:      "is_zero_8",
 	OP_is_zero_map:    "is_zero_map",
+	OP_is_zero:        "is_zero",
+	OP_is_zero_fn:     "is_zero_fn",
 	OP_goto:           "goto",
 	OP_map_iter:       "map_iter",
 	OP_map_stop:       "map_stop",
@@ -139,8 +161,26 @@ var OpNames = [256]string{
 	OP_marshal_p:      "marshal_p",
 	OP_marshal_text:   "marshal_text",
 	OP_marshal_text_p: "marshal_text_p",
+	OP_marshal_inline: "marshal_inline",
 	OP_cond_set:       "cond_set",
 	OP_cond_testc:     "cond_testc",
+	OP_union:          "union",
+	OP_big:            "big",
+	OP_time:           "time",
+	OP_duration:       "duration",
+	OP_format:         "format",
+	OP_i64_js:         "i64_js",
+	OP_u64_js:         "u64_js",
+	OP_c64:            "c64",
+	OP_c128:           "c128",
+	OP_unsupported:    "unsupported",
+	OP_unsupported_skip: "unsupported_skip",
+	OP_complex_skip:    "complex_skip",
+	OP_ascii_skip:      "ascii_skip",
+	OP_canonical_goto:  "canonical_goto",
+	OP_error:          "error",
+	OP_redact:         "redact",
+	OP_redact_omit:    "redact_omit",
 }
 
 func (self Op) String() string {
@@ -173,6 +213,28 @@ func OP_uint() Op {
 	}
 }
 
+func OP_int_js() Op {
+	switch _INT_SIZE {
+	case 32:
+		return OP_i32
+	case 64:
+		return OP_i64_js
+	default:
+		panic("unsupported int size")
+	}
+}
+
+func OP_uint_js() Op {
+	switch _INT_SIZE {
+	case 32:
+		return OP_u32
+	case 64:
+		return OP_u64_js
+	default:
+		panic("unsupported uint size")
+	}
+}
+
 func OP_uintptr() Op {
 	switch _PTR_SIZE {
 	case 32:
@@ -229,6 +291,14 @@ type typAndTab struct {
 	itab *rt.GoItab
 }
 
+func NewInsVti(op Op, vt reflect.Type, vi int) Instr {
+	return Instr{
+		o: op,
+		u: vi,
+		p: unsafe.Pointer(rt.UnpackType(vt)),
+	}
+}
+
 func NewInsVtab(op Op, vt reflect.Type, itab *rt.GoItab) Instr {
 	return Instr{
 		o: op,
@@ -322,6 +392,12 @@ func (self Instr) isBranch() bool {
 		fallthrough
 	case OP_is_zero_8:
 		fallthrough
+	case OP_is_zero_map:
+		fallthrough
+	case OP_is_zero:
+		fallthrough
+	case OP_is_zero_fn:
+		fallthrough
 	case OP_map_check_key:
 		fallthrough
 	case OP_map_write_key:
@@ -329,6 +405,20 @@ func (self Instr) isBranch() bool {
 	case OP_slice_next:
 		fallthrough
 	case OP_cond_testc:
+		fallthrough
+	case OP_unsupported_skip:
+		fallthrough
+	case OP_complex_skip:
+		fallthrough
+	case OP_ascii_skip:
+		fallthrough
+	case OP_canonical_goto:
+		fallthrough
+	case OP_error:
+		fallthrough
+	case OP_redact:
+		fallthrough
+	case OP_redact_omit:
 		return true
 	default:
 		return false
@@ -345,8 +435,19 @@ func (self Instr) Disassemble() string {
 		return fmt.Sprintf("%-18s%d", self.Op().String(), self.Vi())
 	case OP_recurse:
 		fallthrough
+	case OP_union:
+		fallthrough
+	case OP_big:
+		fallthrough
+	case OP_unsupported:
+		return fmt.Sprintf("%-18s%s", self.Op().String(), self.Vt())
 	case OP_map_iter:
+		if self.Vi() != 0 {
+			return fmt.Sprintf("%-18s%s, skip %d", self.Op().String(), self.Vt(), self.Vi())
+		}
 		return fmt.Sprintf("%-18s%s", self.Op().String(), self.Vt())
+	case OP_format:
+		return fmt.Sprintf("%-18s%s, %d", self.Op().String(), self.Vt(), self.Vi())
 	case OP_marshal:
 		fallthrough
 	case OP_marshal_p:
@@ -354,8 +455,17 @@ func (self Instr) Disassemble() string {
 	case OP_marshal_text:
 		fallthrough
 	case OP_marshal_text_p:
+		fallthrough
+	case OP_marshal_inline:
 		vt, _ := self.Vtab()
 		return fmt.Sprintf("%-18s%s", self.Op().String(), vt.Pack())
+	case OP_is_zero:
+		fallthrough
+	case OP_redact:
+		return fmt.Sprintf("%-18sL_%d, %s", self.Op().String(), self.Vi(), self.Vt())
+	case OP_is_zero_fn:
+		vt, _ := self.Vtab()
+		return fmt.Sprintf("%-18sL_%d, %s", self.Op().String(), self.Vi(), vt.Pack())
 	case OP_goto:
 		fallthrough
 	case OP_is_nil:
@@ -377,6 +487,18 @@ func (self Instr) Disassemble() string {
 	case OP_map_check_key:
 		fallthrough
 	case OP_map_write_key:
+		fallthrough
+	case OP_unsupported_skip:
+		fallthrough
+	case OP_complex_skip:
+		fallthrough
+	case OP_ascii_skip:
+		fallthrough
+	case OP_canonical_goto:
+		fallthrough
+	case OP_error:
+		fallthrough
+	case OP_redact_omit:
 		return fmt.Sprintf("%-18sL_%d", self.Op().String(), self.Vi())
 	case OP_slice_next:
 		return fmt.Sprintf("%-18sL_%d, %s", self.Op().String(), self.Vi(), self.Vt())
@@ -434,6 +556,10 @@ func (self *Program) Rtt(op Op, vt reflect.Type) {
 	*self = append(*self, NewInsVt(op, vt))
 }
 
+func (self *Program) Rtti(op Op, vt reflect.Type, vi int) {
+	*self = append(*self, NewInsVti(op, vt, vi))
+}
+
 func (self *Program) Vp(op Op, vt reflect.Type, pv bool) {
 	*self = append(*self, NewInsVp(op, vt, pv))
 }
@@ -0,0 +1,369 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"unsafe"
+
+	"github.com/bytedance/sonic/internal/encoder/alg"
+	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/resolver"
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+// FieldMask is a set of the JSON paths to encode, see NewFieldMask.
+type FieldMask = resolver.FieldMask
+
+// NewFieldMask parses the dot-separated JSON paths like `user.name` and `items.*.id`
+// into a mask, where the `*` segment matches every key, and arrays are transparent to the paths.
+func NewFieldMask(paths ...string) (*FieldMask, error) {
+	return resolver.NewFieldMask(paths...)
+}
+
+// EncodeMasked is like Encode, but only writes the parts of val selected by mask,
+// and the rest are skipped without being encoded. A nil mask selects everything.
+func EncodeMasked(val interface{}, mask *FieldMask, opts Options) ([]byte, error) {
+	var buf []byte
+	if err := EncodeMaskedInto(&buf, val, mask, opts); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// EncodeMaskedInto is like EncodeMasked but uses a user-supplied buffer.
+func EncodeMaskedInto(buf *[]byte, val interface{}, mask *FieldMask, opts Options) error {
+	if mask.All() {
+		return EncodeInto(buf, val, opts)
+	}
+
+	/* walk through the selected parts */
+	n := len(*buf)
+	stk := vars.NewStack()
+	enc := maskEncoder{buf: buf, stk: stk, opts: opts}
+	err := enc.encode(reflect.ValueOf(val), mask)
+
+	/* return the stack into pool */
+	if err != nil {
+		vars.ResetStack(stk)
+	}
+	vars.FreeStack(stk)
+	if err != nil {
+		return err
+	}
+	if opts&Canonical != 0 {
+		return encodeCanonical(buf, n, opts)
+	}
+	*buf = encodeFinish(*buf, opts)
+	return nil
+}
+
+type maskEncoder struct {
+	buf  *[]byte
+	stk  *vars.Stack
+	opts Options
+}
+
+type maskEntry struct {
+	key  string
+	val  reflect.Value
+	mask *FieldMask
+}
+
+func (self *maskEncoder) encode(v reflect.Value, m *FieldMask) error {
+	if !v.IsValid() {
+		return alg.EncodeNil(self.buf)
+	} else if m.All() || !maskable(v.Type()) {
+		return self.encodeValue(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return alg.EncodeNil(self.buf)
+		}
+		return self.encode(v.Elem(), m)
+	case reflect.Struct:
+		return self.encodeStruct(v, m)
+	case reflect.Map:
+		return self.encodeMap(v, m)
+	case reflect.Slice, reflect.Array:
+		return self.encodeArray(v, m)
+	default:
+		return self.encodeValue(v)
+	}
+}
+
+// maskable tells whether the mask selects into the values of vt, which are not
+// written by marshalers or natively as a whole, like tuples, unions and optionals.
+func maskable(vt reflect.Type) bool {
+	if vt.Implements(vars.JsonMarshalerType) || vt.Implements(vars.EncodingTextMarshalerType) {
+		return false
+	}
+
+	switch vt.Kind() {
+	case reflect.Ptr:
+		return maskable(vt.Elem())
+	case reflect.Interface:
+		return resolver.FindUnion(vt) == nil
+	case reflect.Struct:
+		pt := reflect.PtrTo(vt)
+		if pt.Implements(vars.JsonMarshalerType) || pt.Implements(vars.EncodingTextMarshalerType) {
+			return false
+		}
+		return !resolver.IsOptional(vt) && !resolver.IsArrayStruct(vt) && !resolver.IsBig(vt) && !resolver.IsTime(vt)
+	case reflect.Map:
+		switch vt.Key().Kind() {
+		case reflect.String:
+			return true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return !vt.Key().Implements(vars.EncodingTextMarshalerType)
+		default:
+			return false
+		}
+	case reflect.Slice:
+		return vt.Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+func (self *maskEncoder) encodeValue(v reflect.Value) error {
+	if v.CanAddr() {
+		return self.encodeAt(v.Type(), unsafe.Pointer(v.UnsafeAddr()), true)
+	}
+	p := reflect.New(v.Type()).Elem()
+	p.Set(v)
+	return self.encodeAt(v.Type(), unsafe.Pointer(p.UnsafeAddr()), false)
+}
+
+func (self *maskEncoder) encodeAt(vt reflect.Type, p unsafe.Pointer, pv bool) error {
+	fv := uint64(self.opts)
+	if pv {
+		fv |= 1 << alg.BitPointerValue
+	}
+	if t := rt.UnpackType(vt); t.Indirect() {
+		return encodeTypedPointer(self.buf, t, &p, self.stk, fv)
+	} else {
+		return encodeTypedPointer(self.buf, t, (*unsafe.Pointer)(p), self.stk, fv)
+	}
+}
+
+func (self *maskEncoder) encodeStruct(v reflect.Value, m *FieldMask) error {
+	pv := v.CanAddr()
+	if !pv {
+		p := reflect.New(v.Type()).Elem()
+		p.Set(v)
+		v = p
+	}
+
+	/* the redacted fields are written as a whole */
+	redact := self.opts&(Redact|RedactHash|RedactOmit) != 0
+	base := unsafe.Pointer(v.UnsafeAddr())
+	*self.buf = append(*self.buf, '{')
+	n := len(*self.buf)
+
+	fvs := resolver.ResolveStruct(v.Type())
+	if _, err := resolver.InlineField(v.Type(), fvs); err != nil {
+		return err
+	}
+
+	/* the fields are written in the order of keys under Canonical */
+	var order []int
+	if self.opts&Canonical != 0 {
+		order = canonicalOrder(fvs)
+	}
+	for k := 0; k < len(fvs); k++ {
+		var c *FieldMask
+		fv := &fvs[k]
+		if order != nil {
+			fv = &fvs[order[k]]
+		}
+
+		/* the catch-all field has no name to select */
+		if (fv.Opts & resolver.F_inline) != 0 {
+			c = m.Child("*")
+		} else {
+			c = m.Child(fv.Name)
+		}
+		if c == nil {
+			continue
+		}
+
+		/* fields of embedded nil pointers are absent */
+		fp := fieldPointer(base, fv.Path)
+		if fp == nil {
+			continue
+		}
+
+		/* add the comma if not the first field */
+		s := len(*self.buf)
+		if s != n {
+			*self.buf = append(*self.buf, ',')
+		}
+
+		/* the whole field is written with all its options */
+		if c.All() || !maskable(fv.Type) || (fv.Opts&resolver.F_inline) != 0 || (redact && (fv.Opts&resolver.F_redact) != 0) {
+			if ok, err := self.encodeField(fv, fp, pv); err != nil {
+				return err
+			} else if !ok {
+				*self.buf = (*self.buf)[:s]
+			}
+			continue
+		}
+
+		/* otherwise select into the field */
+		fval := reflect.NewAt(fv.Type, fp).Elem()
+		if isEmptyField(fv, fval) {
+			*self.buf = (*self.buf)[:s]
+			continue
+		}
+		*self.buf = alg.QuoteOpt(*self.buf, fv.Name, false, uint64(self.opts))
+		*self.buf = append(*self.buf, ':')
+		if err := self.encode(fval, c); err != nil {
+			return err
+		}
+	}
+
+	*self.buf = append(*self.buf, '}')
+	return nil
+}
+
+// encodeField writes the field at fp as its single-field struct, and strips the
+// braces of the output, it returns false if the field is omitted.
+func (self *maskEncoder) encodeField(fv *resolver.FieldMeta, fp unsafe.Pointer, pv bool) (bool, error) {
+	i := len(*self.buf)
+	if err := self.encodeAt(resolver.FieldStruct(fv), fp, pv); err != nil {
+		return false, err
+	}
+
+	/* `{}` means the field is omitted */
+	buf := *self.buf
+	if len(buf) - i <= 2 {
+		*self.buf = buf[:i]
+		return false, nil
+	}
+	copy(buf[i:], buf[i + 1:len(buf) - 1])
+	*self.buf = buf[:len(buf) - 2]
+	return true, nil
+}
+
+func fieldPointer(p unsafe.Pointer, path []resolver.Offset) unsafe.Pointer {
+	for _, o := range path {
+		if p = rt.Add(p, o.Size); o.Kind == resolver.F_deref {
+			if p = *(*unsafe.Pointer)(p); p == nil {
+				return nil
+			}
+		}
+	}
+	return p
+}
+
+func isEmptyField(fv *resolver.FieldMeta, v reflect.Value) bool {
+	if (fv.Opts & resolver.F_omitempty) != 0 {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			return v.IsNil()
+		case reflect.Map, reflect.Slice, reflect.Array:
+			return v.Len() == 0
+		}
+	}
+	if (fv.Opts & resolver.F_omitzero) != 0 {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return true
+		} else if z, ok := v.Interface().(vars.IsZeroer); ok {
+			return z.IsZero()
+		} else if z, ok := v.Addr().Interface().(vars.IsZeroer); ok {
+			return z.IsZero()
+		}
+		return v.IsZero()
+	}
+	return false
+}
+
+func (self *maskEncoder) encodeMap(v reflect.Value, m *FieldMask) error {
+	if v.IsNil() {
+		if self.opts&NoNullSliceOrMap != 0 {
+			*self.buf = append(*self.buf, "{}"...)
+			return nil
+		}
+		return alg.EncodeNil(self.buf)
+	}
+
+	/* collect the selected entries */
+	var kvs []maskEntry
+	for it := v.MapRange(); it.Next(); {
+		var key string
+		switch k := it.Key(); k.Kind() {
+		case reflect.String:
+			key = k.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key = strconv.FormatInt(k.Int(), 10)
+		default:
+			key = strconv.FormatUint(k.Uint(), 10)
+		}
+		if c := m.Child(key); c != nil {
+			kvs = append(kvs, maskEntry{key: key, val: it.Value(), mask: c})
+		}
+	}
+
+	if self.opts&SortMapKeys != 0 {
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].key < kvs[j].key })
+	}
+
+	*self.buf = append(*self.buf, '{')
+	for i, kv := range kvs {
+		if i != 0 {
+			*self.buf = append(*self.buf, ',')
+		}
+		*self.buf = alg.QuoteOpt(*self.buf, kv.key, false, uint64(self.opts))
+		*self.buf = append(*self.buf, ':')
+		if err := self.encode(kv.val, kv.mask); err != nil {
+			return err
+		}
+	}
+	*self.buf = append(*self.buf, '}')
+	return nil
+}
+
+func (self *maskEncoder) encodeArray(v reflect.Value, m *FieldMask) error {
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		if self.opts&NoNullSliceOrMap != 0 {
+			*self.buf = append(*self.buf, "[]"...)
+			return nil
+		}
+		return alg.EncodeNil(self.buf)
+	}
+
+	c := m.Elem()
+	*self.buf = append(*self.buf, '[')
+	for i := 0; i < v.Len(); i++ {
+		if i != 0 {
+			*self.buf = append(*self.buf, ',')
+		}
+		if err := self.encode(v.Index(i), c); err != nil {
+			return err
+		}
+	}
+	*self.buf = append(*self.buf, ']')
+	return nil
+}
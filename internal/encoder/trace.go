@@ -0,0 +1,310 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package encoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/bytedance/sonic/internal/encoder/alg"
+	"github.com/bytedance/sonic/internal/encoder/vars"
+	"github.com/bytedance/sonic/internal/resolver"
+	"github.com/bytedance/sonic/internal/rt"
+)
+
+// maxTraceSegments limits the length of the reported path, the middle
+// of longer paths (mostly from cyclic values) is elided.
+const maxTraceSegments = 32
+
+// tracer rebuilds the Go path of the value that failed to encode. The
+// encoders save a state for each container they enter, so walking the
+// value along the types and matching the saved pointers against the
+// memory layout tells which field, index or key was being written.
+type tracer struct {
+	st  []vars.State
+	ep  unsafe.Pointer
+	seg []string
+}
+
+// withPath annotates err with the path to the failed value, the root
+// value itself is not worth mentioning and the error is left as is.
+func withPath(err error, efv *rt.GoEface, sb *vars.Stack) error {
+	st, ep := sb.Trace()
+	if ep == nil || efv.Type == nil {
+		return err
+	}
+
+	/* find the failed value from the root */
+	vp := efv.Value
+	if !efv.Type.Indirect() {
+		vp = unsafe.Pointer(&efv.Value)
+	}
+	tr := tracer{st: st, ep: ep}
+	vt := tr.walk(efv.Type.Pack(), vp, false)
+	if len(tr.seg) == 0 {
+		return err
+	}
+
+	/* keep the error type that encoding/json users check for */
+	path := tr.path(efv.Type.Pack())
+	if e, ok := err.(*json.UnsupportedValueError); ok {
+		return &json.UnsupportedValueError{Value: e.Value, Str: e.Str + " at " + path}
+	}
+	return &vars.EncodeError{Path: path, Type: vt, Err: err}
+}
+
+func (self *tracer) path(vt reflect.Type) string {
+	for vt.Kind() == reflect.Ptr {
+		vt = vt.Elem()
+	}
+
+	/* elide the middle of deep paths */
+	seg := self.seg
+	if len(seg) > maxTraceSegments {
+		n := maxTraceSegments / 2
+		tail := seg[len(seg)-n:]
+		seg = append(append(seg[:n:n], "..."+strings.TrimPrefix(tail[0], ".")), tail[1:]...)
+	}
+
+	/* unnamed root types start with the first field or index */
+	return strings.TrimPrefix(vt.Name()+strings.Join(seg, ""), ".")
+}
+
+// next returns the pointer of the next saved state, or the failed value.
+func (self *tracer) next() unsafe.Pointer {
+	if len(self.st) != 0 {
+		return self.st[0].Ptr()
+	}
+	return self.ep
+}
+
+// enter consumes the state saved when entering the container at p.
+func (self *tracer) enter(p unsafe.Pointer) (*vars.State, bool) {
+	if len(self.st) == 0 || self.st[0].Ptr() != p {
+		return nil, false
+	}
+	st := &self.st[0]
+	self.st = self.st[1:]
+	return st, true
+}
+
+// walk follows the saved states from the value at p, and returns the type
+// of the deepest value they lead to.
+func (self *tracer) walk(vt reflect.Type, p unsafe.Pointer, pv bool) reflect.Type {
+	for {
+		if isMarshaler(vt, pv) {
+			return vt
+		}
+
+		switch vt.Kind() {
+		case reflect.Ptr:
+			if _, ok := self.enter(p); !ok {
+				return vt
+			}
+			if p = *(*unsafe.Pointer)(p); p == nil {
+				return vt
+			}
+			vt, pv = vt.Elem(), true
+		case reflect.Interface:
+			dt, dp := dynamic(vt, p)
+			if dt == nil {
+				return vt
+			}
+			vt, p = dt, dp
+		case reflect.Struct:
+			if _, ok := self.enter(p); !ok {
+				return vt
+			}
+			if resolver.IsOptional(vt) {
+				et, off := resolver.OptionalValue(vt)
+				vt, p = et, rt.Add(p, off)
+				continue
+			}
+			name, ft, fp := self.field(vt, p)
+			if ft == nil {
+				return vt
+			}
+			self.seg = append(self.seg, "."+name)
+			vt, p = ft, fp
+		case reflect.Slice:
+			if vars.IsSimpleByte(vt.Elem()) {
+				return vt
+			}
+			if _, ok := self.enter(p); !ok {
+				return vt
+			}
+			sl := (*rt.GoSlice)(p)
+			i, ep := self.index(vt.Elem(), sl.Ptr, sl.Len)
+			if ep == nil {
+				return vt
+			}
+			self.seg = append(self.seg, "["+strconv.Itoa(i)+"]")
+			vt, p, pv = vt.Elem(), ep, true
+		case reflect.Array:
+			if _, ok := self.enter(p); !ok {
+				return vt
+			}
+			i, ep := self.index(vt.Elem(), p, vt.Len())
+			if ep == nil {
+				return vt
+			}
+			self.seg = append(self.seg, "["+strconv.Itoa(i)+"]")
+			vt, p = vt.Elem(), ep
+		case reflect.Map:
+			if _, ok := self.enter(p); !ok {
+				return vt
+			}
+			st, ok := self.enter(p)
+			if !ok || st.Iter() == nil {
+				return vt
+			}
+			it := (*alg.MapIterator)(st.Iter())
+			k, v, sorted := it.Entry()
+
+			/* the key itself may be the failed one */
+			if !sorted && it.It.K != nil && self.next() == it.It.K {
+				self.seg = append(self.seg, "["+mapKey(vt.Key(), it.It.K, false)+"]")
+				return vt.Key()
+			}
+			if k == nil {
+				return vt
+			}
+			self.seg = append(self.seg, "["+mapKey(vt.Key(), k, sorted)+"]")
+			vt, p, pv = vt.Elem(), v, false
+		default:
+			return vt
+		}
+	}
+}
+
+// field finds the struct field holding the next saved state.
+func (self *tracer) field(vt reflect.Type, p unsafe.Pointer) (string, reflect.Type, unsafe.Pointer) {
+	x := self.next()
+	for _, fv := range resolver.ResolveStruct(vt) {
+		fp := p
+		for _, o := range fv.Path {
+			if fp = rt.Add(fp, o.Size); o.Kind == resolver.F_deref {
+				if fp = *(*unsafe.Pointer)(fp); fp == nil {
+					break
+				}
+			}
+		}
+		if fp == nil || !holds(fv.Type, fp, x) {
+			continue
+		}
+		if sf, ok := resolver.FieldByName(vt, fv.Name); ok {
+			return sf.Name, fv.Type, fp
+		}
+		return fv.Name, fv.Type, fp
+	}
+	return "", nil, nil
+}
+
+// index finds the element holding the next saved state.
+func (self *tracer) index(et reflect.Type, p unsafe.Pointer, n int) (int, unsafe.Pointer) {
+	x := self.next()
+	sz := et.Size()
+	if sz == 0 {
+		return 0, nil
+	}
+
+	/* elements are laid out one by one */
+	if d := uintptr(x) - uintptr(p); uintptr(x) >= uintptr(p) && d < uintptr(n)*sz {
+		i := int(d / sz)
+		return i, rt.Add(p, uintptr(i)*sz)
+	}
+
+	/* the values of interfaces live elsewhere */
+	if et.Kind() == reflect.Interface {
+		for i := 0; i < n; i++ {
+			if ep := rt.Add(p, uintptr(i)*sz); holds(et, ep, x) {
+				return i, ep
+			}
+		}
+	}
+	return 0, nil
+}
+
+// holds tells whether x points into the value of type vt at p.
+func holds(vt reflect.Type, p unsafe.Pointer, x unsafe.Pointer) bool {
+	if sz := vt.Size(); sz != 0 && uintptr(x) >= uintptr(p) && uintptr(x)-uintptr(p) < sz {
+		return true
+	}
+	if vt.Kind() != reflect.Interface {
+		return false
+	}
+	dt, dp := dynamic(vt, p)
+	return dt != nil && dp == x
+}
+
+// dynamic returns the dynamic type of the interface at p, and the pointer
+// to its value as the encoders see it.
+func dynamic(vt reflect.Type, p unsafe.Pointer) (reflect.Type, unsafe.Pointer) {
+	var dt *rt.GoType
+	if vt.NumMethod() == 0 {
+		dt = (*rt.GoEface)(p).Type
+	} else if it := (*rt.GoIface)(p).Itab; it != nil {
+		dt = it.Vt
+	}
+
+	/* nil interfaces are written as "null" */
+	if dt == nil {
+		return nil, nil
+	}
+	vp := unsafe.Pointer(&(*rt.GoEface)(p).Value)
+	if dt.Indirect() {
+		return dt.Pack(), *(*unsafe.Pointer)(vp)
+	}
+	return dt.Pack(), vp
+}
+
+func isMarshaler(vt reflect.Type, pv bool) bool {
+	if resolver.IsOptional(vt) {
+		return false
+	}
+	if vt.Implements(vars.JsonMarshalerType) || vt.Implements(vars.EncodingTextMarshalerType) {
+		return true
+	}
+	pt := reflect.PtrTo(vt)
+	return pv && (pt.Implements(vars.JsonMarshalerType) || pt.Implements(vars.EncodingTextMarshalerType))
+}
+
+// mapKey formats the map key at k, which is already a string if sorted.
+func mapKey(kt reflect.Type, k unsafe.Pointer, sorted bool) string {
+	switch kt.Kind() {
+	case reflect.String:
+		return strconv.Quote(*(*string)(k))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if sorted {
+			return *(*string)(k)
+		}
+		return strconv.FormatInt(reflect.NewAt(kt, k).Elem().Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if sorted {
+			return *(*string)(k)
+		}
+		return strconv.FormatUint(reflect.NewAt(kt, k).Elem().Uint(), 10)
+	}
+	if sorted {
+		return strconv.Quote(*(*string)(k))
+	}
+	return fmt.Sprint(reflect.NewAt(kt, k).Elem().Interface())
+}
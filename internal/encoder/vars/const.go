@@ -26,6 +26,7 @@ const (
 	StackSize = unsafe.Sizeof(Stack{})
 	StateSize  = int64(unsafe.Sizeof(State{}))
 	StackLimit = MaxStack * StateSize
+	StackFail  = int64(unsafe.Offsetof(Stack{}.ep))
 )
 
 const (
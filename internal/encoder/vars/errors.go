@@ -47,10 +47,45 @@ func Error_number(number json.Number) error {
     }
 }
 
+// EncodeError is an error raised while encoding a nested value, Path is the
+// Go path of that value, like `Report.Sections[4].Chart.Data`, and Type is
+// its Go type.
+type EncodeError struct {
+    Path string
+    Type reflect.Type
+    Err  error
+}
+
+func (self *EncodeError) Error() string {
+    return "json: error encoding " + self.Path + ": " + self.Err.Error()
+}
+
+func (self *EncodeError) Unwrap() error {
+    return self.Err
+}
+
+func Error_union(vt reflect.Type, mt reflect.Type) error {
+    return &json.UnsupportedValueError {
+        Str   : fmt.Sprintf("%s is not a registered member of union %s", mt, vt),
+        Value : reflect.ValueOf(mt.String()),
+    }
+}
+
+func Error_union_key(vt reflect.Type, mt reflect.Type, key string, name string) error {
+    return &json.UnsupportedValueError {
+        Str   : fmt.Sprintf("the key of member %s of union %s is %q instead of %q", mt, vt, key, name),
+        Value : reflect.ValueOf(key),
+    }
+}
+
 func Error_marshaler(ret []byte, pos int) error {
     return fmt.Errorf("invalid Marshaler output json syntax at %d: %q", pos, ret)
 }
 
+func Error_canonical(pos int, msg string) error {
+    return fmt.Errorf("cannot canonicalize json at %d: %s", pos, msg)
+}
+
 const (
     PanicNilPointerOfNonEmptyString int = 1 + iota
 )
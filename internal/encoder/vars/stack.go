@@ -18,6 +18,8 @@ package vars
 
 import (
 	"bytes"
+	"encoding/json"
+	"reflect"
 	"sync"
 	"unsafe"
 
@@ -36,6 +38,16 @@ type State struct {
 type Stack struct {
 	sp uintptr
 	sb [MaxStack]State
+	ep unsafe.Pointer // the value being encoded when an error was raised
+	vs map[visit]struct{}
+}
+
+// visit identifies a value being encoded, slices are told apart by their
+// lengths as well since they share the backing arrays.
+type visit struct {
+	p  unsafe.Pointer
+	n  int
+	vt *rt.GoType
 }
 
 var (
@@ -65,6 +77,7 @@ func NewStack() *Stack {
 }
 
 func ResetStack(p *Stack) {
+	p.vs = nil
 	rt.MemclrNoHeapPointers(unsafe.Pointer(p), StackSize)
 }
 
@@ -110,6 +123,71 @@ func (s *Stack) Drop() (int, uint64, unsafe.Pointer, unsafe.Pointer) {
 	return st.x, st.f, st.p, st.q
 }
 
+// Fail records p as the value being encoded when err is raised, unless
+// a nested encoder has already recorded a deeper one.
+func (s *Stack) Fail(p unsafe.Pointer, err error) error {
+	if s.ep == nil {
+		s.ep = p
+	}
+	return err
+}
+
+// Deep tells whether the encoding is nested deep enough to look for cycles.
+func (s *Stack) Deep() bool {
+	return s.sp >= uintptr(option.CycleDetectionDepth)*uintptr(StateSize)
+}
+
+// Visit marks the value of type vt at vp as being encoded, and fails if it
+// already is, which means that the value refers to itself.
+func (s *Stack) Visit(vt *rt.GoType, vp *unsafe.Pointer) error {
+	k := visitOf(vt, vp)
+	if _, ok := s.vs[k]; ok {
+		return &json.UnsupportedValueError{
+			Value: reflect.NewAt(vt.Pack(), valueOf(vt, vp)).Elem(),
+			Str:   "encountered a cycle via " + vt.String(),
+		}
+	}
+	if s.vs == nil {
+		s.vs = make(map[visit]struct{})
+	}
+	s.vs[k] = struct{}{}
+	return nil
+}
+
+// Leave unmarks the value marked by Visit once it is encoded.
+func (s *Stack) Leave(vt *rt.GoType, vp *unsafe.Pointer) {
+	delete(s.vs, visitOf(vt, vp))
+}
+
+func visitOf(vt *rt.GoType, vp *unsafe.Pointer) visit {
+	if vt.Kind() == reflect.Slice {
+		sl := (*rt.GoSlice)(*vp)
+		return visit{p: sl.Ptr, n: sl.Len, vt: vt}
+	}
+	return visit{p: *vp, vt: vt}
+}
+
+func valueOf(vt *rt.GoType, vp *unsafe.Pointer) unsafe.Pointer {
+	if vt.Indirect() {
+		return *vp
+	}
+	return unsafe.Pointer(vp)
+}
+
+// Trace returns the states saved so far and the value recorded by Fail,
+// they are left in place by a failed encoding until the stack is reset.
+func (s *Stack) Trace() ([]State, unsafe.Pointer) {
+	return s.sb[:s.sp/uintptr(StateSize)], s.ep
+}
+
+func (s *State) Ptr() unsafe.Pointer {
+	return s.p
+}
+
+func (s *State) Iter() unsafe.Pointer {
+	return s.q
+}
+
 func NewBuffer() *bytes.Buffer {
 	if ret := bufferPool.Get(); ret != nil {
 		return ret.(*bytes.Buffer)
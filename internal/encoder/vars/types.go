@@ -32,8 +32,14 @@ var (
     ErrorType                 = reflect.TypeOf((*error)(nil)).Elem()
     JsonMarshalerType         = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
     EncodingTextMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+    IsZeroerType              = reflect.TypeOf((*IsZeroer)(nil)).Elem()
 )
 
+// IsZeroer is implemented by types that report their own zero-ness for the "omitzero" option.
+type IsZeroer interface {
+    IsZero() bool
+}
+
 func IsSimpleByte(vt reflect.Type) bool {
     if vt.Kind() != ByteType.Kind() {
         return false
@@ -83,7 +83,7 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 			x, _, p, q = s.Load() 
 		case ir.OP_save:
 			if !s.Save(x, f, p, q) {
-				return vars.ERR_too_deep
+				return s.Fail(p, vars.ERR_too_deep)
 			}
 		case ir.OP_drop:
 			x, f, p, q = s.Drop()
@@ -99,12 +99,12 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 			*b = buf
 			if vt.Indirect() {
 				if err := EncodeTypedPointer(b, vt, (*unsafe.Pointer)(rt.NoEscape(unsafe.Pointer(&p))), s, f); err != nil {
-					return err
+					return s.Fail(p, err)
 				}
 			} else {
 				vp := (*unsafe.Pointer)(p)
 				if err := EncodeTypedPointer(b, vt, vp, s, f); err != nil {
-					return err
+					return s.Fail(p, err)
 				}
 			}
 			buf = *b
@@ -122,7 +122,7 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 			buf = append(buf, 'n', 'u', 'l', 'l')
 		case ir.OP_str:
 			v := *(*string)(p)
-			buf = alg.Quote(buf, v, false)
+			buf = alg.QuoteOpt(buf, v, false, flags)
 		case ir.OP_bool:
 			if *(*bool)(p) {
 				buf = append(buf, 't', 'r', 'u', 'e')
@@ -153,24 +153,109 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 		case ir.OP_u64:
 			v := *(*uint64)(p)
 			buf = alg.U64toa(buf, uint64(v))
+		case ir.OP_c64:
+			*b = buf
+			if err := alg.EncodeComplex64(b, p, flags); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_c128:
+			*b = buf
+			if err := alg.EncodeComplex128(b, p, flags); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_unsupported:
+			*b = buf
+			if err := alg.EncodeUnsupported(b, ins.Vr(), flags); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_unsupported_skip:
+			if flags&(1<<alg.BitUnsupportedSkip) != 0 {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_complex_skip:
+			if flags&(1<<alg.BitUnsupportedSkip) != 0 && flags&(1<<alg.BitComplexArray) == 0 {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_ascii_skip:
+			if flags&(1<<alg.BitEscapeNonASCII) == 0 {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_canonical_goto:
+			if flags&(1<<alg.BitCanonical) != 0 {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_error:
+			if flags&(1<<alg.BitErrorString) != 0 {
+				*b = buf
+				if err := alg.EncodeErrorString(b, p, flags); err != nil {
+					return s.Fail(p, err)
+				}
+				buf = *b
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_redact:
+			if flags&alg.RedactMask != 0 {
+				*b = buf
+				if err := EncodeRedacted(b, ins.Vr(), p, s, flags); err != nil {
+					return s.Fail(p, err)
+				}
+				buf = *b
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_redact_omit:
+			if flags&(1<<alg.BitRedactOmit) != 0 {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_i64_js:
+			*b = buf
+			alg.EncodeI64(b, p, flags)
+			buf = *b
+		case ir.OP_u64_js:
+			*b = buf
+			alg.EncodeU64(b, p, flags)
+			buf = *b
 		case ir.OP_f32:
+			if flags&alg.FloatFormatMask != 0 {
+				var err error
+				if buf, err = alg.AppendFloat(buf, float64(*(*float32)(p)), 32, flags); err != nil {
+					return s.Fail(p, err)
+				}
+				continue
+			}
 			v := *(*float32)(p)
 			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
 				if flags&(1<<alg.BitEncodeNullForInfOrNan) != 0 {
 					buf = append(buf, 'n', 'u', 'l', 'l')
 					continue
 				}
-				return vars.ERR_nan_or_infinite
+				return s.Fail(p, vars.ERR_nan_or_infinite)
 			}
 			buf = alg.F32toa(buf, v)
 		case ir.OP_f64:
+			if flags&alg.FloatFormatMask != 0 {
+				var err error
+				if buf, err = alg.AppendFloat(buf, *(*float64)(p), 64, flags); err != nil {
+					return s.Fail(p, err)
+				}
+				continue
+			}
 			v := *(*float64)(p)
 			if math.IsNaN(v) || math.IsInf(v, 0) {
 				if flags&(1<<alg.BitEncodeNullForInfOrNan) != 0 {
 					buf = append(buf, 'n', 'u', 'l', 'l')
 					continue
 				}
-				return vars.ERR_nan_or_infinite
+				return s.Fail(p, vars.ERR_nan_or_infinite)
 			}
 			buf = alg.F64toa(buf, v)
 		case ir.OP_bin:
@@ -178,40 +263,83 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 			buf = rt.EncodeBase64(buf, v)
 		case ir.OP_quote:
 			v := *(*string)(p)
-			buf = alg.Quote(buf, v, true)
+			buf = alg.QuoteOpt(buf, v, true, flags)
 		case ir.OP_number:
 			v := *(*json.Number)(p)
 			if v == "" {
 				buf = append(buf, '0')
 			} else if !rt.IsValidNumber(string(v)) {
-				return vars.Error_number(v)
+				return s.Fail(p, vars.Error_number(v))
 			} else {
 				buf = append(buf, v...)
 			}
 		case ir.OP_eface:
 			*b = buf
 			if err := EncodeTypedPointer(b, *(**rt.GoType)(p), (*unsafe.Pointer)(rt.Add(p, 8)), s, flags); err != nil {
-				return err
+				return s.Fail(p, err)
 			}
 			buf = *b
 		case ir.OP_iface:
 			*b = buf
 			if err := EncodeTypedPointer(b,  (*(**rt.GoItab)(p)).Vt, (*unsafe.Pointer)(rt.Add(p, 8)), s, flags); err != nil {
-				return err
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_union:
+			*b = buf
+			if err := EncodeUnion(b, ins.Vr(), p, s, flags); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_big:
+			*b = buf
+			if err := alg.EncodeBig(b, ins.Vr(), p); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_time:
+			*b = buf
+			if err := alg.EncodeTime(b, p, flags); err != nil {
+				return s.Fail(p, err)
 			}
 			buf = *b
+		case ir.OP_format:
+			*b = buf
+			if err := alg.EncodeFormat(b, ins.Vr(), p, ins.Vi(), flags); err != nil {
+				return s.Fail(p, err)
+			}
+			buf = *b
+		case ir.OP_duration:
+			if flags&(1<<alg.BitDurationString) != 0 {
+				*b = buf
+				alg.EncodeDuration(b, p, flags)
+				buf = *b
+			} else {
+				buf = alg.I64toa(buf, *(*int64)(p))
+			}
 		case ir.OP_is_zero_map:
 			v := *(**rt.GoMap)(p)
 			if v == nil || v.Count == 0 {
 				pc = ins.Vi()
 				continue
 			}
+		case ir.OP_is_zero:
+			if alg.IsZero(p, ins.Vr()) {
+				pc = ins.Vi()
+				continue
+			}
+		case ir.OP_is_zero_fn:
+			vt, itab := ins.Vtab()
+			if alg.IsZeroer(p, vt, itab) {
+				pc = ins.Vi()
+				continue
+			}
 		case ir.OP_map_iter:
 			v := *(**rt.GoMap)(p)
 			vt := ins.Vr()
-			it, err := alg.IteratorStart(rt.MapType(vt), v, flags)
+			it, err := alg.IteratorStart(rt.MapType(vt), v, flags, ins.Vi())
 			if err != nil {
-				return err
+				return s.Fail(p, err)
 			}
 			q = unsafe.Pointer(it)
 		case ir.OP_map_stop:
@@ -243,18 +371,18 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 				default                       : it = convT2I(p, !vt.Indirect(), itab)
 			}
 			if err := alg.EncodeTextMarshaler(&buf, *(*encoding.TextMarshaler)(unsafe.Pointer(&it)), (flags)); err != nil {
-				return err
+				return s.Fail(p, err)
 			}
 		case ir.OP_marshal_text_p:
 			_, itab := ins.Vtab()
 			it := convT2I(p, false, itab)
 			if err := alg.EncodeTextMarshaler(&buf, *(*encoding.TextMarshaler)(unsafe.Pointer(&it)), (flags)); err != nil {
-				return err
+				return s.Fail(p, err)
 			}
 		case ir.OP_map_write_key:
 			if has_opts(flags, alg.BitSortMapKeys) {
 				v := *(*string)(p)
-				buf = alg.Quote(buf, v, false)
+				buf = alg.QuoteOpt(buf, v, false, flags)
 				pc = ins.Vi()
 				continue
 			}
@@ -329,13 +457,23 @@ func Execute(b *[]byte, p unsafe.Pointer, s *vars.Stack, flags uint64, prog *ir.
 				default                       : it = convT2I(p, !vt.Indirect(), itab)
 			}
 			if err := alg.EncodeJsonMarshaler(&buf, *(*json.Marshaler)(unsafe.Pointer(&it)), (flags)); err != nil {
-				return err
+				return s.Fail(p, err)
+			}
+		case ir.OP_marshal_inline:
+			vt, itab := ins.Vtab()
+			it := convT2I(p, itab.Vt == vt && !vt.Indirect(), itab)
+			n := len(buf)
+			if err := alg.EncodeInlineMarshaler(&buf, *(*json.Marshaler)(unsafe.Pointer(&it)), flags, has_opts(f, _S_cond)); err != nil {
+				return s.Fail(p, err)
+			}
+			if len(buf) != n {
+				f &= ^uint64(1 << _S_cond)
 			}
 		case ir.OP_marshal_p:
 			_, itab := ins.Vtab()
 			it := convT2I(p, false, itab)
 			if err := alg.EncodeJsonMarshaler(&buf, *(*json.Marshaler)(unsafe.Pointer(&it)), (flags)); err != nil {
-				return err
+				return s.Fail(p, err)
 			}
 		default:
 			panic(fmt.Sprintf("not implement %s at %d", ins.Op().String(), pc))
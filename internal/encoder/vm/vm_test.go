@@ -16,8 +16,12 @@ package vm_test
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,3 +69,254 @@ func TestCorpusMarshal(t *testing.T) {
     require.Equal(t, jerr == nil, serr == nil)
     require.Equal(t, string(jout), string(sout))
 }
+
+type zeroByPtr struct {
+    V int
+}
+
+func (z *zeroByPtr) IsZero() bool { return z.V == 42 }
+
+func TestEncoder_OmitZero(t *testing.T) {
+    var v struct {
+        I int          `json:"i,omitzero"`
+        T time.Time    `json:"t,omitzero"`
+        R zeroByPtr    `json:"r,omitzero"`
+        P *zeroByPtr   `json:"p,omitzero"`
+        A [1]string    `json:"a,omitzero"`
+    }
+    out, err := encoder.Encode(v, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"r":{"V":0}}`, string(out))
+
+    v.I, v.R, v.P, v.A[0] = 1, zeroByPtr{42}, &zeroByPtr{42}, "x"
+    out, err = encoder.Encode(v, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"i":1,"a":["x"]}`, string(out))
+}
+
+type inlineRaw string
+
+func (r inlineRaw) MarshalJSON() ([]byte, error) { return []byte(r), nil }
+
+func TestEncoder_Inline(t *testing.T) {
+    var v struct {
+        M map[string]int `json:",inline"`
+        A int            `json:"a"`
+    }
+    out, err := encoder.Encode(v, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"a":0}`, string(out))
+
+    v.M = map[string]int{"y": 2, "x": 1}
+    out, err = encoder.Encode(v, encoder.SortMapKeys)
+    require.NoError(t, err)
+    require.Equal(t, `{"x":1,"y":2,"a":0}`, string(out))
+
+    /* keys of the named fields are left out */
+    v.M = map[string]int{"a": 2}
+    for _, opts := range []encoder.Options{0, encoder.SortMapKeys} {
+        out, err = encoder.Encode(v, opts)
+        require.NoError(t, err)
+        require.Equal(t, `{"a":0}`, string(out))
+    }
+    v.M = map[string]int{"a": 2, "b": 3}
+    out, err = encoder.Encode(v, encoder.SortMapKeys)
+    require.NoError(t, err)
+    require.Equal(t, `{"b":3,"a":0}`, string(out))
+
+    var r struct {
+        A int       `json:"a"`
+        R inlineRaw `json:",inline"`
+    }
+    r.R = `{}`
+    out, err = encoder.Encode(r, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"a":0}`, string(out))
+
+    r.R = `{"r":true}`
+    out, err = encoder.Encode(r, 0)
+    require.NoError(t, err)
+    require.Equal(t, `{"a":0,"r":true}`, string(out))
+
+    r.R = `[1]`
+    _, err = encoder.Encode(r, 0)
+    require.Error(t, err)
+}
+
+type cycleNode struct {
+    Name string
+    Next *cycleNode
+}
+
+func TestEncoder_ErrorPath(t *testing.T) {
+    var v struct {
+        Items []struct {
+            Tags  map[string]interface{}
+            Price float64
+        }
+    }
+    v.Items = make([]struct {
+        Tags  map[string]interface{}
+        Price float64
+    }, 3)
+    v.Items[2].Price = math.NaN()
+    _, err := encoder.Encode(&v, 0)
+    require.EqualError(t, err, "json: unsupported value: NaN or ±Infinite at Items[2].Price")
+
+    v.Items[2].Price = 0
+    v.Items[1].Tags = map[string]interface{}{"x": json.Number("1x")}
+    _, err = encoder.Encode(v, encoder.SortMapKeys)
+    require.EqualError(t, err, `json: unsupported value: invalid number literal: "1x" at Items[1].Tags["x"]`)
+
+    n := &cycleNode{Name: "a"}
+    n.Next = n
+    _, err = encoder.Encode(n, 0)
+    var uv *json.UnsupportedValueError
+    require.ErrorAs(t, err, &uv)
+    require.True(t, strings.HasPrefix(uv.Str, "Value nesting too deep at cycleNode.Next.Next"), uv.Str)
+    require.True(t, strings.Contains(uv.Str, ".Next...Next.Next"), uv.Str)
+}
+
+func TestEncoder_DetectCycles(t *testing.T) {
+    n := &cycleNode{Name: "a"}
+    n.Next = &cycleNode{Name: "b", Next: n}
+    _, err := encoder.Encode(n, encoder.DetectCycles)
+    var uv *json.UnsupportedValueError
+    require.ErrorAs(t, err, &uv)
+    require.True(t, strings.HasPrefix(uv.Str, "encountered a cycle via *vm_test.cycleNode at cycleNode.Next.Next"), uv.Str)
+}
+
+type arrayTuple struct{}
+
+type arrayPair struct {
+    arrayTuple `json:",array"`
+    K string
+    V *int      `json:"v,omitempty"`
+}
+
+func TestEncoder_ArrayStruct(t *testing.T) {
+    v := 1
+    r, e := encoder.Encode([]arrayPair{{K: "a", V: &v}, {K: "b"}}, 0)
+    require.NoError(t, e)
+    require.Equal(t, `[["a",1],["b",null]]`, string(r))
+}
+
+func TestEncoder_Time(t *testing.T) {
+    tm := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+    v := map[string]interface{}{"t": tm, "d": 90 * time.Second}
+    r, e := encoder.Encode(v, encoder.SortMapKeys)
+    require.NoError(t, e)
+    require.Equal(t, `{"d":90000000000,"t":"2024-05-06T07:08:09Z"}`, string(r))
+    r, e = encoder.Encode(v, encoder.SortMapKeys | encoder.DurationString | encoder.TimeFormat("unixmilli"))
+    require.NoError(t, e)
+    require.Equal(t, `{"d":"1m30s","t":1714979289000}`, string(r))
+}
+
+func TestEncoder_Float(t *testing.T) {
+    v := map[string]interface{}{"a": 1.005, "b": float32(2), "c": math.Inf(-1)}
+    r, e := encoder.Encode(v, encoder.SortMapKeys | encoder.FloatPrecision(1) | encoder.FloatTrailingZero | encoder.FloatNaNInfString)
+    require.NoError(t, e)
+    require.Equal(t, `{"a":1.0,"b":2.0,"c":"-Infinity"}`, string(r))
+}
+
+func TestEncoder_EscapeNonASCII(t *testing.T) {
+    v := struct {
+        A   string `json:"ä"`
+        Q   string `json:",string"`
+        M   map[string]time.Duration
+    }{"世", "é", map[string]time.Duration{"ü": time.Microsecond}}
+    r, e := encoder.Encode(v, encoder.EscapeNonASCII | encoder.DurationString | encoder.SortMapKeys)
+    require.NoError(t, e)
+    require.Equal(t, `{"\u00e4":"\u4e16","Q":"\"\\u00e9\"","M":{"\u00fc":"1\u00b5s"}}`, string(r))
+    r, e = encoder.Encode(v, encoder.DurationString)
+    require.NoError(t, e)
+    require.Equal(t, `{"ä":"世","Q":"\"é\"","M":{"ü":"1µs"}}`, string(r))
+}
+
+func TestEncoder_Canonical(t *testing.T) {
+    type inner struct {
+        Y int `json:"y"`
+        X int `json:"x,omitempty"`
+    }
+    v := struct {
+        C   inner
+        B   *int `json:",omitempty"`
+        A   map[string]int
+    }{inner{Y: 1}, nil, map[string]int{"\ufb33": 1, "\U0001F600": 2}}
+    r, e := encoder.Encode(v, encoder.Canonical)
+    require.NoError(t, e)
+    require.Equal(t, "{\"A\":{\"\U0001F600\":2,\"\ufb33\":1},\"C\":{\"y\":1}}", string(r))
+    r, e = encoder.Encode(v, encoder.SortMapKeys)
+    require.NoError(t, e)
+    require.Equal(t, "{\"C\":{\"y\":1},\"A\":{\"\ufb33\":1,\"\U0001F600\":2}}", string(r))
+}
+
+func TestEncoder_Int64String(t *testing.T) {
+    v := map[string]interface{}{"a": int64(1) << 60, "b": uint64(1), "c": 1}
+    r, e := encoder.Encode(v, encoder.SortMapKeys | encoder.UnsafeInt64String)
+    require.NoError(t, e)
+    require.Equal(t, `{"a":"1152921504606846976","b":1,"c":1}`, string(r))
+    r, e = encoder.Encode(v, encoder.SortMapKeys | encoder.Int64String)
+    require.NoError(t, e)
+    require.Equal(t, `{"a":"1152921504606846976","b":"1","c":"1"}`, string(r))
+}
+
+func TestEncoder_Unsupported(t *testing.T) {
+    v := struct {
+        A   func()
+        C   complex64
+        E   error
+    }{func() {}, complex(1, 2), errors.New("boom")}
+    _, e := encoder.Encode(v, 0)
+    require.Error(t, e)
+    r, e := encoder.Encode(v, encoder.UnsupportedTypeNull | encoder.ErrorString)
+    require.NoError(t, e)
+    require.Equal(t, `{"A":null,"C":null,"E":"boom"}`, string(r))
+    r, e = encoder.Encode(v, encoder.UnsupportedTypeSkip)
+    require.NoError(t, e)
+    require.Equal(t, `{"E":{}}`, string(r))
+    r, e = encoder.Encode(v, encoder.UnsupportedTypeSkip | encoder.ComplexArray)
+    require.NoError(t, e)
+    require.Equal(t, `{"C":[1,2],"E":{}}`, string(r))
+}
+
+func TestEncoder_Redact(t *testing.T) {
+    v := struct {
+        A   string
+        P   string  `json:",redact"`
+        Q   *int    `json:",redact"`
+    }{"a", "p", nil}
+    r, e := encoder.Encode(v, 0)
+    require.NoError(t, e)
+    require.Equal(t, `{"A":"a","P":"p","Q":null}`, string(r))
+    r, e = encoder.Encode(v, encoder.Redact)
+    require.NoError(t, e)
+    require.Equal(t, `{"A":"a","P":"***","Q":"***"}`, string(r))
+    r, e = encoder.Encode(v, encoder.RedactHash)
+    require.NoError(t, e)
+    require.Equal(t, `{"A":"a","P":"sha256:`+
+        `1fb680aa1ed965bc09f024abd808fad41e7fa0bb2e1ec39e8f237d653d56b456","Q":"sha256:`+
+        `74234e98afe7498fb5daf1f36ac2d78acc339464f950703b8c019892f982b90b"}`, string(r))
+    r, e = encoder.Encode(v, encoder.RedactOmit)
+    require.NoError(t, e)
+    require.Equal(t, `{"A":"a"}`, string(r))
+}
+
+func TestEncoder_FieldMask(t *testing.T) {
+    v := map[string]interface{}{"a": []interface{}{map[string]int{"x": 1, "y": 2}}, "b": struct {
+        C   int     `json:"c,string"`
+        D   int
+    }{1, 2}}
+    m, e := encoder.NewFieldMask("a.x", "b.c")
+    require.NoError(t, e)
+    r, e := encoder.EncodeMasked(v, m, encoder.SortMapKeys)
+    require.NoError(t, e)
+    require.Equal(t, `{"a":[{"x":1}],"b":{"c":"1"}}`, string(r))
+}
+
+func TestEncoder_Big(t *testing.T) {
+    i, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+    r, e := encoder.Encode(map[string]interface{}{"i": i, "f": big.NewFloat(1.5)}, encoder.SortMapKeys)
+    require.NoError(t, e)
+    require.Equal(t, `{"f":1.5,"i":123456789012345678901234567890}`, string(r))
+}
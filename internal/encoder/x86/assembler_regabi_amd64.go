@@ -110,6 +110,7 @@ const (
 )
 
 const (
+	_LB_return                = "_return"
 	_LB_error                 = "_error"
 	_LB_error_too_deep        = "_error_too_deep"
 	_LB_error_invalid_number  = "_error_invalid_number"
@@ -235,6 +236,23 @@ var _OpFuncTab = [256]func(*Assembler, *ir.Instr){
 	ir.OP_number:         (*Assembler)._asm_OP_number,
 	ir.OP_eface:          (*Assembler)._asm_OP_eface,
 	ir.OP_iface:          (*Assembler)._asm_OP_iface,
+	ir.OP_union:          (*Assembler)._asm_OP_union,
+	ir.OP_big:            (*Assembler)._asm_OP_big,
+	ir.OP_time:           (*Assembler)._asm_OP_time,
+	ir.OP_duration:       (*Assembler)._asm_OP_duration,
+	ir.OP_format:         (*Assembler)._asm_OP_format,
+	ir.OP_i64_js:         (*Assembler)._asm_OP_i64_js,
+	ir.OP_u64_js:         (*Assembler)._asm_OP_u64_js,
+	ir.OP_c64:            (*Assembler)._asm_OP_c64,
+	ir.OP_c128:           (*Assembler)._asm_OP_c128,
+	ir.OP_unsupported:    (*Assembler)._asm_OP_unsupported,
+	ir.OP_unsupported_skip: (*Assembler)._asm_OP_unsupported_skip,
+	ir.OP_complex_skip:   (*Assembler)._asm_OP_complex_skip,
+	ir.OP_ascii_skip:     (*Assembler)._asm_OP_ascii_skip,
+	ir.OP_canonical_goto: (*Assembler)._asm_OP_canonical_goto,
+	ir.OP_error:          (*Assembler)._asm_OP_error,
+	ir.OP_redact:         (*Assembler)._asm_OP_redact,
+	ir.OP_redact_omit:    (*Assembler)._asm_OP_redact_omit,
 	ir.OP_byte:           (*Assembler)._asm_OP_byte,
 	ir.OP_text:           (*Assembler)._asm_OP_text,
 	ir.OP_deref:          (*Assembler)._asm_OP_deref,
@@ -251,6 +269,8 @@ var _OpFuncTab = [256]func(*Assembler, *ir.Instr){
 	ir.OP_is_zero_4:      (*Assembler)._asm_OP_is_zero_4,
 	ir.OP_is_zero_8:      (*Assembler)._asm_OP_is_zero_8,
 	ir.OP_is_zero_map:    (*Assembler)._asm_OP_is_zero_map,
+	ir.OP_is_zero:        (*Assembler)._asm_OP_is_zero,
+	ir.OP_is_zero_fn:     (*Assembler)._asm_OP_is_zero_fn,
 	ir.OP_goto:           (*Assembler)._asm_OP_goto,
 	ir.OP_map_iter:       (*Assembler)._asm_OP_map_iter,
 	ir.OP_map_stop:       (*Assembler)._asm_OP_map_stop,
@@ -263,6 +283,7 @@ var _OpFuncTab = [256]func(*Assembler, *ir.Instr){
 	ir.OP_marshal_p:      (*Assembler)._asm_OP_marshal_p,
 	ir.OP_marshal_text:   (*Assembler)._asm_OP_marshal_text,
 	ir.OP_marshal_text_p: (*Assembler)._asm_OP_marshal_text_p,
+	ir.OP_marshal_inline: (*Assembler)._asm_OP_marshal_inline,
 	ir.OP_cond_set:       (*Assembler)._asm_OP_cond_set,
 	ir.OP_cond_testc:     (*Assembler)._asm_OP_cond_testc,
 }
@@ -285,6 +306,7 @@ func (self *Assembler) instrs() {
 
 func (self *Assembler) builtins() {
 	self.more_space()
+	self.error_state()
 	self.error_too_deep()
 	self.error_invalid_number()
 	self.error_nan_or_infinite()
@@ -295,7 +317,7 @@ func (self *Assembler) epilogue() {
 	self.Mark(len(self.p))
 	self.Emit("XORL", _ET, _ET)
 	self.Emit("XORL", _EP, _EP)
-	self.Link(_LB_error)
+	self.Link(_LB_return)
 	self.Emit("MOVQ", _ARG_rb, _CX)                // MOVQ rb<>+0(FP), CX
 	self.Emit("MOVQ", _RL, jit.Ptr(_CX, 8))        // MOVQ RL, 8(CX)
 	self.Emit("MOVQ", jit.Imm(0), _ARG_rb)         // MOVQ AX, rb<>+0(FP)
@@ -628,6 +650,18 @@ var (
 	_I_json_UnsupportedValueError = jit.Itab(rt.UnpackType(vars.ErrorType), vars.JsonUnsupportedValueType)
 )
 
+func (self *Assembler) error_state() {
+	self.Link(_LB_error)
+	self.Emit("CMPQ", jit.Ptr(_ST, vars.StackFail), jit.Imm(0)) // CMPQ ep(ST), $0
+	self.Sjmp("JNE", _LB_return)                                 // JNE  _return
+	self.Emit("MOVQ", _ET, _VAR_sp)                              // MOVQ ET, sp
+	self.Emit("MOVQ", _EP, _VAR_dn)                              // MOVQ EP, dn
+	self.WritePtr(0, _SP_p, jit.Ptr(_ST, vars.StackFail))        // MOVQ SP.p, ep(ST)
+	self.Emit("MOVQ", _VAR_sp, _ET)                              // MOVQ sp, ET
+	self.Emit("MOVQ", _VAR_dn, _EP)                              // MOVQ dn, EP
+	self.Sjmp("JMP", _LB_return)                                 // JMP  _return
+}
+
 func (self *Assembler) error_too_deep() {
 	self.Link(_LB_error_too_deep)
 	self.Emit("MOVQ", _V_ERR_too_deep, _EP)               // MOVQ $_V_ERR_too_deep, EP
@@ -763,6 +797,8 @@ var (
 	_F_memmove       = jit.Func(rt.Memmove)
 	_F_error_number  = jit.Func(vars.Error_number)
 	_F_isValidNumber = jit.Func(rt.IsValidNumber)
+	_F_isZero        = jit.Func(alg.IsZero)
+	_F_isZeroer      = jit.Func(alg.IsZeroer)
 )
 
 var (
@@ -772,9 +808,26 @@ var (
 )
 
 var (
-	_F_encodeTypedPointer  obj.Addr
-	_F_encodeJsonMarshaler obj.Addr
-	_F_encodeTextMarshaler obj.Addr
+	_F_encodeTypedPointer    obj.Addr
+	_F_encodeUnion           obj.Addr
+	_F_encodeRedacted        obj.Addr
+	_F_encodeBig             obj.Addr
+	_F_encodeTime            obj.Addr
+	_F_encodeDuration        obj.Addr
+	_F_encodeFormat          obj.Addr
+	_F_encodeFloat32         obj.Addr
+	_F_encodeFloat64         obj.Addr
+	_F_encodeI64             obj.Addr
+	_F_encodeU64             obj.Addr
+	_F_encodeComplex64       obj.Addr
+	_F_encodeComplex128      obj.Addr
+	_F_encodeUnsupported     obj.Addr
+	_F_encodeErrorString     obj.Addr
+	_F_encodeString          obj.Addr
+	_F_encodeQuotedString    obj.Addr
+	_F_encodeJsonMarshaler   obj.Addr
+	_F_encodeTextMarshaler   obj.Addr
+	_F_encodeInlineMarshaler obj.Addr
 )
 
 const (
@@ -784,7 +837,24 @@ const (
 func init() {
 	_F_encodeJsonMarshaler = jit.Func(alg.EncodeJsonMarshaler)
 	_F_encodeTextMarshaler = jit.Func(alg.EncodeTextMarshaler)
+	_F_encodeInlineMarshaler = jit.Func(alg.EncodeInlineMarshaler)
 	_F_encodeTypedPointer  = jit.Func(EncodeTypedPointer)
+	_F_encodeUnion         = jit.Func(EncodeUnion)
+	_F_encodeRedacted      = jit.Func(EncodeRedacted)
+	_F_encodeBig           = jit.Func(alg.EncodeBig)
+	_F_encodeTime          = jit.Func(alg.EncodeTime)
+	_F_encodeDuration      = jit.Func(alg.EncodeDuration)
+	_F_encodeFormat        = jit.Func(alg.EncodeFormat)
+	_F_encodeFloat32       = jit.Func(alg.EncodeFloat32)
+	_F_encodeFloat64       = jit.Func(alg.EncodeFloat64)
+	_F_encodeI64           = jit.Func(alg.EncodeI64)
+	_F_encodeU64           = jit.Func(alg.EncodeU64)
+	_F_encodeComplex64     = jit.Func(alg.EncodeComplex64)
+	_F_encodeComplex128    = jit.Func(alg.EncodeComplex128)
+	_F_encodeUnsupported   = jit.Func(alg.EncodeUnsupported)
+	_F_encodeErrorString   = jit.Func(alg.EncodeErrorString)
+	_F_encodeString        = jit.Func(alg.EncodeString)
+	_F_encodeQuotedString  = jit.Func(alg.EncodeQuotedString)
 }
 
 func (self *Assembler) _asm_OP_null(_ *ir.Instr) {
@@ -864,7 +934,90 @@ func (self *Assembler) _asm_OP_u64(_ *ir.Instr) {
 	self.store_int(20, _F_u64toa, "MOVQ")
 }
 
+func (self *Assembler) _asm_OP_i64_js(p *ir.Instr) {
+	self.encode_opts(alg.Int64StringMask, _F_encodeI64, "_encode_i64_js_end_{n}")
+	self._asm_OP_i64(p)
+	self.Link("_encode_i64_js_end_{n}")
+}
+
+func (self *Assembler) _asm_OP_u64_js(p *ir.Instr) {
+	self.encode_opts(alg.Int64StringMask, _F_encodeU64, "_encode_u64_js_end_{n}")
+	self._asm_OP_u64(p)
+	self.Link("_encode_u64_js_end_{n}")
+}
+
+// encode_opts encodes the value in Go by fn and jumps to end if any option of mask is set.
+func (self *Assembler) encode_opts(mask int64, fn obj.Addr, end string) {
+	self.Emit("MOVQ", jit.Imm(mask), _CX)                // MOVQ   ${mask}, CX
+	self.Emit("TESTQ", _ARG_fv, _CX)                     // TESTQ  fv, CX
+	self.Sjmp("JZ", "_encode_native_{n}")                // JZ     _encode_native_{n}
+	self.encode_go(fn)
+	self.Sjmp("JMP", end)                                // JMP    end
+	self.Link("_encode_native_{n}")
+}
+
+// encode_go encodes the value by fn(buf, SP.p, fv) in Go.
+func (self *Assembler) encode_go(fn obj.Addr) {
+	self.prep_buffer_AX()                                // MOVE   {buf}, AX
+	self.Emit("MOVQ", _SP_p, _BX)                        // MOVQ   SP.p, BX
+	self.Emit("MOVQ", _ARG_fv, _CX)                      // MOVQ   fv, CX
+	self.call_encoder(fn)                                // CALL   fn
+	self.Emit("TESTQ", _ET, _ET)                         // TESTQ  ET, ET
+	self.Sjmp("JNZ", _LB_error)                          // JNZ    _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_c64(_ *ir.Instr) {
+	self.encode_go(_F_encodeComplex64)
+}
+
+func (self *Assembler) _asm_OP_c128(_ *ir.Instr) {
+	self.encode_go(_F_encodeComplex128)
+}
+
+func (self *Assembler) _asm_OP_unsupported(p *ir.Instr) {
+	self.prep_buffer_AX()                                // MOVE   {buf}, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)             // MOVQ   $(type(p.Vt())), BX
+	self.Emit("MOVQ", _ARG_fv, _CX)                      // MOVQ   fv, CX
+	self.call_encoder(_F_encodeUnsupported)              // CALL   encodeUnsupported
+	self.Emit("TESTQ", _ET, _ET)                         // TESTQ  ET, ET
+	self.Sjmp("JNZ", _LB_error)                          // JNZ    _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_unsupported_skip(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitUnsupportedSkip)), _ARG_fv) // BTQ  ${BitUnsupportedSkip}, fv
+	self.Xjmp("JC", p.Vi())                                            // JC   p.Vi()
+}
+
+func (self *Assembler) _asm_OP_complex_skip(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitComplexArray)), _ARG_fv)    // BTQ  ${BitComplexArray}, fv
+	self.Sjmp("JC", "_complex_skip_{n}")                               // JC   _complex_skip_{n}
+	self.Emit("BTQ", jit.Imm(int64(alg.BitUnsupportedSkip)), _ARG_fv) // BTQ  ${BitUnsupportedSkip}, fv
+	self.Xjmp("JC", p.Vi())                                            // JC   p.Vi()
+	self.Link("_complex_skip_{n}")
+}
+
+func (self *Assembler) _asm_OP_ascii_skip(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitEscapeNonASCII)), _ARG_fv) // BTQ  ${BitEscapeNonASCII}, fv
+	self.Xjmp("JNC", p.Vi())                                          // JNC  p.Vi()
+}
+
+func (self *Assembler) _asm_OP_canonical_goto(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitCanonical)), _ARG_fv) // BTQ  ${BitCanonical}, fv
+	self.Xjmp("JC", p.Vi())                                      // JC   p.Vi()
+}
+
+func (self *Assembler) _asm_OP_error(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitErrorString)), _ARG_fv) // BTQ  ${BitErrorString}, fv
+	self.Sjmp("JNC", "_error_iface_{n}")                          // JNC  _error_iface_{n}
+	self.encode_go(_F_encodeErrorString)
+	self.Xjmp("JMP", p.Vi())                                       // JMP  p.Vi()
+	self.Link("_error_iface_{n}")
+}
+
 func (self *Assembler) _asm_OP_f32(_ *ir.Instr) {
+	self.encode_opts(alg.FloatFormatMask, _F_encodeFloat32, "_encode_f32_end_{n}")
 	self.check_size(32)
 	self.Emit("MOVL", jit.Ptr(_SP_p, 0), _AX)  // MOVL     (SP.p), AX
 	self.Emit("ANDL", jit.Imm(_FM_exp32), _AX) // ANDL     $_FM_exp32, AX
@@ -884,6 +1037,7 @@ func (self *Assembler) _asm_OP_f32(_ *ir.Instr) {
 }
 
 func (self *Assembler) _asm_OP_f64(_ *ir.Instr) {
+	self.encode_opts(alg.FloatFormatMask, _F_encodeFloat64, "_encode_f64_end_{n}")
 	self.check_size(32)
 	self.Emit("MOVQ", jit.Ptr(_SP_p, 0), _AX)  // MOVQ   (SP.p), AX
 	self.Emit("MOVQ", jit.Imm(_FM_exp64), _CX) // MOVQ   $_FM_exp64, CX
@@ -904,7 +1058,9 @@ func (self *Assembler) _asm_OP_f64(_ *ir.Instr) {
 }
 
 func (self *Assembler) _asm_OP_str(_ *ir.Instr) {
+	self.encode_opts(1<<alg.BitEscapeNonASCII, _F_encodeString, "_str_ascii_end_{n}")
 	self.encode_string(false)
+	self.Link("_str_ascii_end_{n}")
 }
 
 func (self *Assembler) _asm_OP_bin(_ *ir.Instr) {
@@ -936,7 +1092,9 @@ func (self *Assembler) _asm_OP_bin(_ *ir.Instr) {
 }
 
 func (self *Assembler) _asm_OP_quote(_ *ir.Instr) {
+	self.encode_opts(1<<alg.BitEscapeNonASCII, _F_encodeQuotedString, "_str_ascii_end_{n}")
 	self.encode_string(true)
+	self.Link("_str_ascii_end_{n}")
 }
 
 func (self *Assembler) _asm_OP_number(_ *ir.Instr) {
@@ -993,6 +1151,86 @@ func (self *Assembler) _asm_OP_iface(_ *ir.Instr) {
 	self.load_buffer_AX()
 }
 
+func (self *Assembler) _asm_OP_big(p *ir.Instr) {
+	self.prep_buffer_AX()                     // MOVE  {buf}, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)  // MOVQ  $(type(p.Vt())), BX
+	self.Emit("MOVQ", _SP_p, _CX)             // MOVQ  SP.p, CX
+	self.call_encoder(_F_encodeBig)           // CALL  encodeBig
+	self.Emit("TESTQ", _ET, _ET)              // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)               // JNZ   _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_time(_ *ir.Instr) {
+	self.prep_buffer_AX()                     // MOVE  {buf}, AX
+	self.Emit("MOVQ", _SP_p, _BX)             // MOVQ  SP.p, BX
+	self.Emit("MOVQ", _ARG_fv, _CX)           // MOVQ  fv, CX
+	self.call_encoder(_F_encodeTime)          // CALL  encodeTime
+	self.Emit("TESTQ", _ET, _ET)              // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)               // JNZ   _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_duration(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitDurationString)), _ARG_fv)
+	self.Sjmp("JC", "_duration_string_{n}")
+	self._asm_OP_i64(p)
+	self.Sjmp("JMP", "_duration_end_{n}")
+	self.Link("_duration_string_{n}")
+	self.prep_buffer_AX()                     // MOVE  {buf}, AX
+	self.Emit("MOVQ", _SP_p, _BX)             // MOVQ  SP.p, BX
+	self.Emit("MOVQ", _ARG_fv, _CX)           // MOVQ  fv, CX
+	self.call_encoder(_F_encodeDuration)      // CALL  encodeDuration
+	self.load_buffer_AX()
+	self.Link("_duration_end_{n}")
+}
+
+func (self *Assembler) _asm_OP_format(p *ir.Instr) {
+	self.prep_buffer_AX()                     // MOVE  {buf}, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)  // MOVQ  $(type(p.Vt())), BX
+	self.Emit("MOVQ", _SP_p, _CX)             // MOVQ  SP.p, CX
+	self.Emit("MOVQ", jit.Imm(p.I64()), _DI)  // MOVQ  $(p.Vi()), DI
+	self.Emit("MOVQ", _ARG_fv, _SI)           // MOVQ  fv, SI
+	self.call_encoder(_F_encodeFormat)        // CALL  encodeFormat
+	self.Emit("TESTQ", _ET, _ET)              // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)               // JNZ   _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_union(p *ir.Instr) {
+	self.prep_buffer_AX()                     // MOVE  {buf}, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)  // MOVQ  $(type(p.Vt())), BX
+	self.Emit("MOVQ", _SP_p, _CX)             // MOVQ  SP.p, CX
+	self.Emit("MOVQ", _ST, _DI)               // MOVQ  ST, DI
+	self.Emit("MOVQ", _ARG_fv, _SI)           // MOVQ  fv, SI
+	self.call_encoder(_F_encodeUnion)         // CALL  encodeUnion
+	self.Emit("TESTQ", _ET, _ET)              // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)               // JNZ   _error
+	self.load_buffer_AX()
+}
+
+func (self *Assembler) _asm_OP_redact(p *ir.Instr) {
+	self.Emit("MOVQ", jit.Imm(alg.RedactMask), _CX) // MOVQ  $RedactMask, CX
+	self.Emit("TESTQ", _ARG_fv, _CX)               // TESTQ fv, CX
+	self.Sjmp("JZ", "_redact_end_{n}")             // JZ    _redact_end_{n}
+	self.prep_buffer_AX()                          // MOVE  {buf}, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)       // MOVQ  $(type(p.Vt())), BX
+	self.Emit("MOVQ", _SP_p, _CX)                  // MOVQ  SP.p, CX
+	self.Emit("MOVQ", _ST, _DI)                    // MOVQ  ST, DI
+	self.Emit("MOVQ", _ARG_fv, _SI)                // MOVQ  fv, SI
+	self.call_encoder(_F_encodeRedacted)           // CALL  encodeRedacted
+	self.Emit("TESTQ", _ET, _ET)                   // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)                    // JNZ   _error
+	self.load_buffer_AX()
+	self.Xjmp("JMP", p.Vi())                       // JMP   p.Vi()
+	self.Link("_redact_end_{n}")
+}
+
+func (self *Assembler) _asm_OP_redact_omit(p *ir.Instr) {
+	self.Emit("BTQ", jit.Imm(int64(alg.BitRedactOmit)), _ARG_fv) // BTQ  ${BitRedactOmit}, fv
+	self.Xjmp("JC", p.Vi())                                       // JC   p.Vi()
+}
+
 func (self *Assembler) _asm_OP_byte(p *ir.Instr) {
 	self.check_size(1)
 	self.Emit("MOVB", jit.Imm(p.I64()), jit.Sib(_RP, _RL, 1, 0)) // MOVL p.Vi(), (RP)(RL*1)
@@ -1097,20 +1335,41 @@ func (self *Assembler) _asm_OP_is_zero_map(p *ir.Instr) {
 	self.Xjmp("JE", p.Vi())                        // JE    p.Vi()
 }
 
+func (self *Assembler) _asm_OP_is_zero(p *ir.Instr) {
+	self.Emit("MOVQ", _SP_p, _AX)                  // MOVQ    SP.p, AX
+	self.Emit("MOVQ", jit.Type(p.Vt()), _BX)       // MOVQ    $p.Vt(), BX
+	self.call_go(_F_isZero)                        // CALL_GO isZero
+	self.Emit("CMPB", _AX, jit.Imm(0))             // CMPB    AX, $0
+	self.Xjmp("JNE", p.Vi())                       // JNE     p.Vi()
+}
+
+func (self *Assembler) _asm_OP_is_zero_fn(p *ir.Instr) {
+	vt, itab := p.Vtab()
+	self.Emit("MOVQ", _SP_p, _AX)                                         // MOVQ    SP.p, AX
+	self.Emit("MOVQ", jit.Gtype(vt), _BX)                                 // MOVQ    $vt, BX
+	self.Emit("MOVQ", jit.Imm(int64(uintptr(unsafe.Pointer(itab)))), _CX) // MOVQ    $itab, CX
+	self.call_go(_F_isZeroer)                                             // CALL_GO isZeroer
+	self.Emit("CMPB", _AX, jit.Imm(0))                                    // CMPB    AX, $0
+	self.Xjmp("JNE", p.Vi())                                              // JNE     p.Vi()
+}
+
 func (self *Assembler) _asm_OP_goto(p *ir.Instr) {
 	self.Xjmp("JMP", p.Vi())
 }
 
 func (self *Assembler) _asm_OP_map_iter(p *ir.Instr) {
-	self.Emit("MOVQ", jit.Type(p.Vt()), _AX)  // MOVQ    $p.Vt(), AX
-	self.Emit("MOVQ", jit.Ptr(_SP_p, 0), _BX) // MOVQ    (SP.p), BX
-	self.Emit("MOVQ", _ARG_fv, _CX)           // MOVQ    fv, CX
-	self.call_go(_F_iteratorStart)            // CALL_GO iteratorStart
-	self.Emit("MOVQ", _AX, _SP_q)             // MOVQ    AX, SP.q
-	self.Emit("MOVQ", _BX, _ET)               // MOVQ    32(SP), ET
-	self.Emit("MOVQ", _CX, _EP)               // MOVQ    40(SP), EP
-	self.Emit("TESTQ", _ET, _ET)              // TESTQ   ET, ET
-	self.Sjmp("JNZ", _LB_error)               // JNZ     _error
+	self.xsave(_REG_all...)                        // SAVE    $REG_all
+	self.Emit("MOVQ", jit.Type(p.Vt()), _AX)       // MOVQ    $p.Vt(), AX
+	self.Emit("MOVQ", jit.Ptr(_SP_p, 0), _BX)      // MOVQ    (SP.p), BX
+	self.Emit("MOVQ", _ARG_fv, _CX)                // MOVQ    fv, CX
+	self.Emit("MOVQ", jit.Imm(int64(p.Vi())), _DI) // MOVQ    $p.Vi(), DI
+	self.call(_F_iteratorStart)                    // CALL    iteratorStart
+	self.xload(_REG_all...)                        // LOAD    $REG_all
+	self.Emit("MOVQ", _AX, _SP_q)                  // MOVQ    AX, SP.q
+	self.Emit("MOVQ", _BX, _ET)                    // MOVQ    32(SP), ET
+	self.Emit("MOVQ", _CX, _EP)                    // MOVQ    40(SP), EP
+	self.Emit("TESTQ", _ET, _ET)                   // TESTQ   ET, ET
+	self.Sjmp("JNZ", _LB_error)                    // JNZ     _error
 }
 
 func (self *Assembler) _asm_OP_map_stop(_ *ir.Instr) {
@@ -1128,7 +1387,9 @@ func (self *Assembler) _asm_OP_map_check_key(p *ir.Instr) {
 func (self *Assembler) _asm_OP_map_write_key(p *ir.Instr) {
 	self.Emit("BTQ", jit.Imm(alg.BitSortMapKeys), _ARG_fv) // BTQ ${SortMapKeys}, fv
 	self.Sjmp("JNC", "_unordered_key_{n}")             // JNC _unordered_key_{n}
+	self.encode_opts(1<<alg.BitEscapeNonASCII, _F_encodeString, "_str_ascii_end_{n}")
 	self.encode_string(false)                          // STR $false
+	self.Link("_str_ascii_end_{n}")                    // _str_ascii_end_{n}:
 	self.Xjmp("JMP", p.Vi())                           // JMP ${p.Vi()}
 	self.Link("_unordered_key_{n}")                    // _unordered_key_{n}:
 }
@@ -1178,6 +1439,33 @@ func (self *Assembler) _asm_OP_marshal_text_p(p *ir.Instr) {
 	}
 }
 
+func (self *Assembler) _asm_OP_marshal_inline(p *ir.Instr) {
+	vt, itab := p.Vtab()
+	self.prep_buffer_AX()                                                 // MOVE {buf}, AX
+	self.Emit("MOVQ", _RL, _VAR_dn)                                       // MOVQ RL, dn
+	self.Emit("MOVQ", jit.Imm(int64(uintptr(unsafe.Pointer(itab)))), _BX) // MOVQ $itab, BX
+
+	/* dereference the pointer if needed */
+	if itab.Vt == vt && !vt.Indirect() {
+		self.Emit("MOVQ", jit.Ptr(_SP_p, 0), _CX) // MOVQ 0(SP.p), CX
+	} else {
+		self.Emit("MOVQ", _SP_p, _CX) // MOVQ SP.p, CX
+	}
+
+	/* the members need a leading comma unless nothing was written before */
+	self.Emit("MOVQ", _ARG_fv, _DI)             // MOVQ  ARG.fv, DI
+	self.Emit("MOVQ", _SP_f, _SI)               // MOVQ  SP.f, SI
+	self.Emit("ANDQ", jit.Imm(1<<_S_cond), _SI) // ANDQ  $(1<<_S_cond), SI
+	self.call_go(_F_encodeInlineMarshaler)      // CALL  encodeInlineMarshaler
+	self.Emit("TESTQ", _ET, _ET)                // TESTQ ET, ET
+	self.Sjmp("JNZ", _LB_error)                 // JNZ   _error
+	self.load_buffer_AX()
+	self.Emit("CMPQ", _RL, _VAR_dn)            // CMPQ  RL, dn
+	self.Sjmp("JE", "_inline_end_{n}")         // JE    _inline_end_{n}
+	self.Emit("BTRQ", jit.Imm(_S_cond), _SP_f) // BTRQ  $_S_cond, SP.f
+	self.Link("_inline_end_{n}")               // _inline_end_{n}:
+}
+
 func (self *Assembler) _asm_OP_cond_set(_ *ir.Instr) {
 	self.Emit("ORQ", jit.Imm(1<<_S_cond), _SP_f) // ORQ $(1<<_S_cond), SP.f
 }
@@ -42,6 +42,8 @@ func EncodeTypedPointer(buf *[]byte, vt *rt.GoType, vp *unsafe.Pointer, sb *vars
 		return alg.EncodeNil(buf)
 	} else if fn, err := vars.FindOrCompile(vt, (fv&(1<<alg.BitPointerValue)) != 0, compiler); err != nil {
 		return err
+	} else if (fv&(1<<alg.BitDetectCycles)) != 0 && sb.Deep() {
+		return encodeVisited(buf, vt, vp, sb, fv, fn.(vars.Encoder))
 	} else if vt.Indirect() {
 		return	fn.(vars.Encoder)(buf, *vp, sb, fv)
 	} else {
@@ -49,3 +51,45 @@ func EncodeTypedPointer(buf *[]byte, vt *rt.GoType, vp *unsafe.Pointer, sb *vars
 	}
 }
 
+// EncodeUnion encodes the union interface of type vt at vp, with the
+// discriminator of its dynamic type as the first key of the object.
+func EncodeUnion(buf *[]byte, vt *rt.GoType, vp unsafe.Pointer, sb *vars.Stack, fv uint64) error {
+	mt := (*(**rt.GoItab)(vp)).Vt
+	n := len(*buf)
+	if err := EncodeTypedPointer(buf, mt, (*unsafe.Pointer)(rt.Add(vp, 8)), sb, fv); err != nil {
+		return err
+	}
+	return alg.EncodeUnionTag(buf, n, vt, mt, vp, fv)
+}
+
+// EncodeRedacted writes the redacted value of type vt at vp by the redaction options of fv,
+// the hash is of its JSON encoding with sorted map keys.
+func EncodeRedacted(buf *[]byte, vt *rt.GoType, vp unsafe.Pointer, sb *vars.Stack, fv uint64) error {
+	if fv&(1<<alg.BitRedactHash) == 0 {
+		*buf = append(*buf, `"***"`...)
+		return nil
+	}
+	pv := &vp
+	if !vt.Indirect() {
+		pv = (*unsafe.Pointer)(vp)
+	}
+	var tmp []byte
+	if err := EncodeTypedPointer(&tmp, vt, pv, sb, fv&^(1<<alg.BitPointerValue)|1<<alg.BitSortMapKeys); err != nil {
+		return err
+	}
+	*buf = alg.AppendRedactHash(*buf, tmp)
+	return nil
+}
+
+func encodeVisited(buf *[]byte, vt *rt.GoType, vp *unsafe.Pointer, sb *vars.Stack, fv uint64, fn vars.Encoder) error {
+	if err := sb.Visit(vt, vp); err != nil {
+		return err
+	}
+	defer sb.Leave(vt, vp)
+	if vt.Indirect() {
+		return fn(buf, *vp, sb, fv)
+	} else {
+		return fn(buf, unsafe.Pointer(vp), sb, fv)
+	}
+}
+
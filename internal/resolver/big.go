@@ -0,0 +1,38 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+	"math/big"
+	"reflect"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// IsBig tells whether vt is `big.Int` or `big.Float`, which are written as JSON
+// numbers natively instead of through their marshalers.
+func IsBig(vt reflect.Type) bool {
+	return vt == bigIntType || vt == bigFloatType
+}
+
+// IsBigFloat tells whether vt is `big.Float`.
+func IsBigFloat(vt reflect.Type) bool {
+	return vt == bigFloatType
+}
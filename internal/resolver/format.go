@@ -0,0 +1,126 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// The kinds of the `format:` option of a field.
+const (
+	FormatTime = iota + 1
+	FormatDuration
+	FormatBase64URL
+	FormatHex
+	FormatPrintf
+)
+
+// Format is a resolved `format:` option, Text is the time format of FormatTime
+// and the verb of FormatPrintf.
+type Format struct {
+	Kind int
+	Text string
+}
+
+var (
+	formatLock sync.RWMutex
+	formatIDs  = map[Format]int{}
+	formats    = []Format{{}}
+)
+
+// ResolveFormat checks the `format:` option text of a field of type vt, which may
+// also be a pointer to the type, and returns the id of the format for FormatOf.
+// The id is 0 for the formats that are the default of vt.
+func ResolveFormat(vt reflect.Type, text string) (int, error) {
+	if vt.Kind() == reflect.Ptr {
+		vt = vt.Elem()
+	}
+
+	var f Format
+	switch {
+	case IsTime(vt):
+		f = Format{Kind: FormatTime, Text: text}
+	case IsDuration(vt) && text == "string":
+		f = Format{Kind: FormatDuration}
+	case isBytes(vt) && text == "base64":
+		return 0, nil
+	case isBytes(vt) && text == "base64url":
+		f = Format{Kind: FormatBase64URL}
+	case isBytes(vt) && text == "hex":
+		f = Format{Kind: FormatHex}
+	case isFloat(vt) && isNumberVerb(text):
+		f = Format{Kind: FormatPrintf, Text: text}
+	default:
+		return 0, fmt.Errorf("json: unsupported format %q for field of type %s", text, vt)
+	}
+
+	formatLock.RLock()
+	id, ok := formatIDs[f]
+	formatLock.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	formatLock.Lock()
+	defer formatLock.Unlock()
+	if id, ok = formatIDs[f]; !ok {
+		id = len(formats)
+		formatIDs[f] = id
+		formats = append(formats, f)
+	}
+	return id, nil
+}
+
+// FormatOf returns the format of the id returned by ResolveFormat.
+func FormatOf(id int) Format {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	return formats[id]
+}
+
+func isBytes(vt reflect.Type) bool {
+	return vt.Kind() == reflect.Slice && vt.Elem().Kind() == reflect.Uint8
+}
+
+func isFloat(vt reflect.Type) bool {
+	return vt.Kind() == reflect.Float32 || vt.Kind() == reflect.Float64
+}
+
+// isNumberVerb tells whether the printf format writes a float as a JSON number.
+// The flags `+`, ` ` and `0` are rejected, since they write signs or leading zeros
+// that are not allowed in JSON, and the output is checked for numbers of both signs.
+func isNumberVerb(text string) bool {
+	if !strings.HasPrefix(text, "%") || strings.Count(text, "%") != 1 {
+		return false
+	}
+	verb := strings.TrimLeft(text[1:], "+- #0")
+	if strings.ContainsAny(text[1:len(text)-len(verb)], "+ 0") {
+		return false
+	}
+	for _, f := range []float64{-1.5, 1.5, 0, 1e21, -1e-7} {
+		var v interface{}
+		out := fmt.Sprintf(text, f)
+		if json.Unmarshal([]byte(out), &v) != nil || strings.TrimSpace(out) != out {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// InlineSetter stores the raw JSON value of an unknown key into
+// a non-map `json:",inline"` catch-all field pointed by vp.
+type InlineSetter func(vp unsafe.Pointer, key string, raw string) error
+
+var inlineSetters sync.Map
+
+// RegisterInlineSetter makes vt usable as a catch-all field type when decoding.
+// Maps with string keys are always supported and need no registration.
+func RegisterInlineSetter(vt reflect.Type, fn InlineSetter) {
+	inlineSetters.Store(vt, fn)
+}
+
+// FindInlineSetter returns the setter registered for vt, or nil.
+func FindInlineSetter(vt reflect.Type) InlineSetter {
+	if fn, ok := inlineSetters.Load(vt); ok {
+		return fn.(InlineSetter)
+	} else {
+		return nil
+	}
+}
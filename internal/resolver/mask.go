@@ -0,0 +1,172 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// FieldMask is a tree of the JSON paths selected for encoding, such as `user.name`
+// and `items.*.id`. A node without children selects the whole value under it,
+// and so does a nil mask.
+type FieldMask struct {
+    next map[string]*FieldMask
+}
+
+// NewFieldMask parses the dot-separated paths into a mask, where the `*` segment
+// matches every key of an object. Arrays are transparent to the paths, so `items.id`
+// is the same as `items.*.id` for an array of items.
+func NewFieldMask(paths ...string) (*FieldMask, error) {
+    root := &FieldMask{next: map[string]*FieldMask{}}
+    for _, path := range paths {
+        if err := root.add(path); err != nil {
+            return nil, err
+        }
+    }
+    return root, nil
+}
+
+func (self *FieldMask) add(path string) error {
+    keys := strings.Split(path, ".")
+    node := self
+
+    /* empty segments are most likely typos */
+    for _, key := range keys {
+        if key == "" {
+            return fmt.Errorf("sonic: invalid field mask path %q", path)
+        }
+    }
+
+    /* a shorter path selects more */
+    for i, key := range keys {
+        if node.next == nil {
+            return nil
+        }
+        next := node.next[key]
+        if next == nil {
+            next = &FieldMask{}
+            if i != len(keys) - 1 {
+                next.next = map[string]*FieldMask{}
+            }
+            node.next[key] = next
+        } else if i == len(keys) - 1 {
+            next.next = nil
+        }
+        node = next
+    }
+    return nil
+}
+
+// All tells whether the mask selects the whole value.
+func (self *FieldMask) All() bool {
+    return self == nil || self.next == nil
+}
+
+// Child returns the mask of the key, or nil if the key is not selected.
+func (self *FieldMask) Child(key string) *FieldMask {
+    if self.All() {
+        return self
+    }
+    m, w := self.next[key], self.next["*"]
+    if m == nil {
+        return w
+    } else if w == nil {
+        return m
+    } else {
+        return merge(m, w)
+    }
+}
+
+// Elem returns the mask of the array elements.
+func (self *FieldMask) Elem() *FieldMask {
+    if self.All() || self.next["*"] == nil {
+        return self
+    } else if len(self.next) == 1 {
+        return self.next["*"]
+    }
+
+    /* both `items.*.id` and `items.name` apply to the elements */
+    rest := &FieldMask{next: make(map[string]*FieldMask, len(self.next) - 1)}
+    for k, v := range self.next {
+        if k != "*" {
+            rest.next[k] = v
+        }
+    }
+    return merge(self.next["*"], rest)
+}
+
+func merge(a *FieldMask, b *FieldMask) *FieldMask {
+    if a.All() || b.All() {
+        return &FieldMask{}
+    }
+    ret := &FieldMask{next: make(map[string]*FieldMask, len(a.next) + len(b.next))}
+    for k, v := range a.next {
+        ret.next[k] = v
+    }
+    for k, v := range b.next {
+        if m := ret.next[k]; m != nil {
+            ret.next[k] = merge(m, v)
+        } else {
+            ret.next[k] = v
+        }
+    }
+    return ret
+}
+
+var fieldStructs sync.Map
+
+// FieldStruct returns a struct type of only the field fv, tagged with its options.
+// The struct shares the memory layout of the field, so a pointer to the field
+// can be encoded as the struct to get `{"name":value}`, or `{}` if it is omitted.
+func FieldStruct(fv *FieldMeta) reflect.Type {
+    if vt, ok := fieldStructs.Load(fv); ok {
+        return vt.(reflect.Type)
+    }
+
+    /* rebuild the tag from the options */
+    tag := []string{fv.Name}
+    if (fv.Opts & F_stringize) != 0 {
+        tag = append(tag, "string")
+    }
+    if (fv.Opts & F_omitempty) != 0 {
+        tag = append(tag, "omitempty")
+    }
+    if (fv.Opts & F_omitzero) != 0 {
+        tag = append(tag, "omitzero")
+    }
+    if (fv.Opts & F_inline) != 0 {
+        tag = append(tag, "inline")
+    }
+    if (fv.Opts & F_redact) != 0 {
+        tag = append(tag, "redact")
+    }
+    if fv.Format != "" {
+        tag = append(tag, "format:" + fv.Format)
+    }
+
+    vt := reflect.StructOf([]reflect.StructField {{
+        Name : "Value",
+        Type : fv.Type,
+        Tag  : reflect.StructTag("json:" + strconv.Quote(strings.Join(tag, ","))),
+    }})
+    fieldStructs.Store(fv, vt)
+    return vt
+}
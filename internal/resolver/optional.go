@@ -0,0 +1,59 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+	"reflect"
+)
+
+// OptionalTag is the first field of every `sonic.Optional[T]`, it tells the
+// compilers to handle the type natively instead of through its marshalers.
+//
+// The layout that follows the tag is fixed:
+//
+//     struct {
+//         _     OptionalTag
+//         set   bool // offset 0, the value is present
+//         null  bool // offset 1, the value is an explicit null
+//         value T
+//     }
+//
+// So an absent value has two zero bytes at its start.
+type OptionalTag struct{}
+
+// Optional states, stored as a little-endian 16-bit word at the start.
+const (
+    OptionalSet  = 0x0001
+    OptionalNull = 0x0100
+)
+
+var optionalTagType = reflect.TypeOf(OptionalTag{})
+
+// IsOptional tells whether vt is an instance of `sonic.Optional[T]`.
+func IsOptional(vt reflect.Type) bool {
+    return vt.Kind() == reflect.Struct &&
+        vt.NumField() == 4 &&
+        vt.Field(0).Type == optionalTagType &&
+        vt.Field(1).Offset == 0 &&
+        vt.Field(2).Offset == 1
+}
+
+// OptionalValue returns the type and offset of the value in the optional type vt.
+func OptionalValue(vt reflect.Type) (reflect.Type, uintptr) {
+    f := vt.Field(3)
+    return f.Type, f.Offset
+}
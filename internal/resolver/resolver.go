@@ -17,6 +17,7 @@
 package resolver
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -29,6 +30,10 @@ type OffsetType int
 const (
     F_omitempty FieldOpts = 1 << iota
     F_stringize
+    F_omitzero
+    F_inline
+    F_required
+    F_redact
 )
 
 const (
@@ -47,6 +52,12 @@ type FieldMeta struct {
     Path []Offset
     Opts FieldOpts
     Type reflect.Type
+
+    // Default is the JSON literal decoded into the field when its key is absent
+    Default string
+
+    // Format is the text of the `format:` option, see ResolveFormat
+    Format string
 }
 
 func (self *FieldMeta) String() string {
@@ -72,6 +83,36 @@ func (self *FieldMeta) String() string {
         opts = append(opts, "omitempty")
     }
 
+    /* check for "omitzero" */
+    if (self.Opts & F_omitzero) != 0 {
+        opts = append(opts, "omitzero")
+    }
+
+    /* check for "inline" */
+    if (self.Opts & F_inline) != 0 {
+        opts = append(opts, "inline")
+    }
+
+    /* check for "required" */
+    if (self.Opts & F_required) != 0 {
+        opts = append(opts, "required")
+    }
+
+    /* check for "redact" */
+    if (self.Opts & F_redact) != 0 {
+        opts = append(opts, "redact")
+    }
+
+    /* check for the format */
+    if self.Format != "" {
+        opts = append(opts, "format:" + self.Format)
+    }
+
+    /* check for the default value */
+    if self.Default != "" {
+        opts = append(opts, "default=" + self.Default)
+    }
+
     /* format the field */
     return fmt.Sprintf(
         "{Field \"%s\" @ %s, opts=%s, type=%s}",
@@ -132,10 +173,10 @@ func resolveFields(vt reflect.Type) []FieldMeta {
         }
 
         /* dump the field path */
-        fmt.Println(fv)
+        var fval reflect.StructField
         for _, i := range fv.index {
             kind := F_offset
-            fval := item.Field(i)
+            fval  = item.Field(i)
             item  = fval.Type
 
             /* deref the pointer if needed */
@@ -152,6 +193,27 @@ func resolveFields(vt reflect.Type) []FieldMeta {
             })
         }
 
+        /* check for "omitzero", parsed here since not every Go version knows it */
+        tag, def := parseTag(fval.Tag.Get("json")).cutDefault()
+        if tag.Contains("omitzero") {
+            opts |= F_omitzero
+        }
+
+        /* check for "inline", InlineField rejects more than one */
+        if tag.Contains("inline") {
+            opts |= F_inline
+        }
+
+        /* check for "required" */
+        if tag.Contains("required") {
+            opts |= F_required
+        }
+
+        /* check for "redact" */
+        if tag.Contains("redact") {
+            opts |= F_redact
+        }
+
         /* get the index to the last offset */
         idx := len(path) - 1
         fvt := path[idx].Type
@@ -162,12 +224,21 @@ func resolveFields(vt reflect.Type) []FieldMeta {
             path[idx].Kind = F_offset
         }
 
+        /* check for "format:", the "string" format of numbers is the "string" option */
+        format := tag.Value("format:")
+        if format == "string" && isStringizable(fvt) {
+            opts |= F_stringize
+            format = ""
+        }
+
         /* add to result */
         ret = append(ret, FieldMeta {
-            Type: fvt,
-            Opts: opts,
-            Path: path,
-            Name: fv.name,
+            Type    : fvt,
+            Opts    : opts,
+            Path    : path,
+            Name    : fv.name,
+            Default : defaultOf(fval.Tag, def),
+            Format  : format,
         })
     }
 
@@ -180,13 +251,188 @@ func resolveFields(vt reflect.Type) []FieldMeta {
     return ret
 }
 
+// InlineField returns the index of the `json:",inline"` catch-all field in the
+// fields of vt, or -1 if there is none. A struct has at most one catch-all field,
+// otherwise the index of the first one is returned with an error.
+func InlineField(vt reflect.Type, fields []FieldMeta) (int, error) {
+    ix := -1
+    for i, f := range fields {
+        if (f.Opts & F_inline) == 0 {
+            continue
+        }
+        if ix >= 0 {
+            return ix, fmt.Errorf("json: fields %q and %q of %s are both tagged inline, at most one is allowed", fields[ix].Name, f.Name, vt)
+        }
+        ix = i
+    }
+    return ix, nil
+}
+
+// InlineTypeError is the error for the catch-all field f of vt, whose type neither
+// holds the unknown keys nor reads and writes the members of an object by itself.
+// Plain structs are not flattened, they can be embedded without the tag instead.
+func InlineTypeError(vt reflect.Type, f *FieldMeta) error {
+    return fmt.Errorf("json: the inline field %q of %s must be a map with string keys or a type like ast.Node, not %s; embed structs to flatten them", f.Name, vt, f.Type)
+}
+
+// IsArrayStruct tells whether vt is marked as a tuple by a blank field tagged
+// `json:",array"`, whose fields are then mapped to the positions of a JSON array.
+// An embedded empty struct with the tag marks it too, which passes `go vet`.
+func IsArrayStruct(vt reflect.Type) bool {
+    if vt.Kind() != reflect.Struct {
+        return false
+    }
+    for i := 0; i < vt.NumField(); i++ {
+        f := vt.Field(i)
+        if f.Name != "_" && !(f.Anonymous && f.Type.Kind() == reflect.Struct && f.Type.NumField() == 0) {
+            continue
+        }
+        if parseTag(f.Tag.Get("json")).Contains("array") {
+            return true
+        }
+    }
+    return false
+}
+
+var checkedCache sync.Map
+
+// CheckedFields returns the indices of the fields in ResolveStruct(vt) that are
+// `required` or have a default value, which are checked after decoding vt.
+func CheckedFields(vt reflect.Type) ([]int, error) {
+    if v, ok := checkedCache.Load(vt); ok {
+        return v.([]int), nil
+    }
+
+    var ret []int
+    for i, f := range ResolveStruct(vt) {
+        if f.Default != "" && !json.Valid([]byte(f.Default)) {
+            if hasTrailingOptions(f.Default) {
+                return nil, fmt.Errorf("json: the default= option of field %q of %s must be the last one in the tag", f.Name, vt)
+            }
+            return nil, fmt.Errorf("json: invalid default value %s for field %q of %s", f.Default, f.Name, vt)
+        }
+        if (f.Opts & F_inline) == 0 && ((f.Opts & F_required) != 0 || f.Default != "") {
+            ret = append(ret, i)
+        }
+    }
+
+    checkedCache.Store(vt, ret)
+    return ret, nil
+}
+
+// FieldByName returns the Go field that the JSON key name is decoded into,
+// the key is matched case-insensitively if there is no exact match.
+func FieldByName(vt reflect.Type, name string) (reflect.StructField, bool) {
+    tfv := typeFields(vt)
+    for _, fv := range tfv.list {
+        if fv.name == name {
+            return vt.FieldByIndex(fv.index), true
+        }
+    }
+    for _, fv := range tfv.list {
+        if strings.EqualFold(fv.name, name) {
+            return vt.FieldByIndex(fv.index), true
+        }
+    }
+    return reflect.StructField{}, false
+}
+
+type tagOptions string
+
+func parseTag(tag string) tagOptions {
+    if i := strings.IndexByte(tag, ','); i >= 0 {
+        return tagOptions(tag[i + 1:])
+    } else {
+        return ""
+    }
+}
+
+// cutDefault splits the `default=...` option from the others. The option takes
+// the rest of the json tag since the value may have commas, so it must be the last one.
+func (self tagOptions) cutDefault() (tagOptions, string) {
+    for i, s := 0, string(self); ; {
+        if strings.HasPrefix(s[i:], "default=") {
+            return tagOptions(strings.TrimSuffix(s[:i], ",")), s[i + len("default="):]
+        }
+        if j := strings.IndexByte(s[i:], ','); j >= 0 {
+            i += j + 1
+        } else {
+            return self, ""
+        }
+    }
+}
+
+// hasTrailingOptions tells whether the invalid default value v is a valid
+// one followed by other options, like `1,omitempty`.
+func hasTrailingOptions(v string) bool {
+    for i := range v {
+        if v[i] == ',' && json.Valid([]byte(v[:i])) {
+            return true
+        }
+    }
+    return false
+}
+
+// defaultOf returns the default value of a field, from the `default:"..."` tag,
+// or the value of the `default=...` option.
+func defaultOf(tag reflect.StructTag, def string) string {
+    if v, ok := tag.Lookup("default"); ok {
+        return v
+    }
+    return def
+}
+
+// Value returns the rest of the first option that starts with prefix.
+func (self tagOptions) Value(prefix string) string {
+    for s := string(self); s != "" ; {
+        var opt string
+        if i := strings.IndexByte(s, ','); i >= 0 {
+            opt, s = s[:i], s[i + 1:]
+        } else {
+            opt, s = s, ""
+        }
+        if strings.HasPrefix(opt, prefix) {
+            return opt[len(prefix):]
+        }
+    }
+    return ""
+}
+
+// isStringizable tells whether the "string" option applies to vt, which are
+// the numbers except time.Duration, and the pointers to them.
+func isStringizable(vt reflect.Type) bool {
+    if vt.Kind() == reflect.Ptr {
+        vt = vt.Elem()
+    }
+    switch vt.Kind() {
+        case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64      : return !IsDuration(vt)
+        case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64 : return true
+        case reflect.Uintptr, reflect.Float32, reflect.Float64                            : return true
+        default                                                                          : return false
+    }
+}
+
+func (self tagOptions) Contains(name string) bool {
+    for s := string(self); s != "" ; {
+        var opt string
+        if i := strings.IndexByte(s, ','); i >= 0 {
+            opt, s = s[:i], s[i + 1:]
+        } else {
+            opt, s = s, ""
+        }
+        if opt == name {
+            return true
+        }
+    }
+    return false
+}
+
 var (
     fieldLock  = sync.RWMutex{}
     fieldCache = map[reflect.Type][]FieldMeta{}
 )
 
 func ResolveStruct(vt reflect.Type) []FieldMeta {
-    println(vt.Name())
     var ok bool
     var fm []FieldMeta
 
@@ -210,7 +456,6 @@ func ResolveStruct(vt reflect.Type) []FieldMeta {
     }
 
     /* resolve the field */
-    println(vt.Name())
     fm = resolveFields(vt)
     fieldCache[vt] = fm
     return fm
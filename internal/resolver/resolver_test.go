@@ -18,7 +18,9 @@ package resolver
 
 import (
     `reflect`
+    `strings`
     `testing`
+    `time`
 )
 
 type bas struct {
@@ -69,3 +71,123 @@ func TestResolver_ResolveStruct(t *testing.T) {
         println(fv.String())
     }
 }
+
+func TestResolver_CheckedFields(t *testing.T) {
+    type checked struct {
+        A int      `json:"a,required"`
+        B []int    `json:"b,omitempty,default=[1,2]"`
+        C string   `json:"c" default:"\"x\""`
+        D int      `json:"d"`
+    }
+    vt := reflect.TypeOf(checked{})
+    ck, err := CheckedFields(vt)
+    if err != nil {
+        t.Fatal(err)
+    }
+    fv := ResolveStruct(vt)
+    if len(ck) != 3 || fv[ck[0]].Name != "a" || fv[ck[1]].Default != "[1,2]" || fv[ck[2]].Default != `"x"` {
+        t.Fatalf("unexpected checked fields: %v", ck)
+    }
+    if (fv[1].Opts & F_omitempty) == 0 {
+        t.Fatal("omitempty is lost")
+    }
+}
+
+func TestResolver_IsArrayStruct(t *testing.T) {
+    type marker struct{}
+    type tuple struct {
+        marker `json:",array"`
+        A int
+    }
+    type plain struct {
+        marker `json:"array"`
+        A int
+    }
+    blank := reflect.StructOf([]reflect.StructField{
+        {Name: "_", PkgPath: "resolver", Type: reflect.TypeOf(struct{}{}), Tag: `json:",array"`},
+        {Name: "A", Type: reflect.TypeOf(0)},
+    })
+    if !IsArrayStruct(reflect.TypeOf(tuple{})) || !IsArrayStruct(blank) || IsArrayStruct(reflect.TypeOf(plain{})) || IsArrayStruct(reflect.TypeOf(1)) {
+        t.Fatal("unexpected array structs")
+    }
+    for _, vt := range []reflect.Type{reflect.TypeOf(tuple{}), blank} {
+        if fv := ResolveStruct(vt); len(fv) != 1 || fv[0].Name != "A" {
+            t.Fatalf("the marker is resolved as a field: %v", fv)
+        }
+    }
+}
+
+func TestResolver_Format(t *testing.T) {
+    type formats struct {
+        T time.Time  `json:"t,format:unixmilli"`
+        B []byte     `json:"b,format:hex"`
+        F float64    `json:"f,format:string"`
+        P *float32   `json:"p,omitempty,format:%.2f"`
+        D time.Duration `json:"d,format:string"`
+    }
+    fv := ResolveStruct(reflect.TypeOf(formats{}))
+    if fv[0].Format != "unixmilli" || fv[1].Format != "hex" || fv[2].Format != "" || fv[3].Format != "%.2f" || fv[4].Format != "string" {
+        t.Fatalf("unexpected formats: %v", fv)
+    }
+    if (fv[2].Opts & F_stringize) == 0 || (fv[4].Opts & F_stringize) != 0 {
+        t.Fatal("the string format of floats is not the string option")
+    }
+    for _, f := range []FieldMeta{fv[0], fv[1], fv[3], fv[4]} {
+        if id, err := ResolveFormat(f.Type, f.Format); err != nil || id == 0 {
+            t.Fatalf("failed to resolve format of %v: %v", f, err)
+        }
+    }
+    if id, err := ResolveFormat(reflect.TypeOf([]byte(nil)), "base64"); err != nil || id != 0 {
+        t.Fatal("base64 is not the default format of bytes")
+    }
+    for _, f := range []string{"hex", "%d", "%s%f", "%x", "%+.2f", "% f", "%08.2f", "%#.0f", "%-10f"} {
+        if _, err := ResolveFormat(reflect.TypeOf(1.0), f); err == nil {
+            t.Fatalf("format %q of floats is resolved", f)
+        }
+    }
+}
+
+func TestResolver_FieldMask(t *testing.T) {
+    m, err := NewFieldMask("a.b.c", "a.b", "x.*.y", "x.z", "*.w")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !m.Child("a").Child("b").All() || m.Child("q").Child("w") == nil || m.Child("q").Child("v") != nil {
+        t.Fatalf("unexpected mask: %v", m)
+    }
+    if e := m.Child("x").Elem(); e.Child("y") == nil || e.Child("z") == nil || e.Child("w") == nil {
+        t.Fatal("the mask of elements is not merged")
+    }
+    if !(*FieldMask)(nil).All() || !(&FieldMask{}).Child("v").All() {
+        t.Fatal("the empty mask does not select everything")
+    }
+    for _, p := range []string{"", "a.", ".a", "a..b"} {
+        if _, err := NewFieldMask(p); err == nil {
+            t.Fatalf("invalid path %q is parsed", p)
+        }
+    }
+    vt := FieldStruct(&ResolveStruct(reflect.TypeOf(struct {
+        V *float64 `json:"v,omitempty,format:%.1f"`
+    }{}))[0])
+    if f := vt.Field(0); f.Offset != 0 || f.Tag.Get("json") != "v,omitempty,format:%.1f" {
+        t.Fatalf("unexpected field struct: %v", f)
+    }
+}
+
+func TestResolver_DefaultOption(t *testing.T) {
+    type literal struct {
+        A string `json:"a,default=\"x,required\""`
+    }
+    fv := ResolveStruct(reflect.TypeOf(literal{}))
+    if fv[0].Default != `"x,required"` || (fv[0].Opts & F_required) != 0 {
+        t.Fatalf("options are read from the default value: %s", fv[0].String())
+    }
+
+    type trailing struct {
+        A int `json:"a,default=1,omitempty"`
+    }
+    _, err := CheckedFields(reflect.TypeOf(trailing{}))
+    if err == nil || !strings.Contains(err.Error(), "must be the last one") {
+        t.Fatalf("unexpected error: %v", err)
+    }
+}
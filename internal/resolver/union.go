@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolver
+
+import (
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strconv"
+    "sync"
+    "unsafe"
+)
+
+// Union is an interface type whose values are told apart by a discriminator key
+// of the JSON object, such as `{"type":"circle",...}`.
+type Union struct {
+    Key   string
+    Types map[string]reflect.Type
+
+    // Probe is a struct with only a `*string` field of the key,
+    // decoding an object into it finds the discriminator
+    Probe reflect.Type
+
+    // tags are the `"key":"name"` texts to write for each member,
+    // which are empty for the members having a field of the key
+    tags  map[reflect.Type]string
+    names map[reflect.Type]string
+    keyed map[reflect.Type]bool
+
+    // keys are the string fields of the key in the members having them
+    keys  map[reflect.Type]FieldMeta
+}
+
+var unionCache sync.Map
+
+// RegisterUnion registers the members of the interface type vt by their
+// discriminator names, each member must be a struct or a pointer to one.
+// A field of the key in a member must be a string, which is written as is,
+// so it must hold the name of the member when the member is encoded.
+// Empty interfaces are not allowed, since they would take over all the `interface{}` values.
+func RegisterUnion(vt reflect.Type, key string, types map[string]reflect.Type) {
+    if vt.Kind() != reflect.Interface || vt.NumMethod() == 0 {
+        panic(fmt.Sprintf("sonic: union type %s is not a non-empty interface", vt))
+    }
+    u := &Union {
+        Key   : key,
+        Types : make(map[string]reflect.Type, len(types)),
+        tags  : make(map[reflect.Type]string, len(types)),
+        names : make(map[reflect.Type]string, len(types)),
+        keyed : make(map[reflect.Type]bool, len(types)),
+        keys  : make(map[reflect.Type]FieldMeta, len(types)),
+        Probe : reflect.StructOf([]reflect.StructField {{
+            Name : "Value",
+            Type : reflect.TypeOf((*string)(nil)),
+            Tag  : reflect.StructTag("json:" + strconv.Quote(key)),
+        }}),
+    }
+
+    for name, mt := range types {
+        st := mt
+        if st != nil && st.Kind() == reflect.Ptr {
+            st = st.Elem()
+        }
+        if st == nil || st.Kind() != reflect.Struct {
+            panic(fmt.Sprintf("sonic: member %q of union %s is not a struct or a pointer to struct", name, vt))
+        }
+        if _, ok := u.names[mt]; ok {
+            panic(fmt.Sprintf("sonic: member %s of union %s is registered twice", mt, vt))
+        }
+        u.Types[name] = mt
+        u.names[mt] = name
+        if f, ok := fieldOf(st, key); ok {
+            if f.Type.Kind() != reflect.String || (f.Opts & F_stringize) != 0 {
+                panic(fmt.Sprintf("sonic: field %q of member %s of union %s is not a string", key, mt, vt))
+            }
+            u.keyed[mt] = true
+            u.keys[mt] = f
+        } else {
+            k, _ := json.Marshal(key)
+            n, _ := json.Marshal(name)
+            u.tags[mt] = string(k) + ":" + string(n)
+        }
+    }
+    unionCache.Store(vt, u)
+}
+
+// FindUnion returns the union registered for vt, or nil if there is none.
+func FindUnion(vt reflect.Type) *Union {
+    if vt.Kind() != reflect.Interface {
+        return nil
+    }
+    if u, ok := unionCache.Load(vt); ok {
+        return u.(*Union)
+    }
+    return nil
+}
+
+// Name returns the discriminator name of the member type mt.
+func (self *Union) Name(mt reflect.Type) (string, bool) {
+    name, ok := self.names[mt]
+    return name, ok
+}
+
+// Tag returns the `"key":"name"` text to write for the member type mt,
+// which is empty if the member writes the key by itself.
+func (self *Union) Tag(mt reflect.Type) string {
+    return self.tags[mt]
+}
+
+// Keyed tells whether the member type mt has a field of the discriminator key.
+func (self *Union) Keyed(mt reflect.Type) bool {
+    return self.keyed[mt]
+}
+
+// KeyOf returns the field of the key in the struct at p of the keyed member type mt,
+// it returns false if the field is in a nil embedded pointer.
+func (self *Union) KeyOf(mt reflect.Type, p unsafe.Pointer) (string, bool) {
+    for _, o := range self.keys[mt].Path {
+        if p = unsafe.Pointer(uintptr(p) + o.Size); o.Kind == F_deref {
+            if p = *(*unsafe.Pointer)(p); p == nil {
+                return "", false
+            }
+        }
+    }
+    return *(*string)(p), true
+}
+
+func fieldOf(vt reflect.Type, name string) (FieldMeta, bool) {
+    for _, f := range ResolveStruct(vt) {
+        if f.Name == name {
+            return f, true
+        }
+    }
+    return FieldMeta{}, false
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `math/big`
+)
+
+// IsInteger tells whether the JSON number literal num has neither fractions nor exponents.
+func IsInteger(num string) bool {
+    for i := 0; i < len(num); i++ {
+        if c := num[i]; c == '.' || c == 'e' || c == 'E' {
+            return false
+        }
+    }
+    return num != ""
+}
+
+// SetBigInt sets z to the integer literal num, it fails for the other numbers.
+func SetBigInt(z *big.Int, num string) bool {
+    if !IsInteger(num) {
+        return false
+    }
+    _, ok := z.SetString(num, 10)
+    return ok
+}
+
+// SetBigFloat sets z to the number literal num, rounded to the precision of z.
+// A zero precision is raised to keep all the digits of num, and at least 64 bits.
+func SetBigFloat(z *big.Float, num string) bool {
+    if num == "" || (num[0] != '-' && (num[0] < '0' || num[0] > '9')) {
+        return false
+    }
+    if z.Prec() == 0 {
+        prec := uint(len(num)) * 4
+        if prec < 64 {
+            prec = 64
+        }
+        z.SetPrec(prec)
+    }
+    _, ok := z.SetString(num)
+    return ok
+}
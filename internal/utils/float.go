@@ -0,0 +1,31 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `math`
+)
+
+// ParseNaNInf parses the JSON strings `"NaN"`, `"Infinity"` and `"-Infinity"`.
+func ParseNaNInf(raw string) (float64, bool) {
+    switch raw {
+        case `"NaN"`       : return math.NaN(), true
+        case `"Infinity"`  : return math.Inf(1), true
+        case `"-Infinity"` : return math.Inf(-1), true
+        default            : return 0, false
+    }
+}
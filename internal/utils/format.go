@@ -0,0 +1,83 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `encoding/base64`
+    `encoding/hex`
+    `strconv`
+    `strings`
+    `time`
+    `unsafe`
+
+    `github.com/bytedance/sonic/internal/resolver`
+)
+
+// DecodeFormat decodes the JSON value raw into vp in the format f of a field, it fails
+// if raw is not of the JSON type of the format. Invalid strings are returned as errors.
+func DecodeFormat(f resolver.Format, vp unsafe.Pointer, raw string) (bool, error) {
+    switch f.Kind {
+        case resolver.FormatTime: {
+            t, ok, err := ParseTime(raw, TimeFormatID(f.Text))
+            if ok && err == nil {
+                *(*time.Time)(vp) = t
+            }
+            return ok, err
+        }
+        case resolver.FormatDuration: {
+            var d time.Duration
+            var err error
+            if raw[0] != '"' && IsInteger(raw) {
+                var v int64
+                v, err = strconv.ParseInt(raw, 10, 64)
+                d = time.Duration(v)
+            } else if str, ok := Unquote(raw); ok {
+                d, err = time.ParseDuration(str)
+            } else {
+                return false, nil
+            }
+            if err != nil {
+                return false, nil
+            }
+            *(*time.Duration)(vp) = d
+            return true, nil
+        }
+        case resolver.FormatBase64URL, resolver.FormatHex: {
+            str, ok := Unquote(raw)
+            if !ok {
+                return false, nil
+            }
+            var b []byte
+            var err error
+            if f.Kind == resolver.FormatHex {
+                b, err = hex.DecodeString(str)
+            } else if strings.HasSuffix(str, "=") {
+                b, err = base64.URLEncoding.DecodeString(str)
+            } else {
+                b, err = base64.RawURLEncoding.DecodeString(str)
+            }
+            if err != nil {
+                return true, err
+            }
+            *(*[]byte)(vp) = b
+            return true, nil
+        }
+        default: {
+            return false, nil
+        }
+    }
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `reflect`
+    `strconv`
+    `unsafe`
+)
+
+var intBits = [...]int {
+    reflect.Int     : strconv.IntSize,
+    reflect.Int8    : 8,
+    reflect.Int16   : 16,
+    reflect.Int32   : 32,
+    reflect.Int64   : 64,
+    reflect.Uint    : strconv.IntSize,
+    reflect.Uint8   : 8,
+    reflect.Uint16  : 16,
+    reflect.Uint32  : 32,
+    reflect.Uint64  : 64,
+    reflect.Uintptr : 32 << (^uintptr(0) >> 63),
+}
+
+// DecodeQuotedInt decodes the JSON string raw of an integer, like `"-12"`, into the
+// integer of kind k at vp. It fails if raw is not an integer string in the range of k.
+func DecodeQuotedInt(k reflect.Kind, vp unsafe.Pointer, raw string) bool {
+    str, ok := Unquote(raw)
+    if !ok || !isDecimal(str) {
+        return false
+    }
+
+    /* signed integers */
+    if k <= reflect.Int64 {
+        v, err := strconv.ParseInt(str, 10, intBits[k])
+        if err != nil {
+            return false
+        }
+        switch k {
+            case reflect.Int   : *(*int)(vp) = int(v)
+            case reflect.Int8  : *(*int8)(vp) = int8(v)
+            case reflect.Int16 : *(*int16)(vp) = int16(v)
+            case reflect.Int32 : *(*int32)(vp) = int32(v)
+            default            : *(*int64)(vp) = v
+        }
+        return true
+    }
+
+    /* unsigned integers */
+    v, err := strconv.ParseUint(str, 10, intBits[k])
+    if err != nil {
+        return false
+    }
+    switch k {
+        case reflect.Uint    : *(*uint)(vp) = uint(v)
+        case reflect.Uint8   : *(*uint8)(vp) = uint8(v)
+        case reflect.Uint16  : *(*uint16)(vp) = uint16(v)
+        case reflect.Uint32  : *(*uint32)(vp) = uint32(v)
+        case reflect.Uintptr : *(*uintptr)(vp) = uintptr(v)
+        default              : *(*uint64)(vp) = v
+    }
+    return true
+}
+
+// isDecimal checks the JSON integer grammar, which has no plus sign and no leading zeros.
+func isDecimal(s string) bool {
+    if s != "" && s[0] == '-' {
+        s = s[1:]
+    }
+    if s == "" || s[0] == '0' && len(s) > 1 {
+        return false
+    }
+    for i := 0; i < len(s); i++ {
+        if s[i] < '0' || s[i] > '9' {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+    `encoding/json`
+    `fmt`
+    `strconv`
+    `strings`
+    `sync`
+    `time`
+)
+
+// The time formats are kept in the options as ids of TimeFormatBits bits,
+// the custom layouts are registered on their first use and follow the builtin ones.
+const (
+    TimeRFC3339Nano uint64 = iota
+    TimeUnix
+    TimeUnixMilli
+    timeCustom
+
+    TimeFormatBits = 12
+    TimeFormatMask = 1 << TimeFormatBits - 1
+)
+
+// Names of the builtin time formats other than RFC 3339.
+const (
+    TimeFormatUnix      = "unix"
+    TimeFormatUnixMilli = "unixmilli"
+)
+
+var (
+    timeLock    sync.RWMutex
+    timeIDs     = map[string]uint64{}
+    timeLayouts []string
+)
+
+// TimeFormatID returns the id of the format, which is either empty or time.RFC3339Nano
+// for the default format, TimeFormatUnix, TimeFormatUnixMilli, or a custom layout.
+func TimeFormatID(format string) uint64 {
+    switch format {
+        case "", time.RFC3339Nano : return TimeRFC3339Nano
+        case TimeFormatUnix       : return TimeUnix
+        case TimeFormatUnixMilli  : return TimeUnixMilli
+    }
+
+    timeLock.RLock()
+    id, ok := timeIDs[format]
+    timeLock.RUnlock()
+    if ok {
+        return id
+    }
+
+    timeLock.Lock()
+    defer timeLock.Unlock()
+    if id, ok = timeIDs[format]; ok {
+        return id
+    }
+    id = timeCustom + uint64(len(timeLayouts))
+    if id > TimeFormatMask {
+        panic(fmt.Sprintf("sonic: too many time layouts, %q is not registered", format))
+    }
+    timeIDs[format] = id
+    timeLayouts = append(timeLayouts, format)
+    return id
+}
+
+// TimeLayout returns the layout of the time format id, which is time.RFC3339Nano
+// for the unix formats.
+func TimeLayout(id uint64) string {
+    if id < timeCustom {
+        return time.RFC3339Nano
+    }
+    timeLock.RLock()
+    defer timeLock.RUnlock()
+    return timeLayouts[id - timeCustom]
+}
+
+// ParseTime parses the JSON value raw of the time format id, strings are parsed by the
+// layout, and integers are accepted by the unix formats. It fails if raw is neither of them.
+func ParseTime(raw string, id uint64) (time.Time, bool, error) {
+    if raw == "" {
+        return time.Time{}, false, nil
+    }
+
+    /* the unix timestamps */
+    if c := raw[0]; c == '-' || (c >= '0' && c <= '9') {
+        if id != TimeUnix && id != TimeUnixMilli || !IsInteger(raw) {
+            return time.Time{}, false, nil
+        }
+        v, err := strconv.ParseInt(raw, 10, 64)
+        if err != nil {
+            return time.Time{}, false, nil
+        }
+        if id == TimeUnix {
+            return time.Unix(v, 0), true, nil
+        } else {
+            return time.Unix(v / 1e3, v % 1e3 * 1e6), true, nil
+        }
+    }
+
+    /* the formatted strings */
+    str, ok := Unquote(raw)
+    if !ok {
+        return time.Time{}, false, nil
+    }
+    layout := time.RFC3339
+    if id >= timeCustom {
+        layout = TimeLayout(id)
+    }
+    t, err := time.Parse(layout, str)
+    return t, true, err
+}
+
+// Unquote returns the content of the JSON string raw, it fails if raw is not a string.
+func Unquote(raw string) (string, bool) {
+    if len(raw) < 2 || raw[0] != '"' {
+        return "", false
+    }
+    str := raw[1:len(raw) - 1]
+    if strings.IndexByte(str, '\\') >= 0 {
+        if err := json.Unmarshal([]byte(raw), &str); err != nil {
+            return "", false
+        }
+    }
+    return str, true
+}
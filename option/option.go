@@ -29,6 +29,10 @@ var (
     // LimitBufferSize indicates the max pool buffer size, in case of OOM.
     // See issue https://github.com/bytedance/sonic/issues/614
     LimitBufferSize uint = 1024 * 1024
+
+    // CycleDetectionDepth is the nesting depth from which the encoder starts
+    // tracking the values being encoded when cycle detection is enabled.
+    CycleDetectionDepth uint = 1000
 )
 
 // CompileOptions includes all options for encoder or decoder compiler.
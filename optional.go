@@ -0,0 +1,130 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sonic
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bytedance/sonic/internal/resolver"
+)
+
+// Optional is a value that is either absent, an explicit null, or set.
+//
+// Decoding leaves absent fields untouched, so the zero Optional reports
+// an absent value; `null` marks it as null, and any other JSON value sets it.
+// Encoding writes `null` unless the value is set, and `omitempty` or
+// `omitzero` omit the field only when the value is absent.
+//
+// The sonic encoder and decoder handle Optional natively, its MarshalJSON
+// and UnmarshalJSON are only used by other JSON libraries.
+type Optional[T any] struct {
+	_     resolver.OptionalTag
+	set   bool
+	null  bool
+	value T
+}
+
+// Some returns an Optional set to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{set: true, value: v}
+}
+
+// Null returns an Optional holding an explicit null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{null: true}
+}
+
+// Get returns the value and whether it is set.
+func (o Optional[T]) Get() (T, bool) {
+	if !o.set {
+		var zero T
+		return zero, false
+	}
+	return o.value, true
+}
+
+// Or returns the value if it is set, otherwise def.
+func (o Optional[T]) Or(def T) T {
+	if !o.set {
+		return def
+	}
+	return o.value
+}
+
+// IsSet tells whether the value is set.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull tells whether the value is an explicit null.
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// IsPresent tells whether the value is either set or null.
+func (o Optional[T]) IsPresent() bool {
+	return o.set || o.null
+}
+
+// IsZero tells whether the value is absent, which `omitzero` relies on.
+func (o Optional[T]) IsZero() bool {
+	return !o.set && !o.null
+}
+
+// Set sets the value to v.
+func (o *Optional[T]) Set(v T) {
+	*o = Some(v)
+}
+
+// SetNull sets the value to an explicit null.
+func (o *Optional[T]) SetNull() {
+	*o = Null[T]()
+}
+
+// Clear makes the value absent.
+func (o *Optional[T]) Clear() {
+	*o = Optional[T]{}
+}
+
+// MarshalJSON writes the value, or `null` if it is not set.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON sets the value from data, or marks it as null.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if o == nil {
+		return errors.New("sonic.Optional: UnmarshalJSON on nil pointer")
+	}
+	if string(data) == "null" {
+		o.SetNull()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Set(v)
+	return nil
+}
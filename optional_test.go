@@ -0,0 +1,96 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sonic
+
+import (
+    `encoding/json`
+    `testing`
+
+    `github.com/stretchr/testify/assert`
+    `github.com/stretchr/testify/require`
+)
+
+type optionalAddr struct {
+    City Optional[string] `json:"city,omitempty"`
+}
+
+type optionalPatch struct {
+    Name  Optional[string]         `json:"name,omitempty"`
+    Age   Optional[int]            `json:"age,omitzero"`
+    Addr  Optional[optionalAddr]   `json:"addr,omitempty"`
+    Tags  Optional[[]string]       `json:"tags"`
+    Ptr   *Optional[float64]       `json:"ptr,omitempty"`
+    List  []Optional[int]          `json:"list,omitempty"`
+}
+
+func TestOptional_Decode(t *testing.T) {
+    src := `{"name":"x","age":null,"addr":{"city":null},"list":[1,null]}`
+    for _, api := range []API{ConfigDefault, Config{CollectErrors: true}.Froze(), ConfigStd} {
+        var v optionalPatch
+        v.Tags.Set([]string{"kept"})
+        require.NoError(t, api.UnmarshalFromString(src, &v))
+
+        name, ok := v.Name.Get()
+        assert.True(t, ok)
+        assert.Equal(t, "x", name)
+        assert.True(t, v.Age.IsNull())
+        assert.False(t, v.Age.IsSet())
+        assert.True(t, v.Addr.IsSet())
+        addr, _ := v.Addr.Get()
+        assert.True(t, addr.City.IsNull())
+        assert.Equal(t, []string{"kept"}, v.Tags.Or(nil))
+        assert.Nil(t, v.Ptr)
+        assert.Equal(t, []Optional[int]{Some(1), Null[int]()}, v.List)
+    }
+
+    /* mismatched values are reported as usual */
+    var v optionalPatch
+    err := Unmarshal([]byte(`{"age":"x"}`), &v)
+    require.Error(t, err)
+}
+
+func TestOptional_Encode(t *testing.T) {
+    f := 1.5
+    cases := []struct{
+        v   optionalPatch
+        exp string
+    }{
+        {optionalPatch{}, `{"tags":null}`},
+        {optionalPatch{Name: Some("x"), Age: Null[int](), Tags: Some([]string{"a"})}, `{"name":"x","age":null,"tags":["a"]}`},
+        {optionalPatch{Addr: Some(optionalAddr{City: Null[string]()}), Ptr: &Optional[float64]{}}, `{"addr":{"city":null},"tags":null,"ptr":null}`},
+        {optionalPatch{Ptr: func() *Optional[float64] { o := Some(f); return &o }(), List: []Optional[int]{Some(1), {}, Null[int]()}}, `{"tags":null,"ptr":1.5,"list":[1,null,null]}`},
+    }
+    for _, c := range cases {
+        out, err := Marshal(c.v)
+        require.NoError(t, err)
+        assert.Equal(t, c.exp, string(out))
+
+        /* the marshalers keep the same states for other libraries,
+         * except for the pointer that is decoded as nil from null */
+        if c.v.Ptr != nil && !c.v.Ptr.IsSet() {
+            continue
+        }
+        var v optionalPatch
+        require.NoError(t, json.Unmarshal(out, &v))
+        out, err = Marshal(v)
+        require.NoError(t, err)
+        assert.Equal(t, c.exp, string(out))
+    }
+}
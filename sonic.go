@@ -21,8 +21,11 @@
 package sonic
 
 import (
+    `bytes`
+    `encoding/json`
     `io`
     `reflect`
+    `strconv`
 
     `github.com/bytedance/sonic/decoder`
     `github.com/bytedance/sonic/encoder`
@@ -46,6 +49,9 @@ func (cfg Config) Froze() API {
     if cfg.EscapeHTML {
         api.encoderOpts |= encoder.EscapeHTML
     }
+    if cfg.EscapeNonASCII {
+        api.encoderOpts |= encoder.EscapeNonASCII
+    }
     if cfg.SortMapKeys {
         api.encoderOpts |= encoder.SortMapKeys
     }
@@ -70,6 +76,81 @@ func (cfg Config) Froze() API {
     if cfg.EncodeNullForInfOrNan {
         api.encoderOpts |= encoder.EncodeNullForInfOrNan
     }
+    if cfg.DetectCycles {
+        api.encoderOpts |= encoder.DetectCycles
+    }
+    if cfg.TimeFormat != "" {
+        api.encoderOpts |= encoder.TimeFormat(cfg.TimeFormat)
+        api.decoderOpts |= decoder.OptionTimeFormat(cfg.TimeFormat)
+    }
+    if cfg.DurationFormat == DurationFormatString {
+        api.encoderOpts |= encoder.DurationString
+        api.decoderOpts |= decoder.OptionDurationString
+    } else if cfg.DurationFormat != "" {
+        panic("sonic: unknown DurationFormat " + strconv.Quote(cfg.DurationFormat))
+    }
+    if cfg.FloatPrecision > 0 && cfg.FloatSignificantDigits > 0 {
+        panic("sonic: FloatPrecision and FloatSignificantDigits are both set")
+    }
+    if cfg.FloatPrecision > 0 {
+        api.encoderOpts |= encoder.FloatPrecision(cfg.FloatPrecision)
+    }
+    if cfg.FloatSignificantDigits > 0 {
+        api.encoderOpts |= encoder.FloatSignificantDigits(cfg.FloatSignificantDigits)
+    }
+    if cfg.FloatNotation == FloatNotationPlain {
+        api.encoderOpts |= encoder.FloatPlain
+    } else if cfg.FloatNotation == FloatNotationExponent {
+        api.encoderOpts |= encoder.FloatExponent
+    } else if cfg.FloatNotation != "" {
+        panic("sonic: unknown FloatNotation " + strconv.Quote(cfg.FloatNotation))
+    }
+    if cfg.FloatTrailingZero {
+        api.encoderOpts |= encoder.FloatTrailingZero
+    }
+    if cfg.NaNInfString {
+        api.encoderOpts |= encoder.FloatNaNInfString
+        api.decoderOpts |= decoder.OptionNaNInfString
+    }
+    if cfg.Int64Format == Int64FormatString {
+        api.encoderOpts |= encoder.Int64String
+    } else if cfg.Int64Format == Int64FormatUnsafeString {
+        api.encoderOpts |= encoder.UnsafeInt64String
+    } else if cfg.Int64Format != "" {
+        panic("sonic: unknown Int64Format " + strconv.Quote(cfg.Int64Format))
+    }
+    if cfg.Int64Format != "" {
+        api.decoderOpts |= decoder.OptionQuotedInt
+    }
+    if cfg.UnsupportedTypes == UnsupportedTypesNull {
+        api.encoderOpts |= encoder.UnsupportedTypeNull
+    } else if cfg.UnsupportedTypes == UnsupportedTypesSkip {
+        api.encoderOpts |= encoder.UnsupportedTypeSkip
+    } else if cfg.UnsupportedTypes != "" && cfg.UnsupportedTypes != UnsupportedTypesError {
+        panic("sonic: unknown UnsupportedTypes " + strconv.Quote(cfg.UnsupportedTypes))
+    }
+    if cfg.ErrorString {
+        api.encoderOpts |= encoder.ErrorString
+    }
+    if cfg.ComplexArray {
+        api.encoderOpts |= encoder.ComplexArray
+    }
+    if cfg.Canonical {
+        api.encoderOpts |= encoder.Canonical
+    }
+    if cfg.RedactMode != "" && cfg.RedactMode != RedactModeMask &&
+        cfg.RedactMode != RedactModeHash && cfg.RedactMode != RedactModeOmit {
+        panic("sonic: unknown RedactMode " + strconv.Quote(cfg.RedactMode))
+    }
+    if cfg.Redact {
+        if cfg.RedactMode == RedactModeHash {
+            api.encoderOpts |= encoder.RedactHash
+        } else if cfg.RedactMode == RedactModeOmit {
+            api.encoderOpts |= encoder.RedactOmit
+        } else {
+            api.encoderOpts |= encoder.Redact
+        }
+    }
 
     // configure decoder options:
     if cfg.NoValidateJSONSkip {
@@ -81,6 +162,9 @@ func (cfg Config) Froze() API {
     if cfg.UseNumber {
         api.decoderOpts |= decoder.OptionUseNumber
     }
+    if cfg.UseBigInt {
+        api.decoderOpts |= decoder.OptionUseBigInt
+    }
     if cfg.DisallowUnknownFields {
         api.decoderOpts |= decoder.OptionDisableUnknown
     }
@@ -90,23 +174,51 @@ func (cfg Config) Froze() API {
     if cfg.ValidateString {
         api.decoderOpts |= decoder.OptionValidateString
     }
+    if cfg.CollectErrors {
+        api.decoderOpts |= decoder.OptionCollectErrors
+    }
+    if cfg.WeaklyTyped {
+        api.decoderOpts |= decoder.OptionWeaklyTyped
+    }
     return api
 }
 
+// With is implemented by sonic
+func (cfg frozenConfig) With(opts ...Option) API {
+    if len(opts) == 0 {
+        return &cfg
+    }
+    return cfg.Config.with(opts).Froze()
+}
+
 // Marshal is implemented by sonic
 func (cfg frozenConfig) Marshal(val interface{}) ([]byte, error) {
+    if cfg.FieldMask != nil {
+        return encoder.EncodeMasked(val, cfg.FieldMask, cfg.encoderOpts)
+    }
     return encoder.Encode(val, cfg.encoderOpts)
 }
 
 // MarshalToString is implemented by sonic
 func (cfg frozenConfig) MarshalToString(val interface{}) (string, error) {
-    buf, err := encoder.Encode(val, cfg.encoderOpts)
+    buf, err := cfg.Marshal(val)
     return rt.Mem2Str(buf), err
 }
 
 // MarshalIndent is implemented by sonic
 func (cfg frozenConfig) MarshalIndent(val interface{}, prefix, indent string) ([]byte, error) {
-    return encoder.EncodeIndented(val, prefix, indent, cfg.encoderOpts)
+    if cfg.FieldMask == nil {
+        return encoder.EncodeIndented(val, prefix, indent, cfg.encoderOpts)
+    }
+    buf, err := encoder.EncodeMasked(val, cfg.FieldMask, cfg.encoderOpts)
+    if err != nil {
+        return nil, err
+    }
+    out := bytes.NewBuffer(make([]byte, 0, len(buf) * 2))
+    if err := json.Indent(out, buf, prefix, indent); err != nil {
+        return nil, err
+    }
+    return out.Bytes(), nil
 }
 
 // UnmarshalFromString is implemented by sonic
@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sonic
+
+import (
+	"reflect"
+
+	"github.com/bytedance/sonic/internal/resolver"
+)
+
+// RegisterUnion registers the concrete types of the interface T, by the values
+// of the discriminator key in their JSON objects, for example:
+//
+//     sonic.RegisterUnion[Shape]("type", map[string]Shape{
+//         "circle": Circle{},
+//         "rect":   &Rect{},
+//     })
+//
+// Values of type T are then decoded into the type named by the discriminator,
+// wherever the key is in the object, and encoded with the discriminator as their
+// first key, unless the type has a field of the key by itself, which must be a string
+// holding the registered name, or the encoding fails. The key is never an unknown
+// field for DisallowUnknownFields.
+//
+// Since the discriminator may come after the other keys, the JIT decoder parses
+// each object of T twice, once for the discriminator and once for the member,
+// and copies the object for DisallowUnknownFields if the member has no field of the key.
+//
+// The types are only known by their static type T, such as the type of fields,
+// elements or pointers, so a T passed to Marshal as interface{} has no discriminator.
+// Each concrete type must be a struct or a pointer to one, and the union must be
+// registered before T is encoded or decoded for the first time, usually in init().
+// It has no effect on the fallback implementation (encoding/json).
+func RegisterUnion[T any](key string, types map[string]T) {
+	vt := reflect.TypeOf((*T)(nil)).Elem()
+	ts := make(map[string]reflect.Type, len(types))
+	for name, v := range types {
+		ts[name] = reflect.TypeOf(v)
+	}
+	resolver.RegisterUnion(vt, key, ts)
+}
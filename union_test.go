@@ -0,0 +1,160 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+ * Copyright 2024 ByteDance Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sonic
+
+import (
+    `testing`
+
+    `github.com/bytedance/sonic/decoder`
+    `github.com/stretchr/testify/assert`
+    `github.com/stretchr/testify/require`
+)
+
+type unionShape interface {
+    Area() float64
+}
+
+type unionCircle struct {
+    R float64 `json:"r"`
+}
+
+type unionRect struct {
+    W, H float64
+}
+
+type unionLabel struct {
+    Kind string `json:"kind"`
+    Text string `json:"text"`
+}
+
+func (c unionCircle) Area() float64 { return 3 * c.R * c.R }
+func (r *unionRect) Area() float64 { return r.W * r.H }
+func (l unionLabel) Area() float64 { return 0 }
+
+type unionScene struct {
+    Main   unionShape            `json:"main"`
+    Shapes []unionShape          `json:"shapes"`
+    ByName map[string]unionShape `json:"by_name,omitempty"`
+}
+
+func init() {
+    RegisterUnion[unionShape]("kind", map[string]unionShape{
+        "circle": unionCircle{},
+        "rect":   &unionRect{},
+        "label":  unionLabel{},
+    })
+}
+
+func TestUnion_Decode(t *testing.T) {
+    src := `{"main":{"r":2,"kind":"circle"},"shapes":[{"kind":"rect","W":2,"H":3},null,{"text":"x","kind":"label"}],"by_name":{"c":{"KIND":"circle","r":1}}}`
+    for _, api := range []API{ConfigDefault, Config{DisallowUnknownFields: true}.Froze(), Config{CollectErrors: true}.Froze()} {
+        var v unionScene
+        require.NoError(t, api.UnmarshalFromString(src, &v))
+        assert.Equal(t, unionCircle{R: 2}, v.Main)
+        assert.Equal(t, []unionShape{&unionRect{W: 2, H: 3}, nil, unionLabel{Kind: "label", Text: "x"}}, v.Shapes)
+        assert.Equal(t, unionCircle{R: 1}, v.ByName["c"])
+    }
+
+    var v unionScene
+    err := Unmarshal([]byte(`{"main":{"r":2}}`), &v)
+    var ue *decoder.UnionError
+    require.ErrorAs(t, err, &ue)
+    assert.True(t, ue.Missing)
+    assert.Equal(t, "$.main", ue.Path())
+
+    err = Unmarshal([]byte(`{"shapes":[{"kind":"hex"}]}`), &v)
+    require.ErrorAs(t, err, &ue)
+    assert.Equal(t, "hex", ue.Value)
+    assert.Equal(t, "$.shapes[0]", ue.Path())
+
+    /* collected errors keep the message of the union */
+    err = Config{CollectErrors: true}.Froze().Unmarshal([]byte(`{"main":{"r":2},"shapes":[{"kind":"hex"}]}`), &v)
+    var errs decoder.DecodeErrors
+    require.ErrorAs(t, err, &errs)
+    require.Len(t, errs, 2)
+    assert.Equal(t, `json: missing discriminator "kind" of union sonic.unionShape at $.main (index 8)`, errs[0].Error())
+    assert.Equal(t, `json: unknown discriminator "hex" of union sonic.unionShape at $.shapes[0] (index 26)`, errs[1].Error())
+    require.ErrorAs(t, errs[1], &ue)
+
+    err = Unmarshal([]byte(`{"main":{"kind":1}}`), &v)
+    require.Error(t, err)
+    err = Unmarshal([]byte(`{"main":{"kind":"circle","r":1,"x":1}}`), &v)
+    require.NoError(t, err)
+    err = Config{DisallowUnknownFields: true}.Froze().Unmarshal([]byte(`{"main":{"kind":"circle","r":1,"x":1}}`), &v)
+    require.Error(t, err)
+}
+
+func TestUnion_Encode(t *testing.T) {
+    v := unionScene{
+        Main:   unionCircle{R: 2},
+        Shapes: []unionShape{&unionRect{W: 2, H: 3}, nil, unionLabel{Kind: "label", Text: "x"}, (*unionRect)(nil)},
+    }
+    out, err := Marshal(v)
+    require.NoError(t, err)
+    assert.Equal(t, `{"main":{"kind":"circle","r":2},"shapes":[{"kind":"rect","W":2,"H":3},null,{"kind":"label","text":"x"},null]}`, string(out))
+
+    var w unionScene
+    require.NoError(t, Unmarshal(out, &w))
+    assert.Equal(t, v.Main, w.Main)
+    assert.Equal(t, v.Shapes[:3], w.Shapes[:3])
+
+    /* empty members still get their discriminators */
+    out, err = Marshal(unionScene{Main: unionCircle{}, ByName: map[string]unionShape{"e": &unionRect{}}})
+    require.NoError(t, err)
+    assert.Equal(t, `{"main":{"kind":"circle","r":0},"shapes":null,"by_name":{"e":{"kind":"rect","W":0,"H":0}}}`, string(out))
+
+    /* unregistered members are rejected */
+    _, err = Marshal(unionScene{Main: unionOther{}})
+    require.Error(t, err)
+}
+
+type unionOther struct{}
+
+func (unionOther) Area() float64 { return 0 }
+
+type unionBad interface {
+    bad()
+}
+
+type unionBadKind struct {
+    Kind int `json:"kind"`
+}
+
+func (unionBadKind) bad() {}
+
+func TestUnion_EncodeKeyed(t *testing.T) {
+    v := unionScene{Main: unionLabel{Text: "x"}}
+    _, err := Marshal(&v)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), `"" instead of "label"`)
+
+    v.Main = unionLabel{Kind: "circle"}
+    _, err = Marshal(&v)
+    require.Error(t, err)
+
+    v.Main = unionLabel{Kind: "label"}
+    out, err := Marshal(&v)
+    require.NoError(t, err)
+    assert.Equal(t, `{"main":{"kind":"label","text":""},"shapes":null}`, string(out))
+
+    assert.Panics(t, func() {
+        RegisterUnion[unionBad]("kind", map[string]unionBad{"bad": unionBadKind{}})
+    })
+}